@@ -0,0 +1,230 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/service"
+	"metalflow/pkg/utils"
+)
+
+// requireApproval creates a change request when the action on the nodes matches an approval policy,
+// the action is executed after the change request is approved. The reason is read from the query.
+func requireApproval(c *gin.Context, s service.MysqlService, action string, nodeIds []uint, payload any) bool {
+	cr, err := s.RequestApproval(action, nodeIds, payload, c.Query("reason"))
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return true
+	}
+	if cr == nil {
+		return false
+	}
+	responseChangeRequest(cr)
+	return true
+}
+
+// responseChangeRequest responds the created change request which is waiting for approval.
+func responseChangeRequest(cr *models.SysChangeRequest) {
+	response.Result(response.Ok, fmt.Sprintf("the change request [%d] is waiting for approval", cr.Id), gin.H{
+		"changeRequest": cr,
+	})
+}
+
+// GetApprovalPolicies gets the approval policies.
+func GetApprovalPolicies(c *gin.Context) {
+	var req request.ApprovalPolicyListRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	policies, err := s.GetApprovalPolicies(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	var resp response.PageData
+	resp.PageInfo = req.PageInfo
+	resp.List = policies
+	response.SuccessWithData(resp)
+}
+
+// CreateApprovalPolicy creates an approval policy for the action type and the node label selector.
+func CreateApprovalPolicy(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.CreateApprovalPolicyRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	// params validate.
+	err = global.NewValidatorError(global.Validate.Struct(req), req.FieldTrans())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	// record current creator information.
+	req.Creator = user.Username
+
+	s := service.New(c)
+	policy, err := s.CreateApprovalPolicy(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(policy)
+}
+
+// UpdateApprovalPolicyById updates the approval policy.
+func UpdateApprovalPolicyById(c *gin.Context) {
+	var req request.UpdateApprovalPolicyRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	policyId := utils.Str2Uint(c.Param("policyId"))
+	if policyId == 0 {
+		response.FailWithMsg("the policyId is incorrect")
+		return
+	}
+	s := service.New(c)
+	err = s.UpdateApprovalPolicyById(policyId, &req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// BatchDeleteApprovalPolicyByIds deletes approval policies in batch.
+func BatchDeleteApprovalPolicyByIds(c *gin.Context) {
+	var req request.Req
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	err = s.DeleteByIds(req.GetUintIds(), new(models.SysApprovalPolicy))
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// GetChangeRequests gets the change requests, the stale pending ones are marked as expired.
+func GetChangeRequests(c *gin.Context) {
+	var req request.ChangeRequestListRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	list, err := s.GetChangeRequests(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	var resp response.PageData
+	resp.PageInfo = req.PageInfo
+	resp.List = list
+	response.SuccessWithData(resp)
+}
+
+// GetChangeRequestById gets the change request with its decisions.
+func GetChangeRequestById(c *gin.Context) {
+	requestId := utils.Str2Uint(c.Param("requestId"))
+	if requestId == 0 {
+		response.FailWithMsg("the requestId is incorrect")
+		return
+	}
+
+	s := service.New(c)
+	cr, err := s.GetChangeRequestById(requestId)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(cr)
+}
+
+// ApproveChangeRequest approves the change request and executes the action as the requester.
+func ApproveChangeRequest(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.ChangeDecisionRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	requestId := utils.Str2Uint(c.Param("requestId"))
+	if requestId == 0 {
+		response.FailWithMsg("the requestId is incorrect")
+		return
+	}
+	s := service.New(c)
+	cr, err := s.ApproveChangeRequest(requestId, &user, req.Comment)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(cr)
+}
+
+// RejectChangeRequest rejects the change request.
+func RejectChangeRequest(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.ChangeDecisionRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	requestId := utils.Str2Uint(c.Param("requestId"))
+	if requestId == 0 {
+		response.FailWithMsg("the requestId is incorrect")
+		return
+	}
+	s := service.New(c)
+	cr, err := s.RejectChangeRequest(requestId, &user, req.Comment)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(cr)
+}
+
+// CancelChangeRequest cancels the pending change request by the requester.
+func CancelChangeRequest(c *gin.Context) {
+	user := GetCurrentUser(c)
+	requestId := utils.Str2Uint(c.Param("requestId"))
+	if requestId == 0 {
+		response.FailWithMsg("the requestId is incorrect")
+		return
+	}
+	s := service.New(c)
+	err := s.CancelChangeRequest(requestId, user.Username)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
@@ -0,0 +1,211 @@
+package v1
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"io"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/service"
+	"metalflow/pkg/utils"
+	"net/http"
+)
+
+// GetCommandJobs gets the command jobs run on nodes, including reboot, shut, start and secure fix jobs.
+func GetCommandJobs(c *gin.Context) {
+	var req request.CommandJobListRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	jobs, err := s.GetCommandJobs(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	var resp response.PageData
+	resp.PageInfo = req.PageInfo
+	resp.List = jobs
+	response.SuccessWithData(resp)
+}
+
+// GetCommandJobById gets the command job with the output, exit code and duration of each node.
+func GetCommandJobById(c *gin.Context) {
+	jobId := utils.Str2Uint(c.Param("jobId"))
+	if jobId == 0 {
+		response.FailWithMsg("the jobId is incorrect")
+		return
+	}
+
+	s := service.New(c)
+	job, err := s.GetCommandJobById(jobId)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(job)
+}
+
+// CreateCommandJob runs a command or script on the selected nodes in background.
+func CreateCommandJob(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.CreateCommandJobRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	// params validate.
+	err = global.NewValidatorError(global.Validate.Struct(req), req.FieldTrans())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	// record current creator information.
+	req.Creator = user.Username
+
+	s := service.New(c)
+	ids, err := s.GetBatchNodeIds(req.GetUintIds(), req.Selector, req.Force)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	if requireApproval(c, s, models.ChangeActionCommand, ids, &req) {
+		return
+	}
+	job, err := s.CreateCommandJob(&req, ids)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(job)
+}
+
+// DownloadCommandJobOutput downloads the output of the command job as a text file, only the node when nodeId is given.
+func DownloadCommandJobOutput(c *gin.Context) {
+	jobId := utils.Str2Uint(c.Param("jobId"))
+	if jobId == 0 {
+		response.FailWithMsg("the jobId is incorrect")
+		return
+	}
+	nodeId := utils.Str2Uint(c.Query("nodeId"))
+
+	s := service.New(c)
+	data, err := s.GetCommandJobOutput(jobId, nodeId)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	filename := fmt.Sprintf("command-job-%d.log", jobId)
+	if nodeId > 0 {
+		filename = fmt.Sprintf("command-job-%d-node-%d.log", jobId, nodeId)
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", data)
+}
+
+// WatchCommandJobWs pushes the events of the command job over websocket as json, including the output lines of each node.
+// The connection is closed after the job is done, the events already produced are replayed first.
+func WatchCommandJobWs(c *gin.Context) {
+	jobId := utils.Str2Uint(c.Param("jobId"))
+	if jobId == 0 {
+		response.FailWithMsg("the jobId is incorrect")
+		return
+	}
+
+	s := service.New(c)
+	history, events, cancel, err := s.WatchCommandJob(jobId)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	defer cancel()
+
+	conn, err := upgrade.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		global.Log.Error("升级websocket连接失败", err)
+		return
+	}
+	defer func(conn *websocket.Conn) {
+		_ = conn.Close()
+	}(conn)
+	// 客户端断开时结束推送
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, e := conn.ReadMessage(); e != nil {
+				return
+			}
+		}
+	}()
+
+	for _, event := range history { //nolint:gocritic
+		if err = conn.WriteJSON(event); err != nil {
+			return
+		}
+	}
+	if events == nil {
+		return
+	}
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err = conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// WatchCommandJobSse pushes the events of the command job as server-sent events, the event name is the event type.
+func WatchCommandJobSse(c *gin.Context) {
+	jobId := utils.Str2Uint(c.Param("jobId"))
+	if jobId == 0 {
+		response.FailWithMsg("the jobId is incorrect")
+		return
+	}
+
+	s := service.New(c)
+	history, events, cancel, err := s.WatchCommandJob(jobId)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	defer cancel()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	for _, event := range history { //nolint:gocritic
+		c.SSEvent(event.Type, event)
+	}
+	c.Writer.Flush()
+	if events == nil {
+		return
+	}
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return event.Type != service.CommandJobEventDone
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/service"
+	"metalflow/pkg/utils"
+)
+
+// GetCredentials gets the ssh credentials, the passwords and private keys are never returned.
+func GetCredentials(c *gin.Context) {
+	var req request.CredentialListRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	credentials, err := s.GetCredentials(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	var resp response.PageData
+	resp.PageInfo = req.PageInfo
+	resp.List = credentials
+	response.SuccessWithData(resp)
+}
+
+// CreateCredential creates a ssh credential, the secrets are encrypted with the master key.
+func CreateCredential(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.CreateCredentialRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	// params validate.
+	err = global.NewValidatorError(global.Validate.Struct(req), req.FieldTrans())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	// record current creator information.
+	req.Creator = user.Username
+
+	s := service.New(c)
+	credential, err := s.CreateCredential(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(credential)
+}
+
+// UpdateCredentialById updates the ssh credential.
+func UpdateCredentialById(c *gin.Context) {
+	var req request.UpdateCredentialRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	credentialId := utils.Str2Uint(c.Param("credentialId"))
+	if credentialId == 0 {
+		response.FailWithMsg("the credentialId is incorrect")
+		return
+	}
+	s := service.New(c)
+	err = s.UpdateCredentialById(credentialId, &req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// BatchDeleteCredentialByIds deletes ssh credentials in batch.
+func BatchDeleteCredentialByIds(c *gin.Context) {
+	var req request.Req
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	err = s.DeleteByIds(req.GetUintIds(), new(models.SysCredential))
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"github.com/gin-gonic/gin"
+	"metalflow/models"
 	"metalflow/pkg/request"
 	"metalflow/pkg/response"
 	"metalflow/pkg/service"
@@ -22,6 +23,14 @@ func CreateCronShutTask(c *gin.Context) {
 	req.Creator = u.Username
 
 	s := service.New(c)
+	ids, err := s.GetCronShutTargetIds(0, req.NodeIds, &req.Selector)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	if requireApproval(c, s, models.ChangeActionCronShut, ids, service.ChangeCronShutPayload{Create: &req}) {
+		return
+	}
 	err = s.CreateCronShutNode(&req)
 	if err != nil {
 		response.FailWithMsg(err.Error())
@@ -74,6 +83,14 @@ func UpdateCronShutTaskById(c *gin.Context) {
 	}
 
 	s := service.New(c)
+	ids, err := s.GetCronShutTargetIds(shutId, req.NodeIds, req.Selector)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	if requireApproval(c, s, models.ChangeActionCronShut, ids, service.ChangeCronShutPayload{ShutId: shutId, Update: &req}) {
+		return
+	}
 	// update data.
 	err = s.UpdateCronShutNodeById(shutId, &req)
 	if err != nil {
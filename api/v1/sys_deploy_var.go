@@ -0,0 +1,100 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/service"
+	"metalflow/pkg/utils"
+)
+
+// GetDeployVars gets the variables used in the worker deploy command templates.
+func GetDeployVars(c *gin.Context) {
+	var req request.DeployVarListRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	vars, err := s.GetDeployVars(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	var resp response.PageData
+	resp.PageInfo = req.PageInfo
+	resp.List = vars
+	response.SuccessWithData(resp)
+}
+
+// CreateDeployVar creates a global variable, or a region variable overriding the global one.
+func CreateDeployVar(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.CreateDeployVarRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	// params validate.
+	err = global.NewValidatorError(global.Validate.Struct(req), req.FieldTrans())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	req.Creator = user.Username
+	s := service.New(c)
+	err = s.CreateDeployVar(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// UpdateDeployVarById updates the name, region or value of a variable.
+func UpdateDeployVarById(c *gin.Context) {
+	var req request.UpdateDeployVarRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	varId := utils.Str2Uint(c.Param("varId"))
+	if varId == 0 {
+		response.FailWithMsg("the varId is incorrect")
+		return
+	}
+	s := service.New(c)
+	err = s.UpdateDeployVarById(varId, &req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// BatchDeleteDeployVarByIds deletes variables in batch.
+func BatchDeleteDeployVarByIds(c *gin.Context) {
+	var req request.Req
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	err = s.DeleteByIds(req.GetUintIds(), new(models.SysDeployVar))
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
@@ -33,7 +33,7 @@ func GetLabels(c *gin.Context) {
 	response.SuccessWithData(resp)
 }
 
-// CreateLabel creates machine labels.
+// CreateLabel creates machine labels, the name can be given as "key=value".
 func CreateLabel(c *gin.Context) {
 	user := GetCurrentUser(c)
 	// bind request body to struct.
@@ -50,6 +50,12 @@ func CreateLabel(c *gin.Context) {
 		response.FailWithMsg(err.Error())
 		return
 	}
+	// split "key=value" names into key and value.
+	err = req.Normalize()
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
 	req.Creator = user.Username
 	s := service.New(c)
 	err = s.Create(req, new(models.SysLabel))
@@ -60,7 +66,7 @@ func CreateLabel(c *gin.Context) {
 	response.Success()
 }
 
-// UpdateLabelById updates label name.
+// UpdateLabelById updates label key and value.
 func UpdateLabelById(c *gin.Context) {
 	var req request.UpdateLabelRequestStruct
 	err := c.ShouldBind(&req)
@@ -68,6 +74,11 @@ func UpdateLabelById(c *gin.Context) {
 		response.FailWithMsg("params binding failed, please check the data type")
 		return
 	}
+	err = req.Normalize()
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
 
 	labelId := utils.Str2Uint(c.Param("labelId"))
 	s := service.New(c)
@@ -1,11 +1,15 @@
 package v1
 
 import (
+	"fmt"
+	"io"
+	"metalflow/models"
 	"metalflow/pkg/global"
 	"metalflow/pkg/request"
 	"metalflow/pkg/response"
 	"metalflow/pkg/service"
 	"metalflow/pkg/utils"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
@@ -96,7 +100,7 @@ func UpdateNodeById(c *gin.Context) {
 
 // BatchDeleteNodeByIds batch delete node by nodeId.
 func BatchDeleteNodeByIds(c *gin.Context) {
-	var req request.Req
+	var req request.NodeBatchRequestStruct
 	err := c.ShouldBind(&req)
 	if err != nil {
 		response.FailWithMsg("Parameter binding failed, please check the data type")
@@ -104,8 +108,16 @@ func BatchDeleteNodeByIds(c *gin.Context) {
 	}
 
 	s := service.New(c)
+	ids, err := s.GetBatchNodeIds(req.GetUintIds(), req.Selector, req.Force)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	if requireApproval(c, s, models.ChangeActionNodeDelete, ids, req) {
+		return
+	}
 	// delete data.
-	err = s.DeleteNodeByIds(req.GetUintIds())
+	err = s.DeleteNodeByIds(ids)
 	if err != nil {
 		response.FailWithMsg(err.Error())
 		return
@@ -132,7 +144,7 @@ func RefreshNodeInfo(c *gin.Context) {
 
 // BatchRebootNodeByIds batch reboot nodes by node id in database.
 func BatchRebootNodeByIds(c *gin.Context) {
-	var req request.Req
+	var req request.NodeRebootRequestStruct
 	err := c.ShouldBind(&req)
 	if err != nil {
 		response.FailWithMsg("参数绑定失败, 请检查数据类型")
@@ -140,11 +152,203 @@ func BatchRebootNodeByIds(c *gin.Context) {
 	}
 
 	s := service.New(c)
-	// delete node data.
-	err = s.BatchRebootNodesByIds(req.GetUintIds())
+	ids, err := s.GetBatchNodeIds(req.GetUintIds(), req.Selector, req.Force)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	if requireApproval(c, s, models.ChangeActionReboot, ids, req) {
+		return
+	}
+	// reboot nodes, the job is returned when rebooting batch by batch in background.
+	job, err := s.BatchRebootNodesByIds(ids, req.Strategy)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	if job != nil {
+		response.SuccessWithData(job)
+		return
+	}
+	response.Success()
+}
+
+// GetNodeMetricsHistory get the downsampled metrics history of the node over a time range.
+func GetNodeMetricsHistory(c *gin.Context) {
+	var req request.NodeMetricsHistoryRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("parameter binding failed, please check the data type")
+		return
+	}
+
+	// get the nodeId in the path.
+	nodeId := utils.Str2Uint(c.Param("nodeId"))
+	if nodeId == 0 {
+		response.FailWithMsg("the nodeId is incorrect")
+		return
+	}
+
+	s := service.New(c)
+	history, err := s.GetNodeMetricsHistory(nodeId, &req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(history)
+}
+
+// GetNodeChanges get hardware and configuration changes of one node, or of the whole fleet without nodeId.
+func GetNodeChanges(c *gin.Context) {
+	var req request.NodeChangeListRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("parameter binding failed, please check the data type")
+		return
+	}
+
+	// the nodeId in the path is optional.
+	var nodeId uint
+	if c.Param("nodeId") != "" {
+		nodeId = utils.Str2Uint(c.Param("nodeId"))
+		if nodeId == 0 {
+			response.FailWithMsg("the nodeId is incorrect")
+			return
+		}
+	}
+
+	s := service.New(c)
+	changes, err := s.GetNodeChanges(nodeId, &req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	// convert to ResponseStruct, hide some fields.
+	var respStruct []response.NodeChangeListResponseStruct
+	utils.Struct2StructByJson(changes, &respStruct)
+	var resp response.PageData
+	resp.PageInfo = req.PageInfo
+	resp.List = respStruct
+	response.SuccessWithData(resp)
+}
+
+// EnterNodeMaintenance puts nodes into maintenance mode,
+// alert mails and scheduled shut/start jobs are suppressed until it exits or expires.
+func EnterNodeMaintenance(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.NodeMaintenanceRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+	err = global.NewValidatorError(global.Validate.Struct(req), req.FieldTrans())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	if req.Owner == "" {
+		req.Owner = user.Username
+	}
+
+	s := service.New(c)
+	ids, err := s.GetBatchNodeIds(req.GetUintIds(), req.Selector, true)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	err = s.EnterMaintenance(ids, &req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// ExitNodeMaintenance takes nodes out of maintenance mode.
+func ExitNodeMaintenance(c *gin.Context) {
+	var req request.NodeBatchRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	ids, err := s.GetBatchNodeIds(req.GetUintIds(), req.Selector, true)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	err = s.ExitMaintenance(ids)
 	if err != nil {
 		response.FailWithMsg(err.Error())
 		return
 	}
 	response.Success()
 }
+
+// ImportNodes imports nodes from a csv or yaml file, existing nodes are updated by address.
+// Nothing is written when dryRun is set or any row fails validation, the per-row report is returned instead.
+func ImportNodes(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.NodeImportRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		response.FailWithMsg("unable to read file")
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	format, err := service.GetNodeFileFormat(req.Format, header.Filename)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		response.FailWithMsg("unable to read file")
+		return
+	}
+
+	s := service.New(c)
+	resp, err := s.ImportNodes(format, data, req.DryRun, user.Username)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	if resp.Failed > 0 && !req.DryRun {
+		response.Result(response.NotOk, fmt.Sprintf("%d rows failed validation, nothing is imported", resp.Failed), resp)
+		return
+	}
+	response.SuccessWithData(resp)
+}
+
+// ExportNodes exports the nodes matching the node list filters as a csv or yaml file.
+func ExportNodes(c *gin.Context) {
+	var req request.NodeExportRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	data, err := s.ExportNodes(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	contentType, ext := "text/csv", service.NodeFileFormatCsv
+	if req.Format != "" && req.Format != service.NodeFileFormatCsv {
+		contentType, ext = "application/x-yaml", service.NodeFileFormatYaml
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=nodes.%s", ext))
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,106 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/service"
+	"metalflow/pkg/utils"
+)
+
+// GetNodeDenies gets the node deny list.
+func GetNodeDenies(c *gin.Context) {
+	var req request.NodeDenyListRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	denies, err := s.GetNodeDenies(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	var resp response.PageData
+	resp.PageInfo = req.PageInfo
+	resp.List = denies
+	response.SuccessWithData(resp)
+}
+
+// CreateNodeDeny hides or blocks the nodes in a cidr, it takes effect immediately.
+func CreateNodeDeny(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.CreateNodeDenyRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	// params validate.
+	err = global.NewValidatorError(global.Validate.Struct(req), req.FieldTrans())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	req.Creator = user.Username
+	s := service.New(c)
+	err = s.CreateNodeDeny(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// UpdateNodeDenyById updates the cidr, action or reason of a deny rule.
+func UpdateNodeDenyById(c *gin.Context) {
+	var req request.UpdateNodeDenyRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	// params validate.
+	err = global.NewValidatorError(global.Validate.Struct(req), req.FieldTrans())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	denyId := utils.Str2Uint(c.Param("denyId"))
+	if denyId == 0 {
+		response.FailWithMsg("the denyId is incorrect")
+		return
+	}
+	s := service.New(c)
+	err = s.UpdateNodeDenyById(denyId, &req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// BatchDeleteNodeDenyByIds deletes deny rules in batch.
+func BatchDeleteNodeDenyByIds(c *gin.Context) {
+	var req request.Req
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	err = s.DeleteByIds(req.GetUintIds(), new(models.SysNodeDeny))
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
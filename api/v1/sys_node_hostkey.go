@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/service"
+	"metalflow/pkg/utils"
+)
+
+// GetNodeHostKeys gets the ssh host keys of the nodes, the mismatched ones carry the pending key.
+func GetNodeHostKeys(c *gin.Context) {
+	var req request.NodeHostKeyListRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	keys, err := s.GetNodeHostKeys(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	var resp response.PageData
+	resp.PageInfo = req.PageInfo
+	resp.List = keys
+	response.SuccessWithData(resp)
+}
+
+// AcceptNodeHostKey accepts the rotated host key recorded on mismatch.
+func AcceptNodeHostKey(c *gin.Context) {
+	keyId := utils.Str2Uint(c.Param("keyId"))
+	if keyId == 0 {
+		response.FailWithMsg("the keyId is incorrect")
+		return
+	}
+	s := service.New(c)
+	err := s.AcceptNodeHostKey(keyId)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// ImportKnownHosts imports host keys from a known_hosts file or the content field.
+func ImportKnownHosts(c *gin.Context) {
+	user := GetCurrentUser(c)
+	data := []byte(c.PostForm("content"))
+	file, _, err := c.Request.FormFile("file")
+	if err == nil {
+		defer func() {
+			_ = file.Close()
+		}()
+		data, err = io.ReadAll(file)
+		if err != nil {
+			response.FailWithMsg("unable to read file")
+			return
+		}
+	}
+	if len(data) == 0 {
+		response.FailWithMsg("the known_hosts is empty")
+		return
+	}
+
+	s := service.New(c)
+	resp, err := s.ImportKnownHosts(data, user.Username)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(resp)
+}
+
+// BatchDeleteNodeHostKeyByIds deletes host keys in batch, the key is trusted again on the next connection.
+func BatchDeleteNodeHostKeyByIds(c *gin.Context) {
+	var req request.Req
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	err = s.DeleteNodeHostKeyByIds(req.GetUintIds())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
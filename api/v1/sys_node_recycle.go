@@ -0,0 +1,66 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/service"
+)
+
+// GetRecycledNodes gets the deleted nodes in the recycle bin.
+func GetRecycledNodes(c *gin.Context) {
+	var req request.NodeRecycleListRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	nodes, err := s.GetRecycledNodes(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	var resp response.PageData
+	resp.PageInfo = req.PageInfo
+	resp.List = nodes
+	response.SuccessWithData(resp)
+}
+
+// RestoreNodeByIds restores the deleted nodes with their original address.
+func RestoreNodeByIds(c *gin.Context) {
+	var req request.Req
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	err = s.RestoreNodeByIds(req.GetUintIds())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// PurgeNodeByIds removes the deleted nodes permanently.
+func PurgeNodeByIds(c *gin.Context) {
+	var req request.Req
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	err = s.PurgeNodeByIds(req.GetUintIds())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
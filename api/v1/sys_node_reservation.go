@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/service"
+	"metalflow/pkg/utils"
+)
+
+// GetNodeReservations gets the list of node reservations.
+func GetNodeReservations(c *gin.Context) {
+	var req request.NodeReservationListRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	reservations, err := s.GetNodeReservations(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	var resp response.PageData
+	resp.PageInfo = req.PageInfo
+	resp.List = reservations
+	response.SuccessWithData(resp)
+}
+
+// CreateNodeReservation reserves nodes for the current user in a time window.
+func CreateNodeReservation(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.CreateNodeReservationRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+	err = global.NewValidatorError(global.Validate.Struct(req), req.FieldTrans())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	s := service.New(c)
+	ids, err := s.GetBatchNodeIds(req.GetUintIds(), req.Selector, req.Force)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	err = s.CreateNodeReservations(user.Username, ids, &req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// RenewNodeReservation extends the end time of a reservation.
+func RenewNodeReservation(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.RenewNodeReservationRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+	err = global.NewValidatorError(global.Validate.Struct(req), req.FieldTrans())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	reservationId := utils.Str2Uint(c.Param("reservationId"))
+	s := service.New(c)
+	err = s.RenewNodeReservation(reservationId, &user, &req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// ReleaseNodeReservation releases a reservation before it expires.
+func ReleaseNodeReservation(c *gin.Context) {
+	user := GetCurrentUser(c)
+	reservationId := utils.Str2Uint(c.Param("reservationId"))
+	s := service.New(c)
+	err := s.ReleaseNodeReservation(reservationId, &user)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"metalflow/models"
 	"metalflow/pkg/response"
 	"metalflow/pkg/service"
 	tests2 "metalflow/tests"
@@ -41,6 +42,13 @@ func TestBatchDeleteNodeByIds(t *testing.T) {
 			s:    &s,
 			url:  "/node/delete/batch?ids=1",
 			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
+				mock.ExpectQuery("SELECT `address` FROM `tb_sys_node`").
+					WithArgs(1, models.SysNodeMaintenanceOn, tests2.AnyTime{}).
+					WillReturnRows(sqlmock.NewRows([]string{"address"}))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_approval_policy`").
+					WithArgs(models.ChangeActionNodeDelete, models.SysApprovalPolicyEnable).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"id", "address"}).AddRow(1, "12.34.56.78"))
 				mock.ExpectBegin()
@@ -55,6 +63,30 @@ func TestBatchDeleteNodeByIds(t *testing.T) {
 			},
 			respCode: 201,
 		},
+		{
+			name: "maintenance",
+			s:    &s,
+			url:  "/node/delete/batch?ids=1",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
+				mock.ExpectQuery("SELECT `address` FROM `tb_sys_node`").
+					WithArgs(1, models.SysNodeMaintenanceOn, tests2.AnyTime{}).
+					WillReturnRows(sqlmock.NewRows([]string{"address"}).AddRow("12.34.56.78"))
+			},
+			respCode: 405,
+		},
+		{
+			name: "blocked",
+			s:    &s,
+			url:  "/node/delete/batch?ids=1&force=true",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").
+					WillReturnRows(sqlmock.NewRows([]string{"cidr", "action"}).AddRow("12.34.56.0/24", models.SysNodeDenyBlock))
+				mock.ExpectQuery("SELECT `address` FROM `tb_sys_node`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"address"}).AddRow("12.34.56.78"))
+			},
+			respCode: 405,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -109,6 +141,7 @@ func TestCreateNode(t *testing.T) {
 			url:   "/node/create",
 			param: `{"address": "10.34.23.57", "sshPort":22, "os": "linux", "labelIds": [1]}`,
 			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs("10.34.23.57").
 					WillReturnRows(sqlmock.NewRows([]string{"id"}))
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_label`").WithArgs(1).
@@ -176,6 +209,7 @@ func TestGetNodes(t *testing.T) {
 			s:    &s,
 			url:  "/node/list?address=12.34&asset=aa&creator=12345678&health=1&pageNum=1&pageSize=10&noPagination=true",
 			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(
 					fmt.Sprintf("%%%s%%", "12.34"),
 					fmt.Sprintf("%%%s%%", "aa"),
@@ -240,6 +274,7 @@ func TestRefreshNodeInfo(t *testing.T) {
 			invoke: func() {
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
 			},
 			respCode: 201,
 		},
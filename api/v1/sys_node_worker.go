@@ -0,0 +1,44 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/service"
+	"metalflow/pkg/utils"
+)
+
+// GetNodeWorkerMatrix gets the deployment status matrix of nodes and workers.
+func GetNodeWorkerMatrix(c *gin.Context) {
+	var req request.NodeWorkerMatrixRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	matrix, err := s.GetNodeWorkerMatrix(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(matrix)
+}
+
+// CheckNodeWorkers probes the deployed workers of the node with their CheckReq.
+func CheckNodeWorkers(c *gin.Context) {
+	nodeId := utils.Str2Uint(c.Param("nodeId"))
+	if nodeId == 0 {
+		response.FailWithMsg("the nodeId is incorrect")
+		return
+	}
+
+	s := service.New(c)
+	relations, err := s.CheckNodeWorkers(nodeId)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(relations)
+}
@@ -13,6 +13,7 @@ import (
 	"metalflow/pkg/global"
 	"metalflow/pkg/request"
 	"metalflow/pkg/response"
+	"metalflow/pkg/service"
 	"metalflow/pkg/utils"
 	"metalflow/pkg/vncproxy"
 	"net/http"
@@ -73,10 +74,40 @@ func NodeConnect(c *gin.Context) {
 		return
 	}
 
-	client, err := utils.GetSshClient(utils.NewSshConfig(req.Address, int(req.SshPort), req.Username, req.Password))
+	s := service.New(c)
+	err = s.CheckNodeBlocked(req.Address)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	// 使用保存的凭据时以凭据认证, 传了用户名时覆盖凭据的默认用户名
+	credential := &service.SshCredential{Username: req.Username, Password: req.Password}
+	if req.CredentialId > 0 {
+		user := GetCurrentUser(c)
+		credential, err = s.GetSshCredentialByAddress(req.CredentialId, user.Username, req.Address)
+		if err != nil {
+			response.FailWithMsg(err.Error())
+			return
+		}
+		if req.Username != "" {
+			credential.Username = req.Username
+		}
+	}
+	if credential.Username == "" {
+		response.FailWithMsg("ssh用户名不能为空")
+		return
+	}
+	// 校验主机公钥, 首次连接时保存
+	hostKey, err := s.HostKeyOption(req.Address)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	client, err := utils.GetSshClient(credential.Config(req.Address, int(req.SshPort), hostKey))
 	if err != nil {
 		global.Log.Error(fmt.Sprintf("建立ssh连接失败：%v", err))
-		response.FailWithMsg("无法建立ssh连接")
+		// 返回具体原因, 主机公钥不一致时提示管理员确认新公钥
+		response.FailWithMsg(fmt.Sprintf("无法建立ssh连接: %v", err))
 		return
 	}
 	// 开启ssh通道channel
@@ -0,0 +1,108 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/service"
+	"metalflow/pkg/utils"
+)
+
+// GetPerformanceProfiles gets the list of performance rating profiles.
+func GetPerformanceProfiles(c *gin.Context) {
+	var req request.PerformanceProfileListRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	profiles, err := s.GetPerformanceProfiles(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	var resp response.PageData
+	resp.PageInfo = req.PageInfo
+	resp.List = profiles
+	response.SuccessWithData(resp)
+}
+
+// CreatePerformanceProfile creates a performance rating profile.
+func CreatePerformanceProfile(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.CreatePerformanceProfileRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	// params validate.
+	err = global.NewValidatorError(global.Validate.Struct(req), req.FieldTrans())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	req.Creator = user.Username
+	s := service.New(c)
+	err = s.CreatePerformanceProfile(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// UpdatePerformanceProfileById updates the weights and thresholds of a performance rating profile.
+func UpdatePerformanceProfileById(c *gin.Context) {
+	var req request.UpdatePerformanceProfileRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	profileId := utils.Str2Uint(c.Param("profileId"))
+	s := service.New(c)
+	err = s.UpdatePerformanceProfileById(profileId, &req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// BatchDeletePerformanceProfileByIds deletes performance rating profiles in batch,
+// nodes using them fall back to the default profile.
+func BatchDeletePerformanceProfileByIds(c *gin.Context) {
+	var req request.Req
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	err = s.DeleteByIds(req.GetUintIds(), new(models.SysPerformanceProfile))
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// RecomputeNodePerformance recomputes the performance rating of the whole fleet with the current profiles.
+func RecomputeNodePerformance(c *gin.Context) {
+	s := service.New(c)
+	count, err := s.RecomputeNodePerformance()
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(count)
+}
@@ -0,0 +1,111 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/service"
+	"metalflow/pkg/utils"
+)
+
+// GetRegions gets the list of region cidrs.
+func GetRegions(c *gin.Context) {
+	var req request.RegionListRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	regions, err := s.GetRegions(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	var resp response.PageData
+	resp.PageInfo = req.PageInfo
+	resp.List = regions
+	response.SuccessWithData(resp)
+}
+
+// CreateRegion binds a cidr to a region.
+func CreateRegion(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.CreateRegionRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	// params validate.
+	err = global.NewValidatorError(global.Validate.Struct(req), req.FieldTrans())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	req.Creator = user.Username
+	s := service.New(c)
+	err = s.CreateRegion(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// UpdateRegionById updates the name or cidr of a region.
+func UpdateRegionById(c *gin.Context) {
+	var req request.UpdateRegionRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	regionId := utils.Str2Uint(c.Param("regionId"))
+	if regionId == 0 {
+		response.FailWithMsg("the regionId is incorrect")
+		return
+	}
+	s := service.New(c)
+	err = s.UpdateRegionById(regionId, &req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// BatchDeleteRegionByIds deletes region cidrs in batch.
+func BatchDeleteRegionByIds(c *gin.Context) {
+	var req request.Req
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	err = s.DeleteByIds(req.GetUintIds(), new(models.SysRegion))
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// RecomputeNodeRegions recomputes the region of existing nodes with the current region cidrs.
+func RecomputeNodeRegions(c *gin.Context) {
+	s := service.New(c)
+	count, err := s.RecomputeNodeRegions()
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(count)
+}
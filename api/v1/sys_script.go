@@ -0,0 +1,202 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/service"
+	"metalflow/pkg/utils"
+)
+
+// GetScripts gets the scripts in the script library.
+func GetScripts(c *gin.Context) {
+	var req request.ScriptListRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	scripts, err := s.GetScripts(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	var resp response.PageData
+	resp.PageInfo = req.PageInfo
+	resp.List = scripts
+	response.SuccessWithData(resp)
+}
+
+// GetScriptById gets the script with its current version.
+func GetScriptById(c *gin.Context) {
+	scriptId := utils.Str2Uint(c.Param("scriptId"))
+	if scriptId == 0 {
+		response.FailWithMsg("the scriptId is incorrect")
+		return
+	}
+
+	s := service.New(c)
+	script, err := s.GetScriptById(scriptId)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(script)
+}
+
+// CreateScript creates a script as its first version.
+func CreateScript(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.CreateScriptRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	// params validate.
+	err = global.NewValidatorError(global.Validate.Struct(req), req.FieldTrans())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	// record current creator information.
+	req.Creator = user.Username
+
+	s := service.New(c)
+	script, err := s.CreateScript(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(script)
+}
+
+// UpdateScriptById updates the script, a new version is created when the content, params or interpreter changes.
+func UpdateScriptById(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.UpdateScriptRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	scriptId := utils.Str2Uint(c.Param("scriptId"))
+	if scriptId == 0 {
+		response.FailWithMsg("the scriptId is incorrect")
+		return
+	}
+	req.Creator = user.Username
+	s := service.New(c)
+	err = s.UpdateScriptById(scriptId, &req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// BatchDeleteScriptByIds deletes scripts in batch.
+func BatchDeleteScriptByIds(c *gin.Context) {
+	var req request.Req
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	err = s.DeleteByIds(req.GetUintIds(), new(models.SysScript))
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
+
+// GetScriptVersions gets the version history of the script.
+func GetScriptVersions(c *gin.Context) {
+	scriptId := utils.Str2Uint(c.Param("scriptId"))
+	if scriptId == 0 {
+		response.FailWithMsg("the scriptId is incorrect")
+		return
+	}
+
+	s := service.New(c)
+	versions, err := s.GetScriptVersions(scriptId)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(versions)
+}
+
+// DiffScriptVersions gets the unified diff between two versions of the script.
+func DiffScriptVersions(c *gin.Context) {
+	var req request.ScriptDiffRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+	err = global.NewValidatorError(global.Validate.Struct(req), req.FieldTrans())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	scriptId := utils.Str2Uint(c.Param("scriptId"))
+	if scriptId == 0 {
+		response.FailWithMsg("the scriptId is incorrect")
+		return
+	}
+	s := service.New(c)
+	diff, err := s.DiffScriptVersions(scriptId, req.From, req.To)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(diff)
+}
+
+// RunScript runs a version of the script with the params on the selected nodes through metaltask.
+func RunScript(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.RunScriptRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("params binding failed, please check the data type")
+		return
+	}
+
+	scriptId := utils.Str2Uint(c.Param("scriptId"))
+	if scriptId == 0 {
+		response.FailWithMsg("the scriptId is incorrect")
+		return
+	}
+	req.Creator = user.Username
+	s := service.New(c)
+	ids, err := s.GetBatchNodeIds(req.GetUintIds(), req.Selector, req.Force)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	if requireApproval(c, s, models.ChangeActionScript, ids, &service.ChangeScriptPayload{
+		ScriptId:               scriptId,
+		RunScriptRequestStruct: req,
+	}) {
+		return
+	}
+	job, err := s.RunScript(scriptId, &req, ids)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(job)
+}
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"github.com/gin-gonic/gin"
+	"metalflow/models"
 	"metalflow/pkg/request"
 	"metalflow/pkg/response"
 	"metalflow/pkg/service"
@@ -165,6 +166,9 @@ func FixNodeSecurityIssuesById(c *gin.Context) {
 		return
 	}
 	s := service.New(c)
+	if requireApproval(c, s, models.ChangeActionSecureFix, []uint{nodeId}, req) {
+		return
+	}
 	err = s.FixSecureRisk(nodeId, req.CveId)
 	if err != nil {
 		response.FailWithMsg(err.Error())
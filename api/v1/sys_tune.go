@@ -85,6 +85,9 @@ func Rollback(c *gin.Context) {
 		return
 	}
 	s := service.New(c)
+	if requireApproval(c, s, models.ChangeActionTuneRollback, []uint{nodeId}, req) {
+		return
+	}
 	err = s.Rollback(nodeId, req.LogId)
 	if err != nil {
 		response.FailWithMsg("rollback failed：" + err.Error())
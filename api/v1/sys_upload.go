@@ -5,6 +5,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/siddontang/go/ioutil2"
 	"io"
+	"metalflow/models"
 	"metalflow/pkg/global"
 	"metalflow/pkg/grpc"
 	"metalflow/pkg/request"
@@ -112,7 +113,6 @@ func UploadMerge(c *gin.Context) { //nolint:gocyclo
 	// wait for all coroutines to finish processing.
 	wg.Wait()
 
-	ids := utils.Str2UintArr(addressIds)
 	m := &MergeInfo{path: mergeFileName}
 
 	fileMetric := grpc.FileMetric{
@@ -122,7 +122,15 @@ func UploadMerge(c *gin.Context) { //nolint:gocyclo
 		FileGetter: m,
 	}
 	s := service.New(c)
-	err = s.BatchUploadByIds(fileMetric, ids)
+	ids, err := s.GetBatchNodeIds(utils.Str2UintArr(addressIds), fileMerge.Selector, fileMerge.Force)
+	var cr *models.SysChangeRequest
+	if err == nil && fileMerge.Runnable {
+		// the file executed after upload is approved as a command.
+		cr, err = s.RequestUploadApproval(ids, fileMetric, &fileMerge, c.Query("reason"))
+	}
+	if err == nil && cr == nil {
+		err = s.BatchUploadByIds(models.SysCommandJobKindUpload, nil, fileMerge.ExecStrategy, fileMetric, ids)
+	}
 	// after the file is transferred to the corresponding machine, delete the path where the fragmented file is located and the original file.
 	_ = os.RemoveAll(filePart.GetChunkRootPath())
 	_ = os.Remove(mergeFileName)
@@ -130,6 +138,10 @@ func UploadMerge(c *gin.Context) { //nolint:gocyclo
 		response.FailWithMsg(err.Error())
 		return
 	}
+	if cr != nil {
+		responseChangeRequest(cr)
+		return
+	}
 
 	// write back file information.
 	var res response.UploadMergeResponseStruct
@@ -36,6 +36,25 @@ func GetWorkers(c *gin.Context) {
 	response.SuccessWithData(resp)
 }
 
+// GetWorkerByRole gets the worker currently used for the role.
+func GetWorkerByRole(c *gin.Context) {
+	role := c.Param("role")
+	if role == "" {
+		response.FailWithMsg("the role is incorrect")
+		return
+	}
+
+	s := service.New(c)
+	worker, err := s.GetWorkerByRole(role)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	var resp response.WorkerListResponseStruct
+	utils.Struct2StructByJson(worker, &resp)
+	response.SuccessWithData(resp)
+}
+
 // CreateWorker creates a worker.
 func CreateWorker(c *gin.Context) {
 	user := GetCurrentUser(c)
@@ -57,7 +76,7 @@ func CreateWorker(c *gin.Context) {
 	req.Creator = user.Username
 
 	s := service.New(c)
-	err = s.Create(req, new(models.SysWorker))
+	err = s.CreateWorker(&req)
 	if err != nil {
 		response.FailWithMsg(err.Error())
 		return
@@ -83,7 +102,7 @@ func UpdateWorkerById(c *gin.Context) {
 
 	s := service.New(c)
 	// update data.
-	err = s.UpdateById(workerId, req, new(models.SysWorker))
+	err = s.UpdateWorkerById(workerId, &req)
 	if err != nil {
 		response.FailWithMsg(err.Error())
 		return
@@ -109,3 +128,74 @@ func BatchDeleteWorkerByIds(c *gin.Context) {
 	}
 	response.Success()
 }
+
+// DeployWorker deploys the worker on the selected nodes, nodes already running the current version are skipped.
+func DeployWorker(c *gin.Context) {
+	runWorkerAction(c, service.WorkerActionDeploy)
+}
+
+// RedeployWorker deploys the worker on the selected nodes again whatever its current status.
+func RedeployWorker(c *gin.Context) {
+	runWorkerAction(c, service.WorkerActionRedeploy)
+}
+
+// UndeployWorker stops the worker on the selected nodes.
+func UndeployWorker(c *gin.Context) {
+	runWorkerAction(c, service.WorkerActionUndeploy)
+}
+
+// runWorkerAction runs the worker deploy commands in background on the union of the nodes selected by ids and label selector,
+// the returned command job can be polled for the progress and output of each node.
+func runWorkerAction(c *gin.Context, action string) {
+	var req request.NodeBatchRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("parameter binding failed, please check the data type")
+		return
+	}
+
+	// Get the workerId in the path.
+	workerId := utils.Str2Uint(c.Param("workerId"))
+	if workerId == 0 {
+		response.FailWithMsg("the workerId is incorrect")
+		return
+	}
+
+	s := service.New(c)
+	ids, err := s.GetBatchNodeIds(req.GetUintIds(), req.Selector, req.Force)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	job, err := s.DeployWorker(workerId, ids, action)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(job)
+}
+
+// PreviewWorkerCmd renders the deploy command templates of the worker for the node.
+func PreviewWorkerCmd(c *gin.Context) {
+	var req request.WorkerCmdPreviewRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("parameter binding failed, please check the data type")
+		return
+	}
+
+	// params validate.
+	err = global.NewValidatorError(global.Validate.Struct(req), req.FieldTrans())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	s := service.New(c)
+	cmds, err := s.PreviewWorkerCmd(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(cmds)
+}
@@ -0,0 +1,127 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/service"
+	"metalflow/pkg/utils"
+)
+
+// GetWorkerUpgrades gets the worker upgrade campaigns.
+func GetWorkerUpgrades(c *gin.Context) {
+	var req request.WorkerUpgradeListRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("parameter binding failed, please check the data type")
+		return
+	}
+
+	s := service.New(c)
+	upgrades, err := s.GetWorkerUpgrades(&req)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+
+	var resp response.PageData
+	resp.PageInfo = req.PageInfo
+	resp.List = upgrades
+	response.SuccessWithData(resp)
+}
+
+// GetWorkerUpgradeById gets the upgrade campaign with the status of each node.
+func GetWorkerUpgradeById(c *gin.Context) {
+	upgradeId := utils.Str2Uint(c.Param("upgradeId"))
+	if upgradeId == 0 {
+		response.FailWithMsg("the upgradeId is incorrect")
+		return
+	}
+
+	s := service.New(c)
+	upgrade, err := s.GetWorkerUpgradeById(upgradeId)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(upgrade)
+}
+
+// CreateWorkerUpgrade creates an upgrade campaign, the canary nodes are upgraded first and then the rest in batches.
+func CreateWorkerUpgrade(c *gin.Context) {
+	user := GetCurrentUser(c)
+	var req request.CreateWorkerUpgradeRequestStruct
+	err := c.ShouldBind(&req)
+	if err != nil {
+		response.FailWithMsg("parameter binding failed, please check the data type")
+		return
+	}
+
+	// params validate.
+	err = global.NewValidatorError(global.Validate.Struct(req), req.FieldTrans())
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	// record current creator information.
+	req.Creator = user.Username
+
+	s := service.New(c)
+	ids, err := s.GetBatchNodeIds(req.GetUintIds(), req.Selector, req.Force)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	upgrade, err := s.CreateWorkerUpgrade(&req, ids)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.SuccessWithData(upgrade)
+}
+
+// StartWorkerUpgrade starts a pending upgrade campaign or resumes a paused one.
+func StartWorkerUpgrade(c *gin.Context) {
+	changeWorkerUpgrade(c, func(s *service.MysqlService, id uint) error {
+		return s.StartWorkerUpgrade(id)
+	})
+}
+
+// PauseWorkerUpgrade pauses the upgrade campaign after the current batch.
+func PauseWorkerUpgrade(c *gin.Context) {
+	changeWorkerUpgrade(c, func(s *service.MysqlService, id uint) error {
+		return s.PauseWorkerUpgrade(id)
+	})
+}
+
+// RollbackWorkerUpgrade rolls the upgraded nodes back to the previous version.
+func RollbackWorkerUpgrade(c *gin.Context) {
+	changeWorkerUpgrade(c, func(s *service.MysqlService, id uint) error {
+		return s.RollbackWorkerUpgrade(id)
+	})
+}
+
+// CancelWorkerUpgrade cancels a pending or paused upgrade campaign.
+func CancelWorkerUpgrade(c *gin.Context) {
+	changeWorkerUpgrade(c, func(s *service.MysqlService, id uint) error {
+		return s.CancelWorkerUpgrade(id)
+	})
+}
+
+// changeWorkerUpgrade changes the status of the upgrade campaign in the path.
+func changeWorkerUpgrade(c *gin.Context, change func(s *service.MysqlService, id uint) error) {
+	upgradeId := utils.Str2Uint(c.Param("upgradeId"))
+	if upgradeId == 0 {
+		response.FailWithMsg("the upgradeId is incorrect")
+		return
+	}
+
+	s := service.New(c)
+	err := change(&s, upgradeId)
+	if err != nil {
+		response.FailWithMsg(err.Error())
+		return
+	}
+	response.Success()
+}
@@ -76,6 +76,10 @@ func Config(filename string) {
 		panic(fmt.Sprintf("初始化配置文件失败: %v, 配置文件: %s", err, global.ConfBox.ConfFile))
 	}
 
+	if err := loadCredentialKey(); err != nil {
+		panic(fmt.Sprintf("初始化ssh凭据主密钥失败: %v", err))
+	}
+
 	if global.Conf.System.ConnectTimeout < 1 {
 		global.Conf.System.ConnectTimeout = defaultConnectTimeout
 	}
@@ -94,6 +98,32 @@ func Config(filename string) {
 	}
 }
 
+// loadCredentialKey 读取ssh凭据加密的主密钥, 优先使用环境变量, 其次为credential-key-file指定的文件, 最后为credential-key
+// 主密钥为曾随配置文件发布的示例值时拒绝启动
+func loadCredentialKey() error {
+	key := strings.TrimSpace(os.Getenv(global.CredentialKeyEnv))
+	file := strings.TrimSpace(global.Conf.System.CredentialKeyFile)
+	if key == "" && file != "" {
+		if !strings.HasPrefix(file, "/") {
+			file = utils.GetWorkDir() + "/" + file
+		}
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		key = strings.TrimSpace(string(b))
+	}
+	if key == "" {
+		key = strings.TrimSpace(global.Conf.System.CredentialKey)
+	}
+	if key == global.CredentialKeyPlaceholder {
+		return fmt.Errorf("the master key must not be the placeholder value, please set %s or system.credential-key-file",
+			global.CredentialKeyEnv)
+	}
+	global.Conf.System.CredentialKey = key
+	return nil
+}
+
 func readConfig(v *viper.Viper, configFile string) {
 	v.SetConfigType(configType)
 	config := global.ConfBox.Find(configFile)
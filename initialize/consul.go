@@ -1,21 +1,17 @@
 package initialize
 
 import (
-	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
 	consulapi "github.com/hashicorp/consul/api"
 	probing "github.com/prometheus-community/pro-bing"
 	"gorm.io/gorm"
-	"io"
 	"metalflow/models"
 	"metalflow/pkg/async"
 	"metalflow/pkg/consul"
 	"metalflow/pkg/global"
 	"metalflow/pkg/request"
 	"metalflow/pkg/service"
-	"net/http"
 	"regexp"
 	"time"
 )
@@ -64,6 +60,16 @@ func UpdateStateByConsul(svc *consul.Service) {
 		consulapi.HealthCritical: models.SysNodeHealthAbnormal,
 		consulapi.HealthPassing:  models.SysNodeHealthNormal,
 	}
+	// 被禁止的机器不注册也不更新状态
+	denyList, err := service.GetNodeDenyList()
+	if err != nil {
+		global.Log.Errorf("search node deny list failed: %v", err)
+		return
+	}
+	if err = denyList.CheckBlocked(svc.Address); err != nil {
+		global.Log.Warnf("ignore consul service %s: %v", svc.Address, err)
+		return
+	}
 	var node models.SysNode
 	query := global.Mysql.Model(&models.SysNode{}).Where("address = ?", svc.Address).First(&node)
 	if query.Error == nil {
@@ -92,9 +98,7 @@ func UpdateStateByConsul(svc *consul.Service) {
 			return
 		}
 		// query the metrics worker and communicate according to its configuration
-		// TODO: 因进行过worker初始化，所以id为1的worker就是metalmetrics
-		var worker models.SysWorker
-		err = global.Mysql.Model(new(models.SysWorker)).Where("id = ?", 1).First(&worker).Error
+		worker, err := s.GetWorkerByRole(models.SysWorkerRoleMetrics)
 		if err != nil {
 			global.Log.Error("search worker from database failed")
 			return
@@ -150,84 +154,29 @@ func logPingStatistics(stats *probing.Statistics) {
 }
 
 func DeployInitWorkers(serverOs, address string, port int) error {
-	workers := make([]models.SysWorker, 0)
-	err := global.Mysql.Model(new(models.SysWorker)).Where("auto_deploy = ?", 1).Find(&workers).Error
+	var node models.SysNode
+	err := global.Mysql.Model(new(models.SysNode)).Where("address = ?", address).First(&node).Error
 	if err != nil {
 		return err
 	}
-	for _, worker := range workers { //nolint:gocritic
-		// send cmd for starting deploy workers.
-		var cmdContent *models.CmdStruct
-		cmdContent, err = worker.GetCmd(serverOs)
-		if err != nil {
-			global.Log.Errorf("Failed to get worker: [%s] deploy commands", worker.Name)
-		}
-		err = sendCmd2MetalBeat(address, cmdContent.Download, port)
-		if err != nil {
-			global.Log.Errorf("Failed to run worker: [%s download] command, err: %v", worker.Name, err)
-		}
-		// run stop command before start.
-		err = sendCmd2MetalBeat(address, cmdContent.Stop, port)
-		if err != nil {
-			global.Log.Errorf("Failed to run worker: [%s stop] command, err: %v", worker.Name, err)
-		}
-		err = sendCmd2MetalBeat(address, cmdContent.Start, port)
-		if err != nil {
-			global.Log.Errorf("Failed to run worker: [%s start] command, err: %v", worker.Name, err)
-		}
-	}
-	return nil
-}
-
-func sendCmd2MetalBeat(address, cmd string, port int) error {
-	var statusOk = 201
-
-	requestBody := map[string]string{
-		"cmd": cmd,
-	}
-	body, err := json.Marshal(requestBody)
-	if err != nil {
-		return fmt.Errorf("marshal request body failed. address: %s, err: %v", address, err)
-	}
-
-	shellUrl := fmt.Sprintf("http://%s:%d/shell", address, port)
-	req, err := http.NewRequest(http.MethodPost, shellUrl, bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("failed to request. address: %s, err: %v", address, err)
-	}
-	req.Header.Add("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req) // nolint:bodyclose
-	if err != nil {
-		return fmt.Errorf("failed to post. address: %s, err: %v", address, err)
-	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("invalid status to send metalbeat:%s cmd", address)
-	}
-	ret, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read metalbeat:%s resp", address)
-	}
-	metalBeatResp := MetalBeatResp{}
-	err = json.Unmarshal(ret, &metalBeatResp)
+	// os info is updated after registration, use the values reported by consul.
+	node.Os = serverOs
+	node.ServicePort = port
+	workers := make([]models.SysWorker, 0)
+	err = global.Mysql.Model(new(models.SysWorker)).Where("auto_deploy = ?", 1).Find(&workers).Error
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal metalbeat:%s resp", address)
+		return err
 	}
-	if metalBeatResp.Code != statusOk {
-		return fmt.Errorf("send cmd to metalbeat:%s failed, err: %s", address, metalBeatResp.Msg)
+	s := service.New(nil)
+	for i := range workers {
+		result := s.RunWorkerAction(&node, &workers[i], service.WorkerActionDeploy)
+		if !result.Success {
+			global.Log.Errorf("Failed to deploy worker: [%s], err: %s", workers[i].Name, result.Error)
+		}
 	}
 	return nil
 }
 
-type MetalBeatResp struct {
-	Code int    `json:"code"`
-	Data any    `json:"data"`
-	Msg  string `json:"msg"`
-}
-
 func sendMail(address string) {
 	var node models.SysNode
 	err := global.Mysql.Model(&models.SysNode{}).Where("address = ?", address).First(&node).Error
@@ -235,6 +184,17 @@ func sendMail(address string) {
 		global.Log.Errorf("数据库查询节点：%s失败：%v", address, err)
 		return
 	}
+	// 被隐藏或禁止的机器不发送告警邮件
+	denyList, err := service.GetNodeDenyList()
+	if err != nil || denyList.Denied(address) {
+		global.Log.Infof("节点：%s已被隐藏或禁止，不发送告警邮件", address)
+		return
+	}
+	// 维护中的机器不发送告警邮件
+	if node.InMaintenance() {
+		global.Log.Infof("节点：%s处于维护模式(%s)，不发送告警邮件", address, node.MaintenanceReason)
+		return
+	}
 	// 根据机器节点负责人获取对应邮件收件人
 	// nolint:gocritic
 	re, _ := regexp.Compile("[^0-9]")
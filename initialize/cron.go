@@ -38,7 +38,10 @@ func Cron() {
 	go func(c *cron.Client) {
 		addRefreshNodeMetricsTask(c)
 		addRefreshNodePingStatsTask(c)
+		addCheckNodeWorkersTask(c)
 		addShutStartNodeTask(c)
+		addCleanNodeMetricsTask(c)
+		addNodeReservationTask(c)
 		err := c.DoInitJobs()
 		if err != nil {
 			panic("执行初始化定时任务失败")
@@ -46,6 +49,10 @@ func Cron() {
 		c.Run()
 	}(c)
 	global.Cron = c
+	// 服务重启前未执行完的批量命令无法继续, 记为已中断
+	service.InterruptCommandJobs()
+	// 继续执行服务重启前未完成的worker升级
+	service.ResumeWorkerUpgrades()
 	global.Log.Debug("初始化定时任务完成")
 }
 
@@ -69,15 +76,23 @@ func runRefreshNodeMetrics() {
 		global.Log.Error("查询数据库机器节点失败：", err)
 		return
 	}
+	denyList, err := service.GetNodeDenyList()
+	if err != nil {
+		global.Log.Error("查询机器隐藏/禁止名单失败：", err)
+		return
+	}
 	for _, node := range nodes { //nolint:gocritic
+		if denyList.Denied(node.Address) {
+			continue
+		}
 		// 判断本次刷新时间与上次刷新时间的间隔，如果小于5分钟，则不进行刷新
 		if time.Since(node.RefreshLastTime.Time).Minutes() < 5 { //nolint:gomnd
 			global.Log.Debugf("五分钟内已有其他人刷新节点[%s]，跳过本次刷新", node.Address)
 			continue
 		}
 		// 启动异步任务刷新机器节点信息
-		var worker models.SysWorker
-		err = global.Mysql.Model(new(models.SysWorker)).Where("id = ?", 1).First(&worker).Error
+		s := service.New(nil)
+		worker, err := s.GetWorkerByRole(models.SysWorkerRoleMetrics)
 		if err != nil {
 			global.Log.Error("search worker from database failed")
 			return
@@ -108,11 +123,14 @@ func addShutStartNodeTask(c *cron.Client) {
 		return
 	}
 	for _, task := range cronShutNodeTasks {
-		if len(task.Nodes) == 0 {
+		if len(task.Nodes) == 0 && task.Selector == "" {
 			continue
 		}
 		jobNodes := &service.JobNodes{
-			Nodes: task.Nodes,
+			Nodes:    task.Nodes,
+			Selector: task.Selector,
+			Keyword:  task.Keyword,
+			Strategy: models.ParseExecStrategy(task.Strategy),
 		}
 		// 添加定时开机任务
 		c.InitJobs[task.Keyword+".start"] = &cron.InitJob{
@@ -140,6 +158,27 @@ func addRefreshNodePingStatsTask(c *cron.Client) {
 	}
 }
 
+// Add cron check workers on nodes with their CheckReq task
+const checkNodeWorkersName = "check.node.worker.2m"
+
+func addCheckNodeWorkersTask(c *cron.Client) {
+	if global.Conf.System.WorkerCheckCronTask != "" {
+		c.InitJobs[checkNodeWorkersName] = &cron.InitJob{
+			Spec:    global.Conf.System.WorkerCheckCronTask,
+			Handler: runCheckNodeWorkers,
+		}
+	}
+}
+
+func runCheckNodeWorkers() {
+	checked, unhealthy, redeployed, err := service.CheckAllNodeWorkers(global.Conf.NodeConf.WorkerCheckRedeploy)
+	if err != nil {
+		global.Log.Errorf("检查机器上的worker健康状态失败：%v", err)
+		return
+	}
+	global.Log.Infof("[定时任务][worker健康检查]共检查%d个, 新增异常%d个, 重新部署%d个", checked, unhealthy, redeployed)
+}
+
 type ServerStats struct {
 	Ip     string
 	Status bool
@@ -152,10 +191,18 @@ func DoPingIps() {
 		global.Log.Errorf("search nodes failed: %v", err)
 		return
 	}
+	denyList, err := service.GetNodeDenyList()
+	if err != nil {
+		global.Log.Errorf("search node deny list failed: %v", err)
+		return
+	}
 
 	wg := sync.WaitGroup{}
 	serverStatsChan := make(chan ServerStats)
 	for _, node := range nodes {
+		if denyList.Denied(node.Address) {
+			continue
+		}
 		wg.Add(1)
 		go func(addr string) {
 			defer wg.Done()
@@ -210,3 +257,48 @@ func ping(ip string) (result bool, message string) {
 	}
 	return result, message
 }
+
+// Add cron clean expired node metrics samples task
+const cleanNodeMetricsName = "clean.node.metrics.1d"
+
+func addCleanNodeMetricsTask(c *cron.Client) {
+	if global.Conf.System.NodeMetricsCleanCronTask != "" && global.Conf.NodeConf.MetricsRetentionDays > 0 {
+		c.InitJobs[cleanNodeMetricsName] = &cron.InitJob{
+			Spec:    global.Conf.System.NodeMetricsCleanCronTask,
+			Handler: runCleanNodeMetrics,
+		}
+	}
+}
+
+func runCleanNodeMetrics() {
+	global.Log.Info("[定时任务][机器节点metrics历史清理]准备开始...")
+	count, err := service.CleanExpiredMetricsSamples(global.Conf.NodeConf.MetricsRetentionDays)
+	if err != nil {
+		global.Log.Errorf("清理过期的机器节点metrics历史失败：%v", err)
+		return
+	}
+	global.Log.Infof("[定时任务][机器节点metrics历史清理]任务结束, 共清理%d条", count)
+}
+
+// Add cron remind and release node reservations task
+const nodeReservationName = "node.reservation.5m"
+
+func addNodeReservationTask(c *cron.Client) {
+	if global.Conf.System.NodeReservationCronTask != "" {
+		c.InitJobs[nodeReservationName] = &cron.InitJob{
+			Spec:    global.Conf.System.NodeReservationCronTask,
+			Handler: runNodeReservation,
+		}
+	}
+}
+
+func runNodeReservation() {
+	remindBefore := time.Duration(global.Conf.NodeConf.ReservationRemindMinutes) * time.Minute
+	reminded, released, err := service.RemindAndReleaseReservations(remindBefore)
+	if err != nil {
+		global.Log.Errorf("处理机器预约到期提醒及释放失败：%v", err)
+	}
+	if reminded > 0 || released > 0 {
+		global.Log.Infof("[定时任务][机器预约]发送到期提醒%d条, 自动释放%d条", reminded, released)
+	}
+}
@@ -25,6 +25,7 @@ var (
 	newTab         = uint(1)
 	noBreadcrumb   = uint(0)
 	autoDeploy     = uint(1)
+	primary        = uint(1)
 	sysRoleDevSort = uint(20) //nolint:gomnd    // 一般开发人员排序
 )
 
@@ -544,12 +545,144 @@ func Data() { //nolint:gocyclo
 			Category: "node",
 			Desc:     "刷新机器节点",
 		},
+		{
+			Method:   "GET",
+			Path:     "/v1/node/metrics/history/:nodeId",
+			Category: "node",
+			Desc:     "获取机器节点metrics历史",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/node/change/list",
+			Category: "node",
+			Desc:     "获取所有机器节点的硬件及配置变更记录",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/node/change/list/:nodeId",
+			Category: "node",
+			Desc:     "获取机器节点的硬件及配置变更记录",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/node/maintenance/enter",
+			Category: "node",
+			Desc:     "机器节点进入维护模式",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/node/maintenance/exit",
+			Category: "node",
+			Desc:     "机器节点退出维护模式",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/node/import",
+			Category: "node",
+			Desc:     "从csv/yaml文件批量导入机器节点",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/node/export",
+			Category: "node",
+			Desc:     "批量导出机器节点为csv/yaml文件",
+		},
 		{
 			Method:   "DELETE",
 			Path:     "/v1/node/delete/batch",
 			Category: "node",
 			Desc:     "批量删除机器节点",
 		},
+		{
+			Method:   "GET",
+			Path:     "/v1/node/recycle/list",
+			Category: "node",
+			Desc:     "获取回收站中已删除的机器节点",
+		},
+		{
+			Method:   "PATCH",
+			Path:     "/v1/node/recycle/restore",
+			Category: "node",
+			Desc:     "从回收站恢复机器节点",
+		},
+		{
+			Method:   "DELETE",
+			Path:     "/v1/node/recycle/purge",
+			Category: "node",
+			Desc:     "彻底删除回收站中的机器节点",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/node/deny/list",
+			Category: "node",
+			Desc:     "获取机器隐藏/禁止名单",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/node/deny/create",
+			Category: "node",
+			Desc:     "创建机器隐藏/禁止规则",
+		},
+		{
+			Method:   "PATCH",
+			Path:     "/v1/node/deny/update/:denyId",
+			Category: "node",
+			Desc:     "更新机器隐藏/禁止规则",
+		},
+		{
+			Method:   "DELETE",
+			Path:     "/v1/node/deny/delete/batch",
+			Category: "node",
+			Desc:     "批量删除机器隐藏/禁止规则",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/node/worker/matrix",
+			Category: "node",
+			Desc:     "获取机器worker部署状态矩阵",
+		},
+		{
+			Method:   "PATCH",
+			Path:     "/v1/node/worker/check/:nodeId",
+			Category: "node",
+			Desc:     "检查机器上的worker状态",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/node/command/list",
+			Category: "node",
+			Desc:     "获取批量命令任务列表",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/node/command/detail/:jobId",
+			Category: "node",
+			Desc:     "获取批量命令任务执行结果",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/node/command/create",
+			Category: "node",
+			Desc:     "在机器上执行命令",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/node/command/download/:jobId",
+			Category: "node",
+			Desc:     "下载批量命令任务输出",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/node/command/ws/:jobId",
+			Category: "node",
+			Desc:     "实时查看批量命令任务输出(websocket)",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/node/command/stream/:jobId",
+			Category: "node",
+			Desc:     "实时查看批量命令任务输出(sse)",
+		},
 		{
 			Method:   "GET",
 			Path:     "/v1/api/list",
@@ -616,6 +749,12 @@ func Data() { //nolint:gocyclo
 			Category: "worker",
 			Desc:     "获取worker列表",
 		},
+		{
+			Method:   "GET",
+			Path:     "/v1/worker/role/:role",
+			Category: "worker",
+			Desc:     "按角色获取worker",
+		},
 		{
 			Method:   "POST",
 			Path:     "/v1/worker/create",
@@ -634,6 +773,96 @@ func Data() { //nolint:gocyclo
 			Category: "worker",
 			Desc:     "批量删除worker",
 		},
+		{
+			Method:   "POST",
+			Path:     "/v1/worker/deploy/:workerId",
+			Category: "worker",
+			Desc:     "在机器上部署worker",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/worker/redeploy/:workerId",
+			Category: "worker",
+			Desc:     "在机器上重新部署worker",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/worker/undeploy/:workerId",
+			Category: "worker",
+			Desc:     "在机器上卸载(停止)worker",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/worker/upgrade/list",
+			Category: "worker",
+			Desc:     "获取worker升级任务列表",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/worker/upgrade/detail/:upgradeId",
+			Category: "worker",
+			Desc:     "获取worker升级任务详情",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/worker/upgrade/create",
+			Category: "worker",
+			Desc:     "创建worker升级任务",
+		},
+		{
+			Method:   "PATCH",
+			Path:     "/v1/worker/upgrade/start/:upgradeId",
+			Category: "worker",
+			Desc:     "开始/继续worker升级任务",
+		},
+		{
+			Method:   "PATCH",
+			Path:     "/v1/worker/upgrade/pause/:upgradeId",
+			Category: "worker",
+			Desc:     "暂停worker升级任务",
+		},
+		{
+			Method:   "PATCH",
+			Path:     "/v1/worker/upgrade/rollback/:upgradeId",
+			Category: "worker",
+			Desc:     "回滚worker升级任务",
+		},
+		{
+			Method:   "PATCH",
+			Path:     "/v1/worker/upgrade/cancel/:upgradeId",
+			Category: "worker",
+			Desc:     "取消worker升级任务",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/worker/cmd/preview",
+			Category: "worker",
+			Desc:     "预览worker部署命令",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/worker/var/list",
+			Category: "worker",
+			Desc:     "获取部署变量列表",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/worker/var/create",
+			Category: "worker",
+			Desc:     "创建部署变量",
+		},
+		{
+			Method:   "PATCH",
+			Path:     "/v1/worker/var/update/:varId",
+			Category: "worker",
+			Desc:     "更新部署变量",
+		},
+		{
+			Method:   "DELETE",
+			Path:     "/v1/worker/var/delete/batch",
+			Category: "worker",
+			Desc:     "批量删除部署变量",
+		},
 		{
 			Method:   "GET",
 			Path:     "/v1/cron/list",
@@ -754,6 +983,240 @@ func Data() { //nolint:gocyclo
 			Category: "secure",
 			Desc:     "修复机器节点安全风险",
 		},
+		{
+			Method:   "GET",
+			Path:     "/v1/performance/profile/list",
+			Category: "performance",
+			Desc:     "获取性能评级规则列表",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/performance/profile/create",
+			Category: "performance",
+			Desc:     "创建性能评级规则",
+		},
+		{
+			Method:   "PATCH",
+			Path:     "/v1/performance/profile/update/:profileId",
+			Category: "performance",
+			Desc:     "更新性能评级规则",
+		},
+		{
+			Method:   "DELETE",
+			Path:     "/v1/performance/profile/delete/batch",
+			Category: "performance",
+			Desc:     "批量删除性能评级规则",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/performance/recompute",
+			Category: "performance",
+			Desc:     "按评级规则重新计算所有机器性能",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/region/list",
+			Category: "region",
+			Desc:     "获取地域网段列表",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/region/create",
+			Category: "region",
+			Desc:     "创建地域网段",
+		},
+		{
+			Method:   "PATCH",
+			Path:     "/v1/region/update/:regionId",
+			Category: "region",
+			Desc:     "更新地域网段",
+		},
+		{
+			Method:   "DELETE",
+			Path:     "/v1/region/delete/batch",
+			Category: "region",
+			Desc:     "批量删除地域网段",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/region/recompute",
+			Category: "region",
+			Desc:     "按地域网段重新计算所有机器地域",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/reservation/list",
+			Category: "reservation",
+			Desc:     "获取机器预约列表",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/reservation/create",
+			Category: "reservation",
+			Desc:     "预约机器",
+		},
+		{
+			Method:   "PATCH",
+			Path:     "/v1/reservation/renew/:reservationId",
+			Category: "reservation",
+			Desc:     "续期机器预约",
+		},
+		{
+			Method:   "PATCH",
+			Path:     "/v1/reservation/release/:reservationId",
+			Category: "reservation",
+			Desc:     "释放机器预约",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/script/list",
+			Category: "script",
+			Desc:     "获取脚本列表",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/script/detail/:scriptId",
+			Category: "script",
+			Desc:     "获取脚本详情",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/script/create",
+			Category: "script",
+			Desc:     "创建脚本",
+		},
+		{
+			Method:   "PATCH",
+			Path:     "/v1/script/update/:scriptId",
+			Category: "script",
+			Desc:     "更新脚本",
+		},
+		{
+			Method:   "DELETE",
+			Path:     "/v1/script/delete/batch",
+			Category: "script",
+			Desc:     "批量删除脚本",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/script/versions/:scriptId",
+			Category: "script",
+			Desc:     "获取脚本历史版本",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/script/diff/:scriptId",
+			Category: "script",
+			Desc:     "对比脚本版本",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/script/run/:scriptId",
+			Category: "script",
+			Desc:     "在机器上执行脚本",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/approval/policy/list",
+			Category: "approval",
+			Desc:     "获取审批策略列表",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/approval/policy/create",
+			Category: "approval",
+			Desc:     "创建审批策略",
+		},
+		{
+			Method:   "PATCH",
+			Path:     "/v1/approval/policy/update/:policyId",
+			Category: "approval",
+			Desc:     "更新审批策略",
+		},
+		{
+			Method:   "DELETE",
+			Path:     "/v1/approval/policy/delete/batch",
+			Category: "approval",
+			Desc:     "批量删除审批策略",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/approval/request/list",
+			Category: "approval",
+			Desc:     "获取变更申请列表",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/approval/request/detail/:requestId",
+			Category: "approval",
+			Desc:     "获取变更申请详情",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/approval/request/approve/:requestId",
+			Category: "approval",
+			Desc:     "批准变更申请",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/approval/request/reject/:requestId",
+			Category: "approval",
+			Desc:     "拒绝变更申请",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/approval/request/cancel/:requestId",
+			Category: "approval",
+			Desc:     "撤回变更申请",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/credential/list",
+			Category: "credential",
+			Desc:     "获取ssh凭据列表",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/credential/create",
+			Category: "credential",
+			Desc:     "创建ssh凭据",
+		},
+		{
+			Method:   "PATCH",
+			Path:     "/v1/credential/update/:credentialId",
+			Category: "credential",
+			Desc:     "更新ssh凭据",
+		},
+		{
+			Method:   "DELETE",
+			Path:     "/v1/credential/delete/batch",
+			Category: "credential",
+			Desc:     "批量删除ssh凭据",
+		},
+		{
+			Method:   "GET",
+			Path:     "/v1/hostkey/list",
+			Category: "hostkey",
+			Desc:     "获取ssh主机公钥列表",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/hostkey/accept/:keyId",
+			Category: "hostkey",
+			Desc:     "接受变更的ssh主机公钥",
+		},
+		{
+			Method:   "POST",
+			Path:     "/v1/hostkey/import",
+			Category: "hostkey",
+			Desc:     "从known_hosts导入ssh主机公钥",
+		},
+		{
+			Method:   "DELETE",
+			Path:     "/v1/hostkey/delete/batch",
+			Category: "hostkey",
+			Desc:     "批量删除ssh主机公钥",
+		},
 	}
 	newApis := make([]models.SysApi, 0)
 	newRoleCasbins := make([]models.SysRoleCasbin, 0)
@@ -812,6 +1275,8 @@ func Data() { //nolint:gocyclo
 		{
 			Name:       "metalmetrics",
 			Desc:       "用于获取机器节点的metrics信息",
+			Role:       models.SysWorkerRoleMetrics,
+			IsPrimary:  &primary,
 			Port:       19091,
 			AutoDeploy: &autoDeploy,
 			DeployCmd: datatypes.JSON(downloadCmd +
@@ -830,6 +1295,8 @@ func Data() { //nolint:gocyclo
 		{
 			Name:       "metaltask",
 			Desc:       "用于接收远程文件并执行",
+			Role:       models.SysWorkerRoleTask,
+			IsPrimary:  &primary,
 			Port:       19092,
 			AutoDeploy: &autoDeploy,
 			DeployCmd: datatypes.JSON(downloadCmd +
@@ -843,23 +1310,26 @@ func Data() { //nolint:gocyclo
 			CheckReq: "metaltask/version",
 		},
 		{
-			Name: "metalsecure",
-			Desc: "用于获取机器节点的安全报告",
-			Port: 19094,
+			Name:      "metalsecure",
+			Desc:      "用于获取机器节点的安全报告",
+			Role:      models.SysWorkerRoleSecure,
+			IsPrimary: &primary,
+			Port:      19094,
 		},
 		{
-			Name: "metaltune",
-			Desc: "用于对机器节点进行调优",
-			Port: 19093,
+			Name:      "metaltune",
+			Desc:      "用于对机器节点进行调优",
+			Role:      models.SysWorkerRoleTune,
+			IsPrimary: &primary,
+			Port:      19093,
 		},
 	}
 	newWorkers := make([]*models.SysWorker, 0)
-	for i, worker := range workers {
-		id := uint(i + 1)
+	for _, worker := range workers {
+		// 按角色判断是否已初始化, 不再依赖固定的id
 		oldWorker := models.SysWorker{}
-		err := global.Mysql.Where("id = ?", id).First(&oldWorker).Error
+		err := global.Mysql.Where("role = ?", worker.Role).First(&oldWorker).Error
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			worker.Id = id
 			worker.Creator = creator
 			newWorkers = append(newWorkers, worker)
 		}
@@ -913,6 +1383,23 @@ func Data() { //nolint:gocyclo
 	if len(newTuneScenes) > 0 {
 		global.Mysql.Create(newTuneScenes)
 	}
+
+	// 7. 初始化默认的性能评级规则
+	oldProfile := models.SysPerformanceProfile{}
+	err := global.Mysql.Where("id = ?", 1).First(&oldProfile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		global.Mysql.Create(&models.SysPerformanceProfile{
+			Model:           models.Model{Id: 1},
+			Name:            "default",
+			Desc:            "默认规则, cpu核数权重40, 内存权重10",
+			CpuWeight:       40, //nolint:gomnd
+			RamWeight:       10, //nolint:gomnd
+			MediumThreshold: 19, //nolint:gomnd
+			HighThreshold:   38, //nolint:gomnd
+			IsDefault:       &status,
+			Creator:         creator,
+		})
+	}
 }
 
 var menuTotal = 0
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"metalflow/models"
 	"metalflow/pkg/global"
+	"metalflow/pkg/utils"
+	"strings"
 	"time"
 
 	"gorm.io/driver/mysql"
@@ -74,6 +76,10 @@ func Mysql() {
 	global.Mysql = db
 	// 表结构
 	autoMigrate()
+	migrateLabelKeys()
+	migrateRegions()
+	migrateNodeDenies()
+	migrateWorkerRoles()
 	global.Log.Info("初始化mysql完成")
 }
 
@@ -88,13 +94,122 @@ func autoMigrate() {
 		new(models.SysNode),
 		new(models.SysOperationLog),
 		new(models.SysWorker),
+		new(models.RelationNodeWorker),
 		new(models.SysCollection),
 		new(models.SysCronShutNode),
 		new(models.SysNodeSecure),
 		new(models.SysNodeTuneScene),
 		new(models.SysNodeTuneLog),
+		new(models.SysNodeMetricsSample),
+		new(models.SysNodeChange),
+		new(models.SysPerformanceProfile),
+		new(models.SysNodeReservation),
+		new(models.SysRegion),
+		new(models.SysNodeDeny),
+		new(models.SysWorkerUpgrade),
+		new(models.SysWorkerUpgradeNode),
+		new(models.SysDeployVar),
+		new(models.SysCommandJob),
+		new(models.SysCommandJobNode),
+		new(models.SysScript),
+		new(models.SysScriptVersion),
+		new(models.SysApprovalPolicy),
+		new(models.SysChangeRequest),
+		new(models.SysChangeDecision),
+		new(models.SysCredential),
+		new(models.SysNodeHostKey),
 	); err != nil {
 		fmt.Println("自动迁移数据表结构失败：", err)
 		global.Log.Error("自动迁移数据表结构失败：", err)
 	}
 }
+
+// 兼容只有名称的旧标签, 按"key=value"格式拆分出键和值
+func migrateLabelKeys() {
+	labels := make([]models.SysLabel, 0)
+	if err := global.Mysql.Where("label_key = ? OR label_key IS NULL", "").Find(&labels).Error; err != nil {
+		global.Log.Error("查询待迁移的标签失败：", err)
+		return
+	}
+	for i := range labels {
+		key, value := utils.ParseLabel(labels[i].Name)
+		err := global.Mysql.Model(&labels[i]).Updates(map[string]any{"label_key": key, "label_value": value}).Error
+		if err != nil {
+			global.Log.Errorf("迁移标签[%s]失败：%v", labels[i].Name, err)
+		}
+	}
+}
+
+// 地域网段表为空时, 使用配置中的网段与地域映射初始化
+func migrateRegions() {
+	var count int64
+	if err := global.Mysql.Model(&models.SysRegion{}).Count(&count).Error; err != nil || count > 0 {
+		return
+	}
+	regions := make([]models.SysRegion, 0)
+	for _, addrInfo := range global.Conf.NodeConf.AddrBind {
+		for _, ip := range addrInfo.Ips {
+			cidr, err := utils.NormalizeCidr(ip)
+			if err != nil {
+				global.Log.Errorf("地域[%s]的网段%s格式错误：%v", addrInfo.Addr, ip, err)
+				continue
+			}
+			regions = append(regions, models.SysRegion{Name: addrInfo.Addr, Cidr: cidr, Creator: creator})
+		}
+	}
+	if len(regions) == 0 {
+		return
+	}
+	if err := global.Mysql.Create(&regions).Error; err != nil {
+		global.Log.Error("初始化地域网段失败：", err)
+	}
+}
+
+// 隐藏/禁止名单为空时, 将配置中隐藏的机器作为禁止规则导入(与原配置同样拒绝创建)
+func migrateNodeDenies() {
+	hide := strings.TrimSpace(global.Conf.NodeConf.Hide)
+	if hide == "" {
+		return
+	}
+	var count int64
+	if err := global.Mysql.Model(&models.SysNodeDeny{}).Count(&count).Error; err != nil || count > 0 {
+		return
+	}
+	block := models.SysNodeDenyBlock
+	denies := make([]models.SysNodeDeny, 0)
+	for _, address := range strings.Split(hide, ",") {
+		cidr, err := utils.NormalizeCidr(address)
+		if err != nil {
+			global.Log.Errorf("隐藏的机器%s格式错误：%v", address, err)
+			continue
+		}
+		denies = append(denies, models.SysNodeDeny{Cidr: cidr, Action: &block, Reason: "node.hide", Creator: creator})
+	}
+	if len(denies) == 0 {
+		return
+	}
+	if err := global.Mysql.Create(&denies).Error; err != nil {
+		global.Log.Error("初始化机器隐藏/禁止名单失败：", err)
+	}
+}
+
+// 兼容没有角色的内置worker, 按名称补充角色并设置为主版本
+func migrateWorkerRoles() {
+	roles := map[string]string{
+		"metalmetrics": models.SysWorkerRoleMetrics,
+		"metaltask":    models.SysWorkerRoleTask,
+		"metalsecure":  models.SysWorkerRoleSecure,
+		"metaltune":    models.SysWorkerRoleTune,
+	}
+	for name, role := range roles {
+		var count int64
+		if err := global.Mysql.Model(&models.SysWorker{}).Where("role = ?", role).Count(&count).Error; err != nil || count > 0 {
+			continue
+		}
+		err := global.Mysql.Model(&models.SysWorker{}).Where("name = ? AND (role = ? OR role IS NULL)", name, "").
+			Updates(map[string]any{"role": role, "is_primary": 1}).Error
+		if err != nil {
+			global.Log.Errorf("迁移worker[%s]角色失败：%v", name, err)
+		}
+	}
+}
@@ -59,5 +59,12 @@ func Routers() *gin.Engine {
 	router.InitCronShutNodeRouter(v1Group, authMiddleware) // 注册定时开关机任务路由
 	router.InitSecureRouter(v1Group, authMiddleware)       // 注册节点安全路由
 	router.InitTuneRouter(v1Group, authMiddleware)         // 注册系统调优路由
+	router.InitPerformanceRouter(v1Group, authMiddleware)  // 注册性能评级规则路由
+	router.InitReservationRouter(v1Group, authMiddleware)  // 注册机器预约路由
+	router.InitRegionRouter(v1Group, authMiddleware)       // 注册地域网段路由
+	router.InitScriptRouter(v1Group, authMiddleware)       // 注册脚本库路由
+	router.InitApprovalRouter(v1Group, authMiddleware)     // 注册审批路由
+	router.InitCredentialRouter(v1Group, authMiddleware)   // 注册ssh凭据路由
+	router.InitNodeHostKeyRouter(v1Group, authMiddleware)  // 注册ssh主机公钥路由
 	return r
 }
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"encoding/json"
+
+	"gorm.io/datatypes"
+)
+
+// 需要审批的操作类型
+const (
+	ChangeActionReboot       = "reboot"        // 批量重启
+	ChangeActionNodeDelete   = "node.delete"   // 删除机器
+	ChangeActionCronShut     = "cron.shut"     // 创建或修改定时关机
+	ChangeActionSecureFix    = "secure.fix"    // 安全漏洞修复
+	ChangeActionTuneRollback = "tune.rollback" // 回滚调优
+	ChangeActionCommand      = "command"       // 在机器上执行命令(包括上传后执行的文件)
+	ChangeActionScript       = "script"        // 在机器上执行脚本库中的脚本
+)
+
+// 审批策略状态
+const (
+	SysApprovalPolicyDisable uint = 0
+	SysApprovalPolicyEnable  uint = 1
+)
+
+// 变更申请状态
+const (
+	SysChangeRequestPending  uint = 0 // 待审批
+	SysChangeRequestExecuted uint = 1 // 已批准并执行成功
+	SysChangeRequestFailed   uint = 2 // 已批准但执行失败
+	SysChangeRequestRejected uint = 3 // 已拒绝
+	SysChangeRequestExpired  uint = 4 // 已过期
+	SysChangeRequestCanceled uint = 5 // 申请人已撤回
+	SysChangeRequestApproved uint = 6 // 已批准, 执行中
+)
+
+// 审批决定
+const (
+	SysChangeDecisionApprove = "approve"
+	SysChangeDecisionReject  = "reject"
+)
+
+// SysApprovalPolicy 审批策略, 操作的机器中有匹配标签选择器的机器时需要指定角色的用户审批
+type SysApprovalPolicy struct {
+	Model
+	Name           string  `gorm:"comment:'策略名称'" json:"name"`
+	Action         string  `gorm:"index:idx_action;comment:'操作类型'" json:"action"`
+	Selector       string  `gorm:"comment:'标签选择器, 为空时匹配所有机器'" json:"selector"`
+	ApproverRoleId uint    `gorm:"comment:'审批人角色id'" json:"approverRoleId"`
+	ApproverRole   SysRole `gorm:"foreignKey:ApproverRoleId" json:"approverRole"`
+	ExpireHours    uint    `gorm:"comment:'申请的有效期(小时), 为0时为24小时'" json:"expireHours"`
+	Status         *uint   `gorm:"type:tinyint(1);default:1;comment:'状态(0:停用 1:启用)'" json:"status"`
+	Creator        string  `gorm:"comment:'创建人'" json:"creator"`
+}
+
+func (m *SysApprovalPolicy) TableName() string {
+	return m.Model.TableName("sys_approval_policy")
+}
+
+// SysChangeRequest 变更申请, 审批通过后执行申请的操作
+type SysChangeRequest struct {
+	Model
+	Action         string              `gorm:"index:idx_action;comment:'操作类型'" json:"action"`
+	PolicyId       uint                `gorm:"comment:'匹配的审批策略id'" json:"policyId"`
+	NodeIds        datatypes.JSON      `gorm:"comment:'操作的机器id'" json:"nodeIds"`
+	Payload        datatypes.JSON      `gorm:"comment:'操作参数'" json:"payload"`
+	Reason         string              `gorm:"comment:'申请原因'" json:"reason"`
+	Requester      string              `gorm:"index:idx_requester;comment:'申请人'" json:"requester"`
+	ApproverRoleId uint                `gorm:"comment:'审批人角色id'" json:"approverRoleId"`
+	ExpiredAt      LocalTime           `gorm:"comment:'过期时间'" json:"expiredAt"`
+	Status         *uint               `gorm:"type:tinyint(1);default:0;index:idx_status;comment:'状态(0:待审批 1:已执行 2:执行失败 3:已拒绝 4:已过期 5:已撤回 6:执行中)'" json:"status"` //nolint:lll
+	Result         string              `gorm:"type:text;comment:'执行结果或失败原因'" json:"result"`
+	ExecutedAt     LocalTime           `gorm:"comment:'执行时间'" json:"executedAt"`
+	Decisions      []SysChangeDecision `gorm:"foreignKey:RequestId" json:"decisions,omitempty"`
+}
+
+func (m *SysChangeRequest) TableName() string {
+	return m.Model.TableName("sys_change_request")
+}
+
+// GetNodeIds 获取操作的机器id
+func (m *SysChangeRequest) GetNodeIds() []uint {
+	ids := make([]uint, 0)
+	if len(m.NodeIds) > 0 {
+		_ = json.Unmarshal(m.NodeIds, &ids)
+	}
+	return ids
+}
+
+// SysChangeDecision 变更申请的审批记录
+type SysChangeDecision struct {
+	Model
+	RequestId uint   `gorm:"index:idx_request_id;comment:'变更申请id'" json:"requestId"`
+	Approver  string `gorm:"comment:'审批人'" json:"approver"`
+	Decision  string `gorm:"comment:'审批决定(approve/reject)'" json:"decision"`
+	Comment   string `gorm:"comment:'审批意见'" json:"comment"`
+}
+
+func (m *SysChangeDecision) TableName() string {
+	return m.Model.TableName("sys_change_decision")
+}
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/datatypes"
+)
+
+// 批量命令任务的类型
+const (
+	SysCommandJobKindCommand      = "command"       // 通过接口执行的命令/脚本
+	SysCommandJobKindUpload       = "upload"        // 上传文件(可选择执行)
+	SysCommandJobKindScript       = "script"        // 执行脚本库中的脚本
+	SysCommandJobKindReboot       = "reboot"        // 重启
+	SysCommandJobKindShut         = "shut"          // 定时关机
+	SysCommandJobKindStart        = "start"         // 定时开机(远程唤醒)
+	SysCommandJobKindSecureFix    = "secure.fix"    // 安全漏洞修复
+	SysCommandJobKindSecureBare   = "secure.bare"   // 裸金属安全修复
+	SysCommandJobKindSecureDocker = "secure.docker" // docker安全修复
+	SysCommandJobKindSecureScan   = "secure.scan"   // 安全扫描(镜像、漏洞、SBOM、风险数及评分)
+	SysCommandJobKindTuneCleanup  = "tune.cleanup"  // 系统清理
+	SysCommandJobKindTuneTurbo    = "tune.turbo"    // 一键加速
+	SysCommandJobKindTuneScene    = "tune.scene"    // 设置调优场景
+	SysCommandJobKindTuneRollback = "tune.rollback" // 回滚调优
+	SysCommandJobKindTuneAuto     = "tune.auto"     // 智能调优
+	SysCommandJobKindWorkerDeploy = "worker.deploy" // worker部署/重新部署/卸载
+)
+
+// 批量命令任务的发起方式
+const (
+	SysCommandJobTriggerUser   = "user"   // 用户通过接口发起, 创建人为用户名
+	SysCommandJobTriggerCron   = "cron"   // 定时任务发起, 创建人为定时任务关键字
+	SysCommandJobTriggerSystem = "system" // 其他后台流程发起
+)
+
+// 批量命令任务的执行方式
+const (
+	SysCommandJobExecutorMetaltask = "metaltask" // 通过metaltask上传执行, 执行结束后才能获取输出
+	SysCommandJobExecutorSsh       = "ssh"       // 通过ssh执行, 可实时获取输出
+)
+
+// 批量命令任务状态
+const (
+	SysCommandJobRunning     uint = 0 // 执行中
+	SysCommandJobCompleted   uint = 1 // 全部成功
+	SysCommandJobFailed      uint = 2 // 存在失败的机器
+	SysCommandJobAborted     uint = 3 // 失败过多, 中止执行剩余的机器
+	SysCommandJobInterrupted uint = 4 // 服务重启, 执行中断
+)
+
+// 批量命令任务中单台机器的状态
+const (
+	SysCommandJobNodePending uint = 0 // 待执行
+	SysCommandJobNodeRunning uint = 1 // 执行中
+	SysCommandJobNodeSuccess uint = 2 // 执行成功
+	SysCommandJobNodeFailed  uint = 3 // 执行失败
+	SysCommandJobNodeSkipped uint = 4 // 任务中止, 未执行
+)
+
+// 默认的最大并发数
+const defaultExecParallelism = 10
+
+// ExecStrategy 批量执行策略, 零值时最多10台并发, 一次执行全部机器
+type ExecStrategy struct {
+	Parallelism       uint `json:"parallelism" form:"parallelism"`             // 最大并发数, 为0时为10
+	BatchSize         uint `json:"batchSize" form:"batchSize"`                 // 每批的机器数, 为0时按batchPercent计算
+	BatchPercent      uint `json:"batchPercent" form:"batchPercent"`           // 每批机器数占总数的百分比, 均为0时不分批
+	PauseSeconds      uint `json:"pauseSeconds" form:"pauseSeconds"`           // 批次之间的暂停时间(秒)
+	StopOnFailure     bool `json:"stopOnFailure" form:"stopOnFailure"`         // 出现失败的机器后不再执行剩余的机器
+	MaxFailurePercent uint `json:"maxFailurePercent" form:"maxFailurePercent"` // 失败机器数超过总数的该百分比后不再执行剩余的机器, 为0时不限制
+}
+
+// Validate 校验百分比参数
+func (s ExecStrategy) Validate() error {
+	if s.BatchPercent > 100 || s.MaxFailurePercent > 100 {
+		return errors.New("the batch percent and max failure percent must not be greater than 100")
+	}
+	return nil
+}
+
+// IsZero 是否未设置策略
+func (s ExecStrategy) IsZero() bool {
+	return s == ExecStrategy{}
+}
+
+// GetParallelism 获取最大并发数
+func (s ExecStrategy) GetParallelism() int {
+	if s.Parallelism == 0 {
+		return defaultExecParallelism
+	}
+	return int(s.Parallelism)
+}
+
+// GetBatchSize 获取total台机器时每批的机器数, 按百分比计算时至少为1
+func (s ExecStrategy) GetBatchSize(total int) int {
+	size := int(s.BatchSize)
+	if size == 0 && s.BatchPercent > 0 {
+		size = total * int(s.BatchPercent) / 100
+		if size == 0 {
+			size = 1
+		}
+	}
+	if size == 0 || size > total {
+		size = total
+	}
+	return size
+}
+
+// JSON 转换为用于保存的json, 未设置时为空
+func (s ExecStrategy) JSON() datatypes.JSON {
+	if s.IsZero() {
+		return nil
+	}
+	b, _ := json.Marshal(s)
+	return b
+}
+
+// ParseExecStrategy 解析保存的执行策略
+func ParseExecStrategy(data datatypes.JSON) ExecStrategy {
+	var strategy ExecStrategy
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &strategy)
+	}
+	return strategy
+}
+
+// ShouldStop total台机器中failed台失败时是否中止执行剩余的机器
+func (s ExecStrategy) ShouldStop(failed, total int) bool {
+	if failed == 0 {
+		return false
+	}
+	if s.StopOnFailure {
+		return true
+	}
+	return s.MaxFailurePercent > 0 && failed*100 > total*int(s.MaxFailurePercent)
+}
+
+// SysCommandJob 在机器上执行的远程操作记录(上传执行脚本、调优等), 记录发起方、参数及每台机器的输出
+type SysCommandJob struct {
+	Model
+	Kind          string              `gorm:"index:idx_kind;comment:'任务类型'" json:"kind"`
+	Name          string              `gorm:"comment:'任务名称'" json:"name"`
+	FilePath      string              `gorm:"comment:'上传的文件名'" json:"filePath"`
+	RemoteDir     string              `gorm:"comment:'上传到机器上的目录'" json:"remoteDir"`
+	IsRunnable    bool                `gorm:"comment:'上传后是否执行'" json:"isRunnable"`
+	Content       string              `gorm:"type:text;comment:'执行的脚本内容(上传文件时为空)'" json:"content"`
+	Executor      string              `gorm:"comment:'执行方式(metaltask/ssh), 为空时为metaltask'" json:"executor"`
+	Params        datatypes.JSON      `gorm:"comment:'操作参数'" json:"params"`
+	ScriptId      uint                `gorm:"comment:'脚本库中的脚本id'" json:"scriptId"`
+	ScriptVersion uint                `gorm:"comment:'脚本版本号'" json:"scriptVersion"`
+	Strategy      datatypes.JSON      `gorm:"comment:'执行策略'" json:"strategy"`
+	Status        *uint               `gorm:"type:tinyint(1);default:0;index:idx_status;comment:'状态(0:执行中 1:全部成功 2:存在失败 3:已中止 4:已中断)'" json:"status"` //nolint:lll
+	NodeCount     uint                `gorm:"comment:'机器数'" json:"nodeCount"`
+	SuccessCount  uint                `gorm:"comment:'成功的机器数'" json:"successCount"`
+	FailedCount   uint                `gorm:"comment:'失败的机器数'" json:"failedCount"`
+	SkippedCount  uint                `gorm:"comment:'因中止未执行的机器数'" json:"skippedCount"`
+	FinishedAt    LocalTime           `gorm:"comment:'结束时间'" json:"finishedAt"`
+	Trigger       string              `gorm:"index:idx_trigger;comment:'发起方式(user:用户 cron:定时任务 system:系统)'" json:"trigger"`
+	Creator       string              `gorm:"comment:'发起人(用户名或定时任务关键字)'" json:"creator"`
+	Nodes         []SysCommandJobNode `gorm:"foreignKey:JobId" json:"nodes,omitempty"`
+}
+
+func (m *SysCommandJob) TableName() string {
+	return m.Model.TableName("sys_command_job")
+}
+
+// Error 将失败机器的错误合并为一个错误, 均成功时返回nil
+func (m *SysCommandJob) Error() error {
+	errAddrs := make([]string, 0)
+	for _, node := range m.Nodes { //nolint:gocritic
+		if node.Status != nil && *node.Status == SysCommandJobNodeFailed {
+			errAddrs = append(errAddrs, fmt.Sprintf("[%s]运行失败: %s。", node.Address, node.Stderr))
+		}
+	}
+	if m.SkippedCount > 0 {
+		errAddrs = append(errAddrs, fmt.Sprintf("失败过多, %d台机器未执行。", m.SkippedCount))
+	}
+	if len(errAddrs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(errAddrs, " "))
+}
+
+// SysCommandJobNode 批量命令任务中单台机器的执行结果
+// metaltask不返回实际的退出码, 执行成功记为0, 脚本执行报错记为1, 未能执行(连接、传输失败或超时)时为空; ssh执行时为实际的退出码
+type SysCommandJobNode struct {
+	Model
+	JobId      uint      `gorm:"index:idx_job_id;comment:'任务id'" json:"jobId"`
+	NodeId     uint      `gorm:"index:idx_node_id;comment:'机器id'" json:"nodeId"`
+	Address    string    `gorm:"comment:'主机地址(ip)'" json:"address"`
+	Status     *uint     `gorm:"type:tinyint(1);default:0;comment:'状态(0:待执行 1:执行中 2:执行成功 3:执行失败 4:未执行)'" json:"status"`
+	Stdout     string    `gorm:"type:longtext;comment:'标准输出'" json:"stdout"`
+	Stderr     string    `gorm:"type:longtext;comment:'错误输出'" json:"stderr"`
+	ExitCode   *int      `gorm:"comment:'退出码'" json:"exitCode"`
+	Duration   int64     `gorm:"comment:'执行耗时(毫秒)'" json:"duration"`
+	StartedAt  LocalTime `gorm:"comment:'开始时间'" json:"startedAt"`
+	FinishedAt LocalTime `gorm:"comment:'结束时间'" json:"finishedAt"`
+}
+
+func (m *SysCommandJobNode) TableName() string {
+	return m.Model.TableName("sys_command_job_node")
+}
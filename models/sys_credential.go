@@ -0,0 +1,29 @@
+package models
+
+// ssh凭据类型
+const (
+	SysCredentialPassword = "password" // 密码
+	SysCredentialKey      = "key"      // 私钥(可带密码)
+)
+
+// SysCredential ssh凭据, 密码、私钥及私钥密码使用主密钥加密保存
+// 可使用的用户为usernames与roleIds的并集(均为空时所有用户可用), 可使用的机器需匹配标签选择器(为空时所有机器可用)
+type SysCredential struct {
+	Model
+	Name        string `gorm:"index:idx_name;comment:'凭据名称'" json:"name"`
+	Type        string `gorm:"comment:'凭据类型(password/key)'" json:"type"`
+	Username    string `gorm:"comment:'默认ssh用户名'" json:"username"`
+	Password    string `gorm:"type:text;comment:'加密的密码'" json:"-"`
+	PrivateKey  string `gorm:"type:text;comment:'加密的私钥'" json:"-"`
+	Passphrase  string `gorm:"type:text;comment:'加密的私钥密码'" json:"-"`
+	Fingerprint string `gorm:"comment:'私钥对应公钥的SHA256指纹'" json:"fingerprint"`
+	Usernames   string `gorm:"comment:'可使用的用户名, 逗号分隔'" json:"usernames"`
+	RoleIds     string `gorm:"comment:'可使用的角色id, 逗号分隔'" json:"roleIds"`
+	Selector    string `gorm:"comment:'可使用的机器的标签选择器'" json:"selector"`
+	Remark      string `gorm:"comment:'备注'" json:"remark"`
+	Creator     string `gorm:"comment:'创建人'" json:"creator"`
+}
+
+func (m *SysCredential) TableName() string {
+	return m.Model.TableName("sys_credential")
+}
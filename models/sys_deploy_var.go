@@ -0,0 +1,15 @@
+package models
+
+// SysDeployVar worker部署命令模板中可使用的变量, 地域为空时为全局变量, 否则只对该地域的机器生效并覆盖全局变量
+type SysDeployVar struct {
+	Model
+	Name    string `gorm:"index:idx_name_region;comment:'变量名称'" json:"name"`
+	Region  string `gorm:"index:idx_name_region;comment:'地域(为空时为全局变量)'" json:"region"`
+	Value   string `gorm:"comment:'变量值'" json:"value"`
+	Remark  string `gorm:"comment:'说明'" json:"remark"`
+	Creator string `gorm:"comment:'创建人'" json:"creator"`
+}
+
+func (m *SysDeployVar) TableName() string {
+	return m.Model.TableName("sys_deploy_var")
+}
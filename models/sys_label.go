@@ -7,7 +7,9 @@ import (
 
 type SysLabel struct {
 	Model
-	Name    string    `gorm:"comment:'标签名称'" json:"name,omitempty"`
+	Name    string    `gorm:"comment:'标签名称(key=value)'" json:"name,omitempty"`
+	Key     string    `gorm:"column:label_key;index:idx_label_key;comment:'标签键'" json:"key"`
+	Value   string    `gorm:"column:label_value;comment:'标签值'" json:"value"`
 	Creator string    `gorm:"comment:'创建人'" json:"creator"`
 	Nodes   []SysNode `gorm:"many2many:sys_node_label_relation" json:"nodes,omitempty"`
 }
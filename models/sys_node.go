@@ -1,6 +1,10 @@
 package models
 
-import "gorm.io/datatypes"
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
 
 const (
 	SysNodeHealthNormal   uint = 0 // 运行中
@@ -8,6 +12,11 @@ const (
 	SysNodeHealthShutdown uint = 2 // 已停机
 )
 
+const (
+	SysNodeMaintenanceOff uint = 0 // 正常
+	SysNodeMaintenanceOn  uint = 1 // 维护中
+)
+
 // SysNode 机器节点信息
 type SysNode struct {
 	Model
@@ -19,16 +28,39 @@ type SysNode struct {
 	Manager         string         `gorm:"comment:'责任人'" json:"manager"`
 	Health          *uint          `gorm:"type:tinyint(1);comment:'健康度(0:运行中 1:异常 2:已停机)';default:0" json:"health"`
 	Performance     *uint          `gorm:"type:tinyint(1);comment:'性能(0:高 1:中 2:低)';default:0" json:"performance"`
+	ProfileId       uint           `gorm:"comment:'性能评级规则id(0:使用默认规则)';default:0" json:"profileId"`
 	PingStat        *uint          `gorm:"type:tinyint(1);comment:'ping状态'" json:"pingStat"`
 	Region          string         `gorm:"comment:'地域'" json:"region"`
 	Remark          string         `gorm:"comment:'说明'" json:"remark"`
 	Creator         string         `gorm:"comment:'创建人'" json:"creator"`
 	Metrics         string         `gorm:"comment:'机器配置'" json:"metrics"`
+	CpuCores        uint           `gorm:"comment:'cpu核数';default:0" json:"cpuCores"`
+	CpuModel        string         `gorm:"comment:'cpu型号'" json:"cpuModel"`
+	RamBytes        uint64         `gorm:"comment:'内存容量(字节)';default:0" json:"ramBytes"`
+	DiskBytes       uint64         `gorm:"comment:'磁盘总容量(字节)';default:0" json:"diskBytes"`
+	NicSpeed        uint           `gorm:"comment:'网卡最大速率(Mb/s)';default:0" json:"nicSpeed"`
 	Information     datatypes.JSON `gorm:"comment:'机器详情'" json:"information"`
 	Labels          []SysLabel     `gorm:"many2many:sys_node_label_relation" json:"labels"`
 	RefreshLastTime LocalTime      `gorm:"comment:'上次刷新时间'" json:"refreshLastTime"`
 	RefreshCount    *uint          `gorm:"comment:'刷新次数';default:0" json:"refreshCount"`
 	Workers         []*SysWorker   `gorm:"many2many:sys_node_worker_relation" json:"workers"`
+	// 机器上各worker的部署状态与健康检查结果
+	WorkerHealth []RelationNodeWorker `gorm:"foreignKey:SysNodeId" json:"workerHealth,omitempty"`
+	// 当前持有该机器的预约
+	Reservations []SysNodeReservation `gorm:"foreignKey:NodeId" json:"reservations,omitempty"`
+	// 维护模式下不发送告警邮件, 定时开关机任务跳过该机器, 批量操作需强制执行
+	Maintenance       *uint     `gorm:"type:tinyint(1);comment:'维护模式(0:否 1:是)';default:0" json:"maintenance"`
+	MaintenanceReason string    `gorm:"comment:'维护原因'" json:"maintenanceReason"`
+	MaintenanceOwner  string    `gorm:"comment:'维护负责人'" json:"maintenanceOwner"`
+	MaintenanceExpire LocalTime `gorm:"comment:'维护到期时间(为空时不过期)'" json:"maintenanceExpire"`
+}
+
+// InMaintenance 是否处于维护模式, 超过到期时间后自动失效
+func (m *SysNode) InMaintenance() bool {
+	if m.Maintenance == nil || *m.Maintenance != SysNodeMaintenanceOn {
+		return false
+	}
+	return m.MaintenanceExpire.IsZero() || time.Now().Before(m.MaintenanceExpire.Time)
 }
 
 func (m *SysNode) TableName() string {
@@ -0,0 +1,15 @@
+package models
+
+// SysNodeChange 机器节点两次刷新之间的硬件与配置变更记录
+type SysNodeChange struct {
+	Model
+	NodeId   uint   `gorm:"index:idx_node_id;comment:'机器id'" json:"nodeId"`
+	Address  string `gorm:"comment:'主机地址(ip)'" json:"address"`
+	Field    string `gorm:"comment:'变更字段(cpu/ram/disk/kernel/os/mac/eth/users)'" json:"field"`
+	OldValue string `gorm:"type:text;comment:'变更前的值'" json:"oldValue"`
+	NewValue string `gorm:"type:text;comment:'变更后的值'" json:"newValue"`
+}
+
+func (m *SysNodeChange) TableName() string {
+	return m.Model.TableName("sys_node_change")
+}
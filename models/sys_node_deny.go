@@ -0,0 +1,24 @@
+package models
+
+const (
+	SysNodeDenyHide  uint = 0 // 隐藏: 不在机器列表中展示, 定时任务及告警跳过
+	SysNodeDenyBlock uint = 1 // 禁止: 在隐藏的基础上拒绝注册、创建及任何操作
+)
+
+// SysNodeDeny 机器隐藏/禁止名单
+type SysNodeDeny struct {
+	Model
+	Cidr    string `gorm:"index:idx_cidr;comment:'网段(CIDR格式, 支持ipv6及单个ip)'" json:"cidr"`
+	Action  *uint  `gorm:"type:tinyint(1);comment:'动作(0:隐藏 1:禁止)';default:0" json:"action"`
+	Reason  string `gorm:"comment:'原因'" json:"reason"`
+	Creator string `gorm:"comment:'创建人'" json:"creator"`
+}
+
+// IsBlock 是否为禁止
+func (m *SysNodeDeny) IsBlock() bool {
+	return m.Action != nil && *m.Action == SysNodeDenyBlock
+}
+
+func (m *SysNodeDeny) TableName() string {
+	return m.Model.TableName("sys_node_deny")
+}
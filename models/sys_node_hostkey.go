@@ -0,0 +1,33 @@
+package models
+
+// 主机公钥来源
+const (
+	SysNodeHostKeySourceTofu   = "tofu"   // 首次连接时保存
+	SysNodeHostKeySourceImport = "import" // 从known_hosts导入
+	SysNodeHostKeySourceAccept = "accept" // 管理员接受变更后的公钥
+)
+
+// 主机公钥不一致时的处理方式
+const (
+	SysNodeHostKeyMismatchReject = "reject"
+	SysNodeHostKeyMismatchWarn   = "warn"
+)
+
+// SysNodeHostKey 机器的ssh主机公钥, 每个地址每种公钥类型一条记录
+// 连接时服务端提供的公钥与保存的不一致时记录为待确认公钥, 管理员接受后替换
+type SysNodeHostKey struct {
+	Model
+	Address            string    `gorm:"uniqueIndex:uk_address_key_type;comment:'机器地址'" json:"address"`
+	KeyType            string    `gorm:"uniqueIndex:uk_address_key_type;comment:'公钥类型'" json:"keyType"`
+	PublicKey          string    `gorm:"type:text;comment:'公钥(authorized_keys格式)'" json:"publicKey"`
+	Fingerprint        string    `gorm:"comment:'公钥的SHA256指纹'" json:"fingerprint"`
+	Source             string    `gorm:"comment:'来源(tofu/import/accept)'" json:"source"`
+	PendingKey         string    `gorm:"type:text;comment:'不一致时服务端提供的公钥'" json:"pendingKey"`
+	PendingFingerprint string    `gorm:"comment:'不一致时服务端提供的公钥指纹'" json:"pendingFingerprint"`
+	MismatchAt         LocalTime `gorm:"comment:'最近一次公钥不一致的时间'" json:"mismatchAt"`
+	Creator            string    `gorm:"comment:'创建人'" json:"creator"`
+}
+
+func (m *SysNodeHostKey) TableName() string {
+	return m.Model.TableName("sys_node_hostkey")
+}
@@ -0,0 +1,19 @@
+package models
+
+// SysNodeMetricsSample 机器节点metrics历史采样, 每次刷新节点信息时新增一条
+type SysNodeMetricsSample struct {
+	Model
+	NodeId    uint    `gorm:"index:idx_node_id;comment:'机器id'" json:"nodeId"`
+	Cpu       string  `gorm:"comment:'cpu原始信息'" json:"cpu"`
+	Ram       string  `gorm:"comment:'内存原始信息'" json:"ram"`
+	Disk      string  `gorm:"comment:'磁盘原始信息'" json:"disk"`
+	Io        string  `gorm:"comment:'io原始信息'" json:"io"`
+	CpuUsage  float64 `gorm:"comment:'cpu使用率(%)'" json:"cpuUsage"`
+	RamUsage  float64 `gorm:"comment:'内存使用率(%)'" json:"ramUsage"`
+	DiskUsage float64 `gorm:"comment:'磁盘使用率(%)'" json:"diskUsage"`
+	IoUsage   float64 `gorm:"comment:'io使用率(%)'" json:"ioUsage"`
+}
+
+func (m *SysNodeMetricsSample) TableName() string {
+	return m.Model.TableName("sys_node_metrics_sample")
+}
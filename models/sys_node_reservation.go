@@ -0,0 +1,24 @@
+package models
+
+const (
+	SysNodeReservationActive   uint = 0 // 有效
+	SysNodeReservationReleased uint = 1 // 已释放
+)
+
+// SysNodeReservation 机器节点预约(租用)记录, 同一机器有效预约的时间段不能重叠
+type SysNodeReservation struct {
+	Model
+	NodeId     uint      `gorm:"index:idx_node_id;comment:'机器id'" json:"nodeId"`
+	Address    string    `gorm:"comment:'主机地址(ip)'" json:"address"`
+	Username   string    `gorm:"index:idx_username;comment:'预约人'" json:"username"`
+	Purpose    string    `gorm:"comment:'用途'" json:"purpose"`
+	StartTime  LocalTime `gorm:"comment:'开始时间'" json:"startTime"`
+	EndTime    LocalTime `gorm:"index:idx_end_time;comment:'结束时间'" json:"endTime"`
+	Status     *uint     `gorm:"type:tinyint(1);comment:'状态(0:有效 1:已释放)';default:0" json:"status"`
+	Reminded   *uint     `gorm:"type:tinyint(1);comment:'是否已发送到期提醒(0:否 1:是)';default:0" json:"reminded"`
+	ReleasedBy string    `gorm:"comment:'释放人(到期自动释放时为系统)'" json:"releasedBy"`
+}
+
+func (m *SysNodeReservation) TableName() string {
+	return m.Model.TableName("sys_node_reservation")
+}
@@ -0,0 +1,21 @@
+package models
+
+// SysPerformanceProfile 机器性能评级规则, 分数=各项指标*权重之和/100
+type SysPerformanceProfile struct {
+	Model
+	Name            string  `gorm:"unique;comment:'规则名称'" json:"name"`
+	Desc            string  `gorm:"comment:'说明'" json:"desc"`
+	CpuWeight       float64 `gorm:"comment:'cpu核数权重'" json:"cpuWeight"`
+	RamWeight       float64 `gorm:"comment:'内存容量(GB)权重'" json:"ramWeight"`
+	DiskWeight      float64 `gorm:"comment:'磁盘总容量(GB)权重'" json:"diskWeight"`
+	IoWeight        float64 `gorm:"comment:'io权重'" json:"ioWeight"`
+	NetWeight       float64 `gorm:"comment:'网卡速率(Gb/s)权重'" json:"netWeight"`
+	MediumThreshold float64 `gorm:"comment:'中性能分数下限'" json:"mediumThreshold"`
+	HighThreshold   float64 `gorm:"comment:'高性能分数下限'" json:"highThreshold"`
+	IsDefault       *uint   `gorm:"type:tinyint(1);comment:'是否为默认规则(0:否 1:是)';default:0" json:"isDefault"`
+	Creator         string  `gorm:"comment:'创建人'" json:"creator"`
+}
+
+func (m *SysPerformanceProfile) TableName() string {
+	return m.Model.TableName("sys_performance_profile")
+}
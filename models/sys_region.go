@@ -0,0 +1,14 @@
+package models
+
+// SysRegion 网段与地域的对应关系, ip匹配多个网段时取前缀最长的网段
+type SysRegion struct {
+	Model
+	Name    string `gorm:"comment:'地域名称'" json:"name"`
+	Cidr    string `gorm:"index:idx_cidr;comment:'网段(CIDR格式, 支持ipv6)'" json:"cidr"`
+	Remark  string `gorm:"comment:'说明'" json:"remark"`
+	Creator string `gorm:"comment:'创建人'" json:"creator"`
+}
+
+func (m *SysRegion) TableName() string {
+	return m.Model.TableName("sys_region")
+}
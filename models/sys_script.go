@@ -0,0 +1,104 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"gorm.io/datatypes"
+)
+
+// 脚本参数类型
+const (
+	ScriptParamString = "string"
+	ScriptParamInt    = "int"
+	ScriptParamBool   = "bool"
+	ScriptParamEnum   = "enum"
+)
+
+// ScriptParam 脚本参数, 在脚本中以{{.Params.name}}的形式使用, 字符串及枚举参数输出时自动转义为shell单引号字符串
+type ScriptParam struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Default  string   `json:"default"`
+	Options  []string `json:"options"` // enum类型的可选值
+	Desc     string   `json:"desc"`
+}
+
+// SysScript 脚本库中的脚本, 内容、参数或解释器变化时生成新版本
+type SysScript struct {
+	Model
+	Name        string             `gorm:"index:idx_name;comment:'脚本名称'" json:"name"`
+	Desc        string             `gorm:"comment:'说明'" json:"desc"`
+	Interpreter string             `gorm:"comment:'解释器(如bash、sh、python3)'" json:"interpreter"`
+	Params      datatypes.JSON     `gorm:"comment:'参数定义'" json:"params"`
+	Content     string             `gorm:"type:text;comment:'当前版本的脚本内容'" json:"content"`
+	Version     uint               `gorm:"comment:'当前版本号'" json:"version"`
+	Creator     string             `gorm:"comment:'创建人'" json:"creator"`
+	Versions    []SysScriptVersion `gorm:"foreignKey:ScriptId" json:"versions,omitempty"`
+}
+
+func (m *SysScript) TableName() string {
+	return m.Model.TableName("sys_script")
+}
+
+// SysScriptVersion 脚本的历史版本, 创建后不再修改
+type SysScriptVersion struct {
+	Model
+	ScriptId    uint           `gorm:"uniqueIndex:uk_script_version;comment:'脚本id'" json:"scriptId"`
+	Version     uint           `gorm:"uniqueIndex:uk_script_version;comment:'版本号'" json:"version"`
+	Interpreter string         `gorm:"comment:'解释器'" json:"interpreter"`
+	Params      datatypes.JSON `gorm:"comment:'参数定义'" json:"params"`
+	Content     string         `gorm:"type:text;comment:'脚本内容'" json:"content"`
+	Remark      string         `gorm:"comment:'变更说明'" json:"remark"`
+	Creator     string         `gorm:"comment:'创建人'" json:"creator"`
+}
+
+func (m *SysScriptVersion) TableName() string {
+	return m.Model.TableName("sys_script_version")
+}
+
+// Render 使用参数渲染脚本, 脚本没有以#!开头时按解释器添加
+// 字符串及枚举参数以ShellString传入模板, 输出时自动转义, 避免参数值中的shell元字符被执行
+func (m *SysScriptVersion) Render(params map[string]any) (string, error) {
+	t, err := template.New(fmt.Sprintf("v%d", m.Version)).
+		Funcs(template.FuncMap{"quote": ShellQuote}).
+		Option("missingkey=error").
+		Parse(m.Content)
+	if err != nil {
+		return "", fmt.Errorf("parse script failed: %v", err)
+	}
+	values := make(map[string]any, len(params))
+	for name, value := range params {
+		if v, ok := value.(string); ok {
+			values[name] = ShellString(v)
+		} else {
+			values[name] = value
+		}
+	}
+	var buf bytes.Buffer
+	if !strings.HasPrefix(m.Content, "#!") {
+		fmt.Fprintf(&buf, "#!/usr/bin/env %s\n", m.Interpreter)
+	}
+	if err = t.Execute(&buf, map[string]any{"Params": values}); err != nil {
+		return "", fmt.Errorf("render script failed: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// ShellString 脚本中的字符串参数, 输出时转义为shell单引号字符串, 在模板中比较时仍为原值
+type ShellString string
+
+func (s ShellString) String() string {
+	return "'" + strings.ReplaceAll(string(s), "'", `'\''`) + "'"
+}
+
+// ShellQuote 将值转义为shell单引号字符串, 已转义的ShellString不重复转义, 兼容使用{{quote .Params.name}}的脚本
+func ShellQuote(v any) string {
+	if s, ok := v.(ShellString); ok {
+		return s.String()
+	}
+	return ShellString(fmt.Sprint(v)).String()
+}
@@ -1,15 +1,28 @@
 package models
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"gorm.io/datatypes"
 	"metalflow/pkg/global"
+	"text/template"
+)
+
+// worker的角色, 按角色查找worker, 不依赖数据库编号
+const (
+	SysWorkerRoleMetrics = "metrics" // metalmetrics, 获取机器metrics信息
+	SysWorkerRoleTask    = "task"    // metaltask, 接收远程文件并执行
+	SysWorkerRoleSecure  = "secure"  // metalsecure, 获取机器安全报告
+	SysWorkerRoleTune    = "tune"    // metaltune, 机器调优
 )
 
 type SysWorker struct {
 	Model
 	Name       string         `json:"name" gorm:"comment:'worker名称';unique"`
+	Role       string         `json:"role" gorm:"index:idx_role;comment:'worker角色(metrics/task/secure/tune)'"`
+	Version    string         `json:"version" gorm:"comment:'worker版本'"`
+	IsPrimary  *uint          `json:"isPrimary" gorm:"type:tinyint(1);default:0;comment:'是否为该角色当前使用的worker'"`
 	Desc       string         `json:"desc" gorm:"comment:'worker描述'"`
 	Port       int            `json:"port" gorm:"comment:'worker暴露端口'"`
 	AutoDeploy *uint          `json:"autoDeploy" gorm:"type:tinyint(1);default:0;comment:'是否节点注册时部署'"`
@@ -24,10 +37,32 @@ func (m *SysWorker) TableName() string {
 	return m.Model.TableName("sys_worker")
 }
 
-// RelationNodeWorker save node id which had deployed workers normally.
+// worker在机器上的部署状态
+const (
+	NodeWorkerDeploying uint = 0 // 部署中
+	NodeWorkerRunning   uint = 1 // 运行中
+	NodeWorkerFailed    uint = 2 // 部署失败
+	NodeWorkerStopped   uint = 3 // 已停止
+)
+
+// worker最近一次健康检查(CheckReq)结果
+const (
+	NodeWorkerHealthUnknown  uint = 0 // 未检查
+	NodeWorkerHealthNormal   uint = 1 // 正常
+	NodeWorkerHealthAbnormal uint = 2 // 异常
+)
+
+// RelationNodeWorker save the deployment state of the worker on the node.
 type RelationNodeWorker struct {
-	SysWorkerId uint `json:"sysWorkerId"`
-	SysNodeId   uint `json:"sysNodeId"`
+	SysWorkerId   uint      `gorm:"primaryKey" json:"sysWorkerId"`
+	SysNodeId     uint      `gorm:"primaryKey" json:"sysNodeId"`
+	Status        *uint     `gorm:"type:tinyint(1);default:0;comment:'部署状态(0:部署中 1:运行中 2:部署失败 3:已停止)'" json:"status"`
+	Version       string    `gorm:"comment:'已部署的版本'" json:"version"`
+	Health        *uint     `gorm:"type:tinyint(1);default:0;comment:'最近一次健康检查结果(0:未检查 1:正常 2:异常)'" json:"health"`
+	LastCheckTime LocalTime `gorm:"comment:'最近一次健康检查时间'" json:"lastCheckTime"`
+	CheckFailures uint      `gorm:"default:0;comment:'连续健康检查失败次数'" json:"checkFailures"`
+	LastError     string    `gorm:"type:text;comment:'最近一次部署或检查的错误信息'" json:"lastError"`
+	UpdatedAt     LocalTime `gorm:"comment:'状态更新时间'" json:"updatedAt"`
 }
 
 func (m RelationNodeWorker) TableName() string {
@@ -40,9 +75,79 @@ type CmdStruct struct {
 	Stop     string `json:"stop,omitempty"`
 }
 
-func (m *SysWorker) GetCmd(os string) (*CmdStruct, error) {
+// DeployCmdData 部署命令模板可使用的数据, 如{{.Node.Address}}、{{.Worker.Port}}、{{.Vars.mirror}}、{{index .Node.Labels "env"}}
+// 标签值与部署变量为任意文本, 以ShellString传入模板, 输出时自动转义, 与脚本参数一致
+type DeployCmdData struct {
+	Node   DeployCmdNode
+	Worker DeployCmdWorker
+	Vars   map[string]ShellString // 部署变量, 机器所在地域的变量覆盖全局变量
+}
+
+// DeployCmdNode 部署命令模板中的机器信息
+type DeployCmdNode struct {
+	Id          uint
+	Address     string
+	Region      string
+	Os          string
+	SshPort     uint
+	ServicePort int
+	Labels      map[string]ShellString
+}
+
+// DeployCmdWorker 部署命令模板中的worker信息
+type DeployCmdWorker struct {
+	Id      uint
+	Name    string
+	Role    string
+	Version string
+	Port    int
+}
+
+// Render 将各命令作为go模板渲染, 使用不存在的变量时报错
+func (c *CmdStruct) Render(data *DeployCmdData) (*CmdStruct, error) {
+	var (
+		cmd CmdStruct
+		err error
+	)
+	if cmd.Download, err = renderCmd("download", c.Download, data); err != nil {
+		return nil, err
+	}
+	if cmd.Start, err = renderCmd("start", c.Start, data); err != nil {
+		return nil, err
+	}
+	if cmd.Stop, err = renderCmd("stop", c.Stop, data); err != nil {
+		return nil, err
+	}
+	return &cmd, nil
+}
+
+func renderCmd(name, text string, data *DeployCmdData) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	t, err := template.New(name).
+		Funcs(template.FuncMap{"quote": ShellQuote}).
+		Option("missingkey=error").
+		Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse %s command failed: %v", name, err)
+	}
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render %s command failed: %v", name, err)
+	}
+	return buf.String(), nil
+}
+
+// ParseDeployCmd 解析各操作系统的部署命令
+func ParseDeployCmd(deployCmd []byte) (map[string]*CmdStruct, error) {
 	var osDeployCmd map[string]*CmdStruct
-	err := json.Unmarshal([]byte(m.DeployCmd.String()), &osDeployCmd)
+	err := json.Unmarshal(deployCmd, &osDeployCmd)
+	return osDeployCmd, err
+}
+
+func (m *SysWorker) GetCmd(os string) (*CmdStruct, error) {
+	osDeployCmd, err := ParseDeployCmd([]byte(m.DeployCmd.String()))
 	if err != nil {
 		return nil, err
 	}
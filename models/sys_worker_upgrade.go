@@ -0,0 +1,69 @@
+package models
+
+import "gorm.io/datatypes"
+
+// worker升级任务状态
+const (
+	SysWorkerUpgradePending     uint = 0 // 待开始
+	SysWorkerUpgradeRunning     uint = 1 // 升级中
+	SysWorkerUpgradePaused      uint = 2 // 已暂停
+	SysWorkerUpgradeCompleted   uint = 3 // 已完成
+	SysWorkerUpgradeRollingBack uint = 4 // 回滚中
+	SysWorkerUpgradeRolledBack  uint = 5 // 已回滚
+	SysWorkerUpgradeCancelled   uint = 6 // 已取消
+)
+
+// worker升级任务失败数超过阈值时的处理方式
+const (
+	SysWorkerUpgradeOnFailurePause    uint = 0 // 暂停
+	SysWorkerUpgradeOnFailureRollback uint = 1 // 自动回滚
+)
+
+// worker升级任务中单台机器的状态
+const (
+	SysWorkerUpgradeNodePending    uint = 0 // 待升级
+	SysWorkerUpgradeNodeSuccess    uint = 1 // 升级成功
+	SysWorkerUpgradeNodeFailed     uint = 2 // 升级失败
+	SysWorkerUpgradeNodeRolledBack uint = 3 // 已回滚
+)
+
+// SysWorkerUpgrade worker升级任务, 按批次升级, 第0批为金丝雀机器, 每批完成后进行健康检查
+type SysWorkerUpgrade struct {
+	Model
+	WorkerId      uint                   `gorm:"index:idx_worker_id;comment:'worker id'" json:"workerId"`
+	WorkerName    string                 `gorm:"comment:'worker名称'" json:"workerName"`
+	Version       string                 `gorm:"comment:'升级的目标版本'" json:"version"`
+	DeployCmd     datatypes.JSON         `gorm:"comment:'目标版本的部署命令'" json:"deployCmd"`
+	PrevVersion   string                 `gorm:"comment:'升级前的版本, 用于回滚'" json:"prevVersion"`
+	PrevDeployCmd datatypes.JSON         `gorm:"comment:'升级前的部署命令, 用于回滚'" json:"prevDeployCmd"`
+	BatchSize     uint                   `gorm:"comment:'每批升级的机器数'" json:"batchSize"`
+	BatchCount    uint                   `gorm:"comment:'总批次数(含金丝雀批次)'" json:"batchCount"`
+	CurrentBatch  uint                   `gorm:"comment:'当前批次(从0开始)'" json:"currentBatch"`
+	MaxFailures   uint                   `gorm:"comment:'单批允许的最大失败机器数'" json:"maxFailures"`
+	OnFailure     *uint                  `gorm:"type:tinyint(1);default:0;comment:'失败数超过阈值时(0:暂停 1:自动回滚)'" json:"onFailure"`
+	CheckDelay    uint                   `gorm:"comment:'每批部署后等待多少秒再进行健康检查'" json:"checkDelay"`
+	Status        *uint                  `gorm:"type:tinyint(1);default:0;index:idx_status;comment:'状态(0:待开始 1:升级中 2:已暂停 3:已完成 4:回滚中 5:已回滚 6:已取消)'" json:"status"` //nolint:lll
+	LastError     string                 `gorm:"type:text;comment:'最近一次失败原因'" json:"lastError"`
+	Creator       string                 `gorm:"comment:'创建人'" json:"creator"`
+	Nodes         []SysWorkerUpgradeNode `gorm:"foreignKey:UpgradeId" json:"nodes"`
+}
+
+func (m *SysWorkerUpgrade) TableName() string {
+	return m.Model.TableName("sys_worker_upgrade")
+}
+
+// SysWorkerUpgradeNode worker升级任务中的机器
+type SysWorkerUpgradeNode struct {
+	Model
+	UpgradeId uint   `gorm:"index:idx_upgrade_id;comment:'升级任务id'" json:"upgradeId"`
+	NodeId    uint   `gorm:"comment:'机器id'" json:"nodeId"`
+	Address   string `gorm:"comment:'主机地址(ip)'" json:"address"`
+	Batch     uint   `gorm:"comment:'所在批次(0为金丝雀批次)'" json:"batch"`
+	Status    *uint  `gorm:"type:tinyint(1);default:0;comment:'状态(0:待升级 1:升级成功 2:升级失败 3:已回滚)'" json:"status"`
+	Version   string `gorm:"comment:'健康检查返回的版本'" json:"version"`
+	Error     string `gorm:"type:text;comment:'失败原因'" json:"error"`
+}
+
+func (m *SysWorkerUpgradeNode) TableName() string {
+	return m.Model.TableName("sys_worker_upgrade_node")
+}
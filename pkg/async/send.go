@@ -157,7 +157,7 @@ func (m *Machinery) SendSecureScoreTask(address string, port int) {
 	m.registerTask(SecureScoreTaskName, args)
 }
 
-func (m *Machinery) SetTune(address string, port int, isSave bool) {
+func (m *Machinery) SetTune(address string, port int, isSave bool, creator string) {
 	args := make([]tasks.Arg, 0)
 	args = append(args,
 		tasks.Arg{
@@ -175,6 +175,11 @@ func (m *Machinery) SetTune(address string, port int, isSave bool) {
 			Type:  "bool",
 			Value: isSave,
 		},
+		tasks.Arg{
+			Name:  "creator",
+			Type:  "string",
+			Value: creator,
+		},
 	)
 	m.registerTask(SetTuneTaskName, args)
 }
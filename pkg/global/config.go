@@ -24,6 +24,8 @@ type SystemConfiguration struct {
 	PprofPort                   int      `mapstructure:"pprof-port" json:"pprofPort"`
 	ConnectTimeout              int      `mapstructure:"connect-timeout" json:"connectTimeout"`
 	ExecuteTimeout              int      `mapstructure:"execute-timeout" json:"executeTimeout"`
+	CredentialKey               string   `mapstructure:"credential-key" json:"-"`
+	CredentialKeyFile           string   `mapstructure:"credential-key-file" json:"credentialKeyFile"`
 	Transaction                 bool     `mapstructure:"transaction" json:"transaction"`
 	InitData                    bool     `mapstructure:"init-data" json:"initData"`
 	OperationLogKey             string   `mapstructure:"operation-log-key" json:"operationLogKey"`
@@ -33,6 +35,9 @@ type SystemConfiguration struct {
 	IdempotenceTokenName        string   `mapstructure:"idempotence-token-name" json:"idempotenceTokenName"`
 	NodeMetricsCronTask         string   `mapstructure:"node-metrics-cron-task" json:"nodeMetricsCronTask"`
 	NodePingCronTask            string   `mapstructure:"node-ping-cron-task" json:"nodePingCronTask"`
+	NodeMetricsCleanCronTask    string   `mapstructure:"node-metrics-clean-cron-task" json:"nodeMetricsCleanCronTask"`
+	NodeReservationCronTask     string   `mapstructure:"node-reservation-cron-task" json:"nodeReservationCronTask"`
+	WorkerCheckCronTask         string   `mapstructure:"worker-check-cron-task" json:"workerCheckCronTask"`
 }
 
 type LogsConfiguration struct {
@@ -45,8 +50,13 @@ type LogsConfiguration struct {
 }
 
 type NodeConfiguration struct {
-	AddrBind []NodeAddrConfiguration `mapstructure:"addr-bind" json:"addrBind"`
-	Hide     string                  `mapstructure:"hide" json:"hide"`
+	AddrBind                 []NodeAddrConfiguration `mapstructure:"addr-bind" json:"addrBind"`
+	Hide                     string                  `mapstructure:"hide" json:"hide"`
+	MetricsRetentionDays     int                     `mapstructure:"metrics-retention-days" json:"metricsRetentionDays"`
+	ReservationRemindMinutes int                     `mapstructure:"reservation-remind-minutes" json:"reservationRemindMinutes"`
+	WorkerCheckMaxFailures   uint                    `mapstructure:"worker-check-max-failures" json:"workerCheckMaxFailures"`
+	WorkerCheckRedeploy      bool                    `mapstructure:"worker-check-redeploy" json:"workerCheckRedeploy"`
+	HostKeyMismatch          string                  `mapstructure:"host-key-mismatch" json:"hostKeyMismatch"`
 }
 
 type NodeAddrConfiguration struct {
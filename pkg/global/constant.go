@@ -14,3 +14,9 @@ const (
 	Prod = "release"
 	Test = "test"
 )
+
+// ssh凭据加密的主密钥
+const (
+	CredentialKeyEnv         = "METALFLOW_CREDENTIAL_KEY" // 主密钥环境变量, 优先于配置
+	CredentialKeyPlaceholder = "credential-secret-key"    // 曾随配置文件发布的示例主密钥, 不能使用
+)
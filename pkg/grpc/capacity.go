@@ -0,0 +1,109 @@
+package grpc
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Capacity metalmetrics返回信息中解析出的机器容量, 用于排序与筛选
+type Capacity struct {
+	CpuCores  uint
+	CpuModel  string
+	RamBytes  uint64
+	DiskBytes uint64
+	NicSpeed  uint // 网卡速率(Mb/s), 多网卡时取最大值
+}
+
+var (
+	cpuCoresReg  = regexp.MustCompile(`^\s*(\d+)\s*(?i:cpus?|cores?)?`)
+	sizeReg      = regexp.MustCompile(`(?i)([\d.]+)\s*(bytes|[kmgtp]i?b?|b)\b`)
+	nicSpeedReg  = regexp.MustCompile(`(?i)([\d.]+)\s*([mg])(?:b/s|bps|bit/s|bit)`)
+	sizeUnitsExp = map[string]float64{
+		"b": 0, "bytes": 0,
+		"k": 1, "kb": 1, "kib": 1,
+		"m": 2, "mb": 2, "mib": 2,
+		"g": 3, "gb": 3, "gib": 3,
+		"t": 4, "tb": 4, "tib": 4,
+		"p": 5, "pb": 5, "pib": 5,
+	}
+)
+
+const sizeUnitBase = 1024
+
+// ParseCapacity 将metalmetrics中cpu/ram/disk/网卡的自由文本解析为类型化的容量字段, 磁盘容量为所有磁盘之和
+func ParseCapacity(metric *Metric) Capacity {
+	cores, model := parseCpu(metric.Cpu)
+	disks := parseSizes(GetMetricCapacity(metric.Disk))
+	var diskBytes uint64
+	for _, d := range disks {
+		diskBytes += d
+	}
+	var ramBytes uint64
+	if rams := parseSizes(GetMetricCapacity(metric.Ram)); len(rams) > 0 {
+		ramBytes = rams[0]
+	}
+	nicSpeed := parseNicSpeed(metric.Network)
+	if nicSpeed == 0 {
+		nicSpeed = parseNicSpeed(metric.Eth)
+	}
+	return Capacity{
+		CpuCores:  cores,
+		CpuModel:  model,
+		RamBytes:  ramBytes,
+		DiskBytes: diskBytes,
+		NicSpeed:  nicSpeed,
+	}
+}
+
+// parseCpu 解析如"8 CPU (Intel(R) Xeon(R) Gold 6248)"或"8 CPU Intel Xeon (12.5% used)"的cpu信息
+func parseCpu(cpu string) (cores uint, model string) {
+	loc := cpuCoresReg.FindStringSubmatchIndex(cpu)
+	if loc == nil {
+		return 0, strings.TrimSpace(GetMetricCapacity(cpu))
+	}
+	n, _ := strconv.ParseUint(cpu[loc[2]:loc[3]], 10, 32) //nolint:gomnd
+	// 括号内为使用率时不属于cpu型号
+	rest := strings.TrimSpace(GetMetricCapacity(cpu[loc[1]:]))
+	// 型号整体被括号包裹时去掉最外层括号
+	if strings.HasPrefix(rest, "(") && strings.HasSuffix(rest, ")") {
+		rest = rest[1 : len(rest)-1]
+	}
+	return uint(n), strings.TrimSpace(rest)
+}
+
+// parseSizes 解析文本中所有带单位的容量, 单位按1024进制换算为字节
+func parseSizes(s string) []uint64 {
+	sizes := make([]uint64, 0)
+	for _, m := range sizeReg.FindAllStringSubmatch(s, -1) {
+		exp, ok := sizeUnitsExp[strings.ToLower(m[2])]
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			continue
+		}
+		sizes = append(sizes, uint64(n*math.Pow(sizeUnitBase, exp)))
+	}
+	return sizes
+}
+
+// parseNicSpeed 解析网卡速率, 统一换算为Mb/s并取最大值
+func parseNicSpeed(s string) uint {
+	var speed uint
+	for _, m := range nicSpeedReg.FindAllStringSubmatch(s, -1) {
+		n, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(m[2], "g") {
+			n *= 1000
+		}
+		if uint(n) > speed {
+			speed = uint(n)
+		}
+	}
+	return speed
+}
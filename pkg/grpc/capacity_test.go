@@ -0,0 +1,76 @@
+package grpc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCapacity(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric Metric
+		want   Capacity
+	}{
+		{
+			name: "usage",
+			metric: Metric{
+				ModelMetric: ModelMetric{
+					Cpu:  "64 CPU (12.5% used)",
+					Ram:  "256 GB (30.1% used)",
+					Disk: "1.5 TB (40.0% used)",
+				},
+				Network: "eth0 10000Mb/s",
+			},
+			want: Capacity{
+				CpuCores:  64,
+				RamBytes:  256 << 30,
+				DiskBytes: 1536 << 30,
+				NicSpeed:  10000,
+			},
+		},
+		{
+			name: "model",
+			metric: Metric{
+				ModelMetric: ModelMetric{
+					Cpu:  "8 CPU (Intel(R) Xeon(R) Gold 6248)",
+					Ram:  "16384 MiB",
+					Disk: "sda 500 GB, sdb 1 TB",
+				},
+				Eth: "eth0 1Gbps, eth1 100Mbps",
+			},
+			want: Capacity{
+				CpuCores:  8,
+				CpuModel:  "Intel(R) Xeon(R) Gold 6248",
+				RamBytes:  16 << 30,
+				DiskBytes: 1524 << 30,
+				NicSpeed:  1000,
+			},
+		},
+		{
+			name: "disks with usage",
+			metric: Metric{
+				ModelMetric: ModelMetric{
+					Cpu:  "8 CPU (Intel(R) Xeon(R) Gold 6248) (12.5% used)",
+					Disk: "sda 500 GB (40% used), sdb 1 TB (10% used)",
+				},
+			},
+			want: Capacity{
+				CpuCores:  8,
+				CpuModel:  "Intel(R) Xeon(R) Gold 6248",
+				DiskBytes: 1524 << 30,
+			},
+		},
+		{
+			name:   "empty",
+			metric: Metric{},
+			want:   Capacity{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseCapacity(&tt.metric); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCapacity() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
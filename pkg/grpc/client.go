@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"metalflow/models"
 	"metalflow/pkg/global"
 	"metalflow/pkg/grpc/pb"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -105,6 +108,28 @@ func GetNodeInformation(metric *Metric) (string, error) {
 	return string(information), nil
 }
 
+var usageReg = regexp.MustCompile(`\(\s*([\d.]+)\s*%`)
+
+// GetMetricUsage 提取metrics字段括号中的使用率, 如"8 CPU (12.5% used)"返回12.5
+// 没有百分比时其中的数字为容量(如"16384 MiB"), 返回0表示未知
+func GetMetricUsage(metric string) float64 {
+	strs := usageReg.FindStringSubmatch(metric)
+	if len(strs) < 2 {
+		return 0
+	}
+	usage, _ := strconv.ParseFloat(strs[1], 64)
+	return usage
+}
+
+// usageSuffixReg 以百分比开头的括号, 其他括号(如cpu型号中的"(R)")不属于使用率
+var usageSuffixReg = regexp.MustCompile(`\s*\(\s*[\d.]+\s*%[^()]*\)`)
+
+// GetMetricCapacity 去掉metrics字段括号中随时间变化的使用率, 只保留容量信息, 如"8 CPU (12.5% used)"返回"8 CPU"
+// 多块磁盘各自带有使用率时逐个去掉, 如"sda 500 GB (40% used), sdb 1 TB (10% used)"返回"sda 500 GB, sdb 1 TB"
+func GetMetricCapacity(metric string) string {
+	return strings.TrimSpace(usageSuffixReg.ReplaceAllString(metric, ""))
+}
+
 func ConnectGrpc(address string, port int, ctx context.Context) (conn *grpc.ClientConn, err error) {
 	// 根据发现的服务的ip和port建立连接
 	cox, cancel := context.WithTimeout(ctx, time.Duration(global.Conf.System.ConnectTimeout)*time.Second)
@@ -118,3 +143,21 @@ func ConnectGrpc(address string, port int, ctx context.Context) (conn *grpc.Clie
 	}
 	return conn, nil
 }
+
+// CheckWorker 检查worker是否正常, metalmetrics配置了CheckReq时通过SendMetrics发送检查请求并返回其输出(一般为版本号)
+// 其他角色的worker没有可用于检查的接口(如metaltask只提供文件传输), 与未配置CheckReq时一样只检查能否建立连接
+func CheckWorker(address string, port int, role, checkReq string) (string, error) {
+	if checkReq == "" || role != models.SysWorkerRoleMetrics {
+		conn, err := ConnectGrpc(address, port, context.Background())
+		if err != nil {
+			return "", err
+		}
+		_ = conn.Close()
+		return "", nil
+	}
+	output, err := SendFlow(address, port, checkReq)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.Trim(output, "\"")), nil
+}
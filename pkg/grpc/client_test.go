@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"metalflow/models"
+	"metalflow/pkg/global"
+	proto "metalflow/pkg/grpc/uploadpb"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+func TestCheckWorker(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	proto.RegisterTaskProtoServer(server, &proto.UnimplementedTaskProtoServer{})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	global.Conf.System.ConnectTimeout = 5
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	// metaltask只提供文件传输, 配置了CheckReq也只检查能否建立连接
+	output, err := CheckWorker("127.0.0.1", port, models.SysWorkerRoleTask, "metaltask/version")
+	if err != nil || output != "" {
+		t.Errorf("CheckWorker() = %q, %v, want connect-only check", output, err)
+	}
+}
+
+func TestGetMetricCapacity(t *testing.T) {
+	tests := []struct {
+		metric string
+		want   string
+	}{
+		{metric: "8 CPU (12.5% used)", want: "8 CPU"},
+		{metric: "8 CPU (Intel(R) Xeon(R) Gold 6248)", want: "8 CPU (Intel(R) Xeon(R) Gold 6248)"},
+		{metric: "8 CPU (Intel(R) Xeon(R) Gold 6248) (12.5% used)", want: "8 CPU (Intel(R) Xeon(R) Gold 6248)"},
+		{metric: "sda 500 GB (40% used), sdb 1 TB (10% used)", want: "sda 500 GB, sdb 1 TB"},
+		{metric: "16384 MiB", want: "16384 MiB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.metric, func(t *testing.T) {
+			if got := GetMetricCapacity(tt.metric); got != tt.want {
+				t.Errorf("GetMetricCapacity() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetMetricUsage(t *testing.T) {
+	tests := []struct {
+		metric string
+		want   float64
+	}{
+		{metric: "8 CPU (12.5% used)", want: 12.5},
+		{metric: "sda 500 GB ( 40 % used), sdb 1 TB (10% used)", want: 40},
+		{metric: "8 CPU", want: 0},
+		{metric: "16384 MiB", want: 0},
+		{metric: "", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.metric, func(t *testing.T) {
+			if got := GetMetricUsage(tt.metric); got != tt.want {
+				t.Errorf("GetMetricUsage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -25,6 +25,16 @@ type Uploader struct {
 	ctx         context.Context
 	doneRequest chan string
 	failRequest chan string
+	execRequest chan string
+}
+
+// ExecError metaltask执行文件后返回的错误, 区别于连接、传输失败
+type ExecError struct {
+	Output string
+}
+
+func (e *ExecError) Error() string {
+	return fmt.Sprintf("文件传输失败： %s", e.Output)
 }
 
 type FileMetric struct {
@@ -32,6 +42,7 @@ type FileMetric struct {
 	RemoteDir  string
 	IsRunnable bool
 	FileGetter FileGetter
+	Timeout    time.Duration // 传输及执行的超时时间, 为0时使用system.execute-timeout
 }
 
 func NewUploader(ctx context.Context, client proto.TaskProtoClient) *Uploader {
@@ -40,6 +51,7 @@ func NewUploader(ctx context.Context, client proto.TaskProtoClient) *Uploader {
 		client:      client,
 		doneRequest: make(chan string),
 		failRequest: make(chan string),
+		execRequest: make(chan string),
 	}
 	return u
 }
@@ -63,7 +75,11 @@ func Upload(address string, port int, metric FileMetric) (string, error) {
 func uploadFiles(ctx context.Context, client proto.TaskProtoClient, metric FileMetric) (output string, err error) {
 	ul := NewUploader(ctx, client)
 	// 添加传输执行任务超时时间
-	c, cancel := context.WithTimeout(ctx, time.Duration(global.Conf.System.ExecuteTimeout)*time.Second)
+	timeout := metric.Timeout
+	if timeout <= 0 {
+		timeout = time.Duration(global.Conf.System.ExecuteTimeout) * time.Second
+	}
+	c, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	go ul.upload(metric)
@@ -73,6 +89,8 @@ func uploadFiles(ctx context.Context, client proto.TaskProtoClient, metric FileM
 	case output = <-ul.doneRequest:
 	case failedStr := <-ul.failRequest:
 		err = fmt.Errorf("文件传输失败： %s", failedStr)
+	case errOutput := <-ul.execRequest:
+		err = &ExecError{Output: errOutput}
 	case <-c.Done():
 		err = errors.New("文件传输或执行超时")
 	}
@@ -136,7 +154,7 @@ func (u *Uploader) upload(fileMetric FileMetric) {
 	}
 
 	if errOutput := status.Error; errOutput != "" {
-		u.failRequest <- errOutput
+		u.execRequest <- errOutput
 		return
 	}
 	// 如果成功，则将远程metaltask的消息返回
@@ -0,0 +1,53 @@
+package request
+
+import "metalflow/pkg/response"
+
+// ApprovalPolicyListRequestStruct 获取审批策略列表结构体
+type ApprovalPolicyListRequestStruct struct {
+	Name              string `json:"name" form:"name"`
+	Action            string `json:"action" form:"action"`
+	Status            *uint  `json:"status" form:"status"`
+	response.PageInfo        // 分页参数
+}
+
+// CreateApprovalPolicyRequestStruct 创建审批策略结构体
+type CreateApprovalPolicyRequestStruct struct {
+	Name           string   `json:"name" form:"name" validate:"required"`
+	Action         string   `json:"action" form:"action" validate:"required"`
+	Selector       string   `json:"selector" form:"selector"` // 标签选择器, 为空时匹配所有机器
+	ApproverRoleId uint     `json:"approverRoleId" form:"approverRoleId" validate:"required"`
+	ExpireHours    uint     `json:"expireHours" form:"expireHours"` // 申请的有效期(小时), 为0时为24小时
+	Status         *ReqUint `json:"status" form:"status"`
+	Creator        string   `json:"creator" form:"creator"`
+}
+
+// FieldTrans 翻译需要校验的字段名称
+func (s *CreateApprovalPolicyRequestStruct) FieldTrans() map[string]string {
+	m := make(map[string]string, 0)
+	m["Name"] = "策略名称"
+	m["Action"] = "操作类型"
+	m["ApproverRoleId"] = "审批人角色"
+	return m
+}
+
+// UpdateApprovalPolicyRequestStruct 更新审批策略结构体
+type UpdateApprovalPolicyRequestStruct struct {
+	Name           *string  `json:"name" form:"name"`
+	Selector       *string  `json:"selector" form:"selector"`
+	ApproverRoleId *uint    `json:"approverRoleId" form:"approverRoleId"`
+	ExpireHours    *uint    `json:"expireHours" form:"expireHours"`
+	Status         *ReqUint `json:"status" form:"status"`
+}
+
+// ChangeRequestListRequestStruct 获取变更申请列表结构体
+type ChangeRequestListRequestStruct struct {
+	Action            string `json:"action" form:"action"`
+	Status            *uint  `json:"status" form:"status"`
+	Requester         string `json:"requester" form:"requester"`
+	response.PageInfo        // 分页参数
+}
+
+// ChangeDecisionRequestStruct 审批变更申请结构体
+type ChangeDecisionRequestStruct struct {
+	Comment string `json:"comment" form:"comment"`
+}
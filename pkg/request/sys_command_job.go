@@ -0,0 +1,48 @@
+package request
+
+import (
+	"metalflow/models"
+	"metalflow/pkg/response"
+)
+
+// CommandJobListRequestStruct 获取批量命令任务(操作记录)列表结构体
+type CommandJobListRequestStruct struct {
+	Kind              string `json:"kind" form:"kind"`
+	Name              string `json:"name" form:"name"`
+	Status            *uint  `json:"status" form:"status"`
+	Trigger           string `json:"trigger" form:"trigger"` // 发起方式(user/cron/system)
+	Creator           string `json:"creator" form:"creator"`
+	NodeId            uint   `json:"nodeId" form:"nodeId"`       // 只查询包含该机器的任务
+	Address           string `json:"address" form:"address"`     // 只查询包含该地址的任务
+	StartTime         string `json:"startTime" form:"startTime"` // 创建时间范围
+	EndTime           string `json:"endTime" form:"endTime"`
+	response.PageInfo        // 分页参数
+}
+
+// CommandJobExecRequestStruct 命令任务的执行方式
+type CommandJobExecRequestStruct struct {
+	Executor     string              `json:"executor" form:"executor"`         // metaltask或ssh, 为空时为metaltask, ssh执行时可实时查看输出
+	Username     string              `json:"username" form:"username"`         // ssh用户名, 使用凭据时为空则使用凭据的默认用户名
+	Password     string              `json:"password" form:"password"`         // ssh密码
+	CredentialId uint                `json:"credentialId" form:"credentialId"` // 使用的ssh凭据id
+	Timeout      uint                `json:"timeout" form:"timeout"`           // 单台机器的执行超时时间(秒), 为0时使用system.execute-timeout
+	Strategy     models.ExecStrategy `json:"strategy"`                         // 分批执行策略
+}
+
+// CreateCommandJobRequestStruct 在机器上执行命令/脚本结构体, 机器为ids与标签选择器匹配机器的并集
+type CreateCommandJobRequestStruct struct {
+	NodeBatchRequestStruct
+	CommandJobExecRequestStruct
+	Name      string `json:"name" form:"name"`
+	Content   string `json:"content" form:"content" validate:"required"` // 命令或脚本内容, 没有以#!开头时使用bash执行
+	FileName  string `json:"fileName" form:"fileName"`                   // 上传到机器上的脚本文件名, 为空时自动生成
+	RemoteDir string `json:"remoteDir" form:"remoteDir"`                 // 上传到机器上的目录, 为空时为/tmp/
+	Creator   string `json:"creator" form:"creator"`
+}
+
+// FieldTrans 翻译需要校验的字段名称
+func (s *CreateCommandJobRequestStruct) FieldTrans() map[string]string {
+	m := make(map[string]string, 0)
+	m["Content"] = "命令内容"
+	return m
+}
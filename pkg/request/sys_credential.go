@@ -0,0 +1,44 @@
+package request
+
+import "metalflow/pkg/response"
+
+// CredentialListRequestStruct 获取ssh凭据列表结构体
+type CredentialListRequestStruct struct {
+	Name              string `json:"name" form:"name"`
+	Type              string `json:"type" form:"type"`
+	response.PageInfo        // 分页参数
+}
+
+// CreateCredentialRequestStruct 创建ssh凭据结构体, 密码与私钥至少传一个
+type CreateCredentialRequestStruct struct {
+	Name       string   `json:"name" form:"name" validate:"required"`
+	Username   string   `json:"username" form:"username"` // 默认ssh用户名
+	Password   string   `json:"password" form:"password"`
+	PrivateKey string   `json:"privateKey" form:"privateKey"` // PEM格式的私钥
+	Passphrase string   `json:"passphrase" form:"passphrase"` // 私钥密码
+	Usernames  []string `json:"usernames" form:"usernames"`   // 可使用的用户名
+	RoleIds    []uint   `json:"roleIds" form:"roleIds"`       // 可使用的角色id
+	Selector   string   `json:"selector" form:"selector"`     // 可使用的机器的标签选择器
+	Remark     string   `json:"remark" form:"remark"`
+	Creator    string   `json:"creator" form:"creator"`
+}
+
+// FieldTrans 翻译需要校验的字段名称
+func (s *CreateCredentialRequestStruct) FieldTrans() map[string]string {
+	m := make(map[string]string, 0)
+	m["Name"] = "凭据名称"
+	return m
+}
+
+// UpdateCredentialRequestStruct 更新ssh凭据结构体, 未传的字段不修改
+type UpdateCredentialRequestStruct struct {
+	Name       *string   `json:"name" form:"name"`
+	Username   *string   `json:"username" form:"username"`
+	Password   *string   `json:"password" form:"password"`
+	PrivateKey *string   `json:"privateKey" form:"privateKey"`
+	Passphrase *string   `json:"passphrase" form:"passphrase"`
+	Usernames  *[]string `json:"usernames" form:"usernames"`
+	RoleIds    *[]uint   `json:"roleIds" form:"roleIds"`
+	Selector   *string   `json:"selector" form:"selector"`
+	Remark     *string   `json:"remark" form:"remark"`
+}
@@ -1,15 +1,20 @@
 package request
 
-import "metalflow/pkg/response"
+import (
+	"metalflow/models"
+	"metalflow/pkg/response"
+)
 
 type CreateCronShutNodeRequest struct {
-	Name      string   `json:"name" form:"name" validate:"required"`
-	Keyword   string   `json:"keyword" form:"keyword" validate:"required"`
-	StartTime string   `json:"startTime" form:"startTime" validate:"required"`
-	ShutTime  string   `json:"shutTime" form:"shutTime" validate:"required"`
-	NodeIds   []uint   `json:"nodeIds" form:"nodeIds" validate:"required"`
-	Status    *ReqUint `json:"status" form:"status" validate:"required"`
-	Creator   string   `json:"creator,omitempty" form:"creator"`
+	Name      string              `json:"name" form:"name" validate:"required"`
+	Keyword   string              `json:"keyword" form:"keyword" validate:"required"`
+	StartTime string              `json:"startTime" form:"startTime" validate:"required"`
+	ShutTime  string              `json:"shutTime" form:"shutTime" validate:"required"`
+	NodeIds   []uint              `json:"nodeIds" form:"nodeIds" validate:"required_without=Selector"`
+	Selector  string              `json:"selector" form:"selector"` // 标签选择器, 与nodeIds取并集
+	Status    *ReqUint            `json:"status" form:"status" validate:"required"`
+	Creator   string              `json:"creator,omitempty" form:"creator"`
+	Strategy  models.ExecStrategy `json:"strategy"` // 定时关机的分批执行策略
 }
 
 type ListCronShutNodeRequest struct {
@@ -19,11 +24,13 @@ type ListCronShutNodeRequest struct {
 }
 
 type UpdateCronShutNodeRequest struct {
-	Name      string   `json:"name" form:"name"`
-	StartTime string   `json:"startTime" form:"startTime"`
-	ShutTime  string   `json:"shutTime" form:"shutTime"`
-	NodeIds   []uint   `json:"nodeIds" form:"nodeIds"`
-	Status    *ReqUint `json:"status" form:"status"`
+	Name      string               `json:"name" form:"name"`
+	StartTime string               `json:"startTime" form:"startTime"`
+	ShutTime  string               `json:"shutTime" form:"shutTime"`
+	NodeIds   []uint               `json:"nodeIds" form:"nodeIds"`
+	Selector  *string              `json:"selector" form:"selector"`
+	Status    *ReqUint             `json:"status" form:"status"`
+	Strategy  *models.ExecStrategy `json:"strategy"`
 }
 
 func (s *CreateCronShutNodeRequest) FieldTrans() map[string]string {
@@ -0,0 +1,34 @@
+package request
+
+import "metalflow/pkg/response"
+
+// DeployVarListRequestStruct 获取部署变量列表结构体
+type DeployVarListRequestStruct struct {
+	Name              string  `json:"name" form:"name"`
+	Region            *string `json:"region" form:"region"` // 传空字符串时只查询全局变量
+	response.PageInfo         // 分页参数
+}
+
+// CreateDeployVarRequestStruct 创建部署变量结构体
+type CreateDeployVarRequestStruct struct {
+	Name    string `json:"name" form:"name" validate:"required"`
+	Region  string `json:"region" form:"region"` // 为空时为全局变量
+	Value   string `json:"value" form:"value"`
+	Remark  string `json:"remark" form:"remark"`
+	Creator string `json:"creator" form:"creator"`
+}
+
+// UpdateDeployVarRequestStruct 更新部署变量结构体
+type UpdateDeployVarRequestStruct struct {
+	Name   *string `json:"name" form:"name"`
+	Region *string `json:"region" form:"region"`
+	Value  *string `json:"value" form:"value"`
+	Remark *string `json:"remark" form:"remark"`
+}
+
+// FieldTrans 翻译需要校验的字段名称
+func (s *CreateDeployVarRequestStruct) FieldTrans() map[string]string {
+	m := make(map[string]string, 0)
+	m["Name"] = "变量名称"
+	return m
+}
@@ -1,20 +1,29 @@
 package request
 
-import "metalflow/pkg/response"
+import (
+	"metalflow/pkg/response"
+	"metalflow/pkg/utils"
+	"strings"
+)
 
 type LabelListRequestStruct struct {
 	Name              string `json:"name,omitempty" form:"name"`
+	Key               string `json:"key,omitempty" form:"key"`
 	Creator           string `json:"creator,omitempty" form:"creator"`
 	response.PageInfo        // 分页参数
 }
 
 type CreateLabelRequestStruct struct {
-	Name    string `json:"name,omitempty" form:"name" validate:"required"`
+	Name    string `json:"name,omitempty" form:"name"`
+	Key     string `json:"key,omitempty" form:"key"`
+	Value   string `json:"value,omitempty" form:"value"`
 	Creator string `json:"creator,omitempty" form:"creator"`
 }
 
 type UpdateLabelRequestStruct struct {
-	Name string `json:"name,omitempty" form:"name"`
+	Name  string `json:"name,omitempty" form:"name"`
+	Key   string `json:"key,omitempty" form:"key"`
+	Value string `json:"value,omitempty" form:"value"`
 }
 
 func (s *CreateLabelRequestStruct) FieldTrans() map[string]string {
@@ -22,3 +31,31 @@ func (s *CreateLabelRequestStruct) FieldTrans() map[string]string {
 	m["Name"] = "标签名称"
 	return m
 }
+
+// Normalize 补全标签的键值: 未传key时按"key=value"格式拆分name, 否则由key和value生成name
+func (s *CreateLabelRequestStruct) Normalize() error {
+	var err error
+	s.Name, s.Key, s.Value, err = normalizeLabel(s.Name, s.Key, s.Value)
+	return err
+}
+
+// Normalize 补全标签的键值, 都为空时不更新
+func (s *UpdateLabelRequestStruct) Normalize() error {
+	if strings.TrimSpace(s.Name) == "" && strings.TrimSpace(s.Key) == "" {
+		return nil
+	}
+	var err error
+	s.Name, s.Key, s.Value, err = normalizeLabel(s.Name, s.Key, s.Value)
+	return err
+}
+
+func normalizeLabel(name, key, value string) (string, string, string, error) {
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	if key == "" {
+		key, value = utils.ParseLabel(name)
+	}
+	if err := utils.ValidateLabel(key, value); err != nil {
+		return "", "", "", err
+	}
+	return utils.FormatLabel(key, value), key, value, nil
+}
@@ -1,6 +1,7 @@
 package request
 
 import (
+	"metalflow/models"
 	"metalflow/pkg/response"
 )
 
@@ -16,6 +17,15 @@ type NodeListRequestStruct struct {
 	Region            string `json:"region" form:"region"`
 	Remark            string `json:"remark" form:"remark"`
 	Creator           string `json:"creator" form:"creator"`
+	CpuModel          string `json:"cpuModel" form:"cpuModel"`
+	MinCpuCores       uint   `json:"minCpuCores" form:"minCpuCores"` // 最少cpu核数
+	MinRamGb          uint   `json:"minRamGb" form:"minRamGb"`       // 最小内存(GB)
+	MinDiskGb         uint   `json:"minDiskGb" form:"minDiskGb"`     // 最小磁盘总容量(GB)
+	MinNicSpeed       uint   `json:"minNicSpeed" form:"minNicSpeed"` // 最小网卡速率(Mb/s)
+	SortBy            string `json:"sortBy" form:"sortBy"`           // 排序字段(cpuCores/ramBytes/diskBytes/nicSpeed/createdAt)
+	SortDesc          *bool  `json:"sortDesc" form:"sortDesc"`       // 是否降序, 默认降序
+	Selector          string `json:"selector" form:"selector"`       // 标签选择器, 如"env=ci,arch in (x86,arm),!deprecated"
+	Holder            string `json:"holder" form:"holder"`           // 当前预约人
 	response.PageInfo        // 分页参数
 }
 
@@ -35,10 +45,11 @@ type CreateNodeRequestStruct struct {
 }
 
 type NodeShellConnectRequestStruct struct {
-	Address  string  `json:"address" form:"address" validate:"required"`
-	SshPort  ReqUint `json:"sshPort" form:"sshPort" validate:"required"`
-	Username string  `json:"username" form:"username" validate:"required"`
-	Password string  `json:"password" form:"password" validate:"required"`
+	Address      string  `json:"address" form:"address" validate:"required"`
+	SshPort      ReqUint `json:"sshPort" form:"sshPort" validate:"required"`
+	Username     string  `json:"username" form:"username"` // 使用凭据时为空则使用凭据的默认用户名
+	Password     string  `json:"password" form:"password"`
+	CredentialId uint    `json:"credentialId" form:"credentialId"` // 使用的ssh凭据id, 为0时使用密码连接
 }
 
 // NodeShellWsRequestStruct 机器shell_ws请求结构体
@@ -71,10 +82,39 @@ type NodeVncWsRequestStruct struct {
 	Port    int    `json:"port" form:"port" validate:"required"`
 }
 
+// NodeBatchRequestStruct 批量操作机器结构体, 机器为ids与标签选择器匹配机器的并集
+type NodeBatchRequestStruct struct {
+	Req
+	Selector string `json:"selector" form:"selector"` // 标签选择器, 与ids取并集
+	Force    bool   `json:"force" form:"force"`       // 是否强制操作维护中的机器
+}
+
+// NodeRebootRequestStruct 批量重启机器结构体
+type NodeRebootRequestStruct struct {
+	NodeBatchRequestStruct
+	Strategy models.ExecStrategy `json:"strategy"` // 分批重启策略, 设置后在后台执行
+}
+
+// NodeMaintenanceRequestStruct 机器进入维护模式结构体
+type NodeMaintenanceRequestStruct struct {
+	NodeBatchRequestStruct
+	Reason     string `json:"reason" form:"reason" validate:"required"`
+	Owner      string `json:"owner" form:"owner"`           // 维护负责人, 为空时为当前用户
+	ExpireTime string `json:"expireTime" form:"expireTime"` // 维护到期时间, 为空时不过期
+}
+
+// FieldTrans 翻译需要校验的字段名称
+func (s *NodeMaintenanceRequestStruct) FieldTrans() map[string]string {
+	m := make(map[string]string, 0)
+	m["Reason"] = "维护原因"
+	return m
+}
+
 // UpdateNodeRequestStruct 更新机器结构体
 type UpdateNodeRequestStruct struct {
-	LabelIds []ReqUint `json:"labelIds"` // 标签的ids
-	Manager  string    `json:"manager"`  // 机器对应责任人
+	LabelIds  []ReqUint `json:"labelIds"`  // 标签的ids
+	Manager   string    `json:"manager"`   // 机器对应责任人
+	ProfileId *uint     `json:"profileId"` // 性能评级规则id, 0表示使用默认规则
 }
 
 // FieldTrans 翻译需要校验的字段名称
@@ -85,3 +125,50 @@ func (s *CreateNodeRequestStruct) FieldTrans() map[string]string {
 	m["Os"] = "操作系统"
 	return m
 }
+
+// NodeChangeListRequestStruct 机器变更记录查询结构体
+type NodeChangeListRequestStruct struct {
+	Address           string `json:"address" form:"address"`
+	Field             string `json:"field" form:"field"`
+	StartTime         string `json:"startTime" form:"startTime"`
+	EndTime           string `json:"endTime" form:"endTime"`
+	response.PageInfo        // 分页参数
+}
+
+// NodeMetricsHistoryRequestStruct 机器metrics历史查询结构体
+type NodeMetricsHistoryRequestStruct struct {
+	StartTime string  `json:"startTime" form:"startTime"` // 开始时间, 默认为结束时间前一天
+	EndTime   string  `json:"endTime" form:"endTime"`     // 结束时间, 默认为当前时间
+	Interval  ReqUint `json:"interval" form:"interval"`   // 降采样间隔(秒), 默认自动计算
+}
+
+// NodeRecordStruct 批量导入导出的机器字段, labels为"key=value"格式
+type NodeRecordStruct struct {
+	Address     string   `json:"address" yaml:"address"`
+	SshPort     uint     `json:"sshPort" yaml:"sshPort,omitempty"`
+	ServicePort int      `json:"servicePort" yaml:"servicePort,omitempty"`
+	Os          string   `json:"os" yaml:"os,omitempty"`
+	Manager     string   `json:"manager" yaml:"manager,omitempty"`
+	Asset       string   `json:"asset" yaml:"asset,omitempty"`
+	Remark      string   `json:"remark" yaml:"remark,omitempty"`
+	Labels      []string `json:"labels" yaml:"labels,omitempty"`
+}
+
+// NodeImportRequestStruct 批量导入机器结构体, 文件通过表单file字段上传
+type NodeImportRequestStruct struct {
+	Format string `json:"format" form:"format"` // 文件格式(csv/yaml), 为空时根据文件后缀判断
+	DryRun bool   `json:"dryRun" form:"dryRun"` // 只校验并预览, 不写入数据库
+}
+
+// NodeExportRequestStruct 批量导出机器结构体, 筛选条件与机器列表相同
+type NodeExportRequestStruct struct {
+	Format string `json:"format" form:"format"` // 文件格式(csv/yaml), 默认csv
+	NodeListRequestStruct
+}
+
+// NodeRecycleListRequestStruct 回收站机器列表结构体
+type NodeRecycleListRequestStruct struct {
+	Address           string `json:"address" form:"address"` // 原始地址
+	Creator           string `json:"creator" form:"creator"`
+	response.PageInfo        // 分页参数
+}
@@ -0,0 +1,40 @@
+package request
+
+import "metalflow/pkg/response"
+
+// NodeDenyListRequestStruct 获取机器隐藏/禁止名单结构体
+type NodeDenyListRequestStruct struct {
+	Cidr              string `json:"cidr" form:"cidr"`
+	Action            *uint  `json:"action" form:"action"`
+	response.PageInfo        // 分页参数
+}
+
+// CreateNodeDenyRequestStruct 创建机器隐藏/禁止规则结构体
+type CreateNodeDenyRequestStruct struct {
+	Cidr    string `json:"cidr" form:"cidr" validate:"required"` // 支持CIDR、单个ip以及ipv4前缀(如10.12)
+	Action  *uint  `json:"action" form:"action" validate:"required,oneof=0 1"`
+	Reason  string `json:"reason" form:"reason"`
+	Creator string `json:"creator" form:"creator"`
+}
+
+// UpdateNodeDenyRequestStruct 更新机器隐藏/禁止规则结构体
+type UpdateNodeDenyRequestStruct struct {
+	Cidr   *string `json:"cidr" form:"cidr"`
+	Action *uint   `json:"action" form:"action" validate:"omitempty,oneof=0 1"`
+	Reason *string `json:"reason" form:"reason"`
+}
+
+// FieldTrans 翻译需要校验的字段名称
+func (s *CreateNodeDenyRequestStruct) FieldTrans() map[string]string {
+	m := make(map[string]string, 0)
+	m["Cidr"] = "网段"
+	m["Action"] = "动作"
+	return m
+}
+
+// FieldTrans 翻译需要校验的字段名称
+func (s *UpdateNodeDenyRequestStruct) FieldTrans() map[string]string {
+	m := make(map[string]string, 0)
+	m["Action"] = "动作"
+	return m
+}
@@ -0,0 +1,10 @@
+package request
+
+import "metalflow/pkg/response"
+
+// NodeHostKeyListRequestStruct 获取机器主机公钥列表结构体
+type NodeHostKeyListRequestStruct struct {
+	Address           string `json:"address" form:"address"`
+	Mismatch          bool   `json:"mismatch" form:"mismatch"` // 只查询存在不一致的待确认公钥的记录
+	response.PageInfo        // 分页参数
+}
@@ -0,0 +1,40 @@
+package request
+
+import "metalflow/pkg/response"
+
+// NodeReservationListRequestStruct 获取机器预约列表结构体
+type NodeReservationListRequestStruct struct {
+	NodeId            uint   `json:"nodeId" form:"nodeId"`
+	Address           string `json:"address" form:"address"`
+	Username          string `json:"username" form:"username"`
+	Status            *uint  `json:"status" form:"status"`
+	response.PageInfo        // 分页参数
+}
+
+// CreateNodeReservationRequestStruct 预约机器结构体
+type CreateNodeReservationRequestStruct struct {
+	NodeBatchRequestStruct
+	Purpose   string `json:"purpose" form:"purpose" validate:"required"`
+	StartTime string `json:"startTime" form:"startTime"` // 开始时间, 为空时从当前时间开始
+	EndTime   string `json:"endTime" form:"endTime" validate:"required"`
+}
+
+// RenewNodeReservationRequestStruct 续期机器预约结构体
+type RenewNodeReservationRequestStruct struct {
+	EndTime string `json:"endTime" form:"endTime" validate:"required"`
+}
+
+// FieldTrans 翻译需要校验的字段名称
+func (s *CreateNodeReservationRequestStruct) FieldTrans() map[string]string {
+	m := make(map[string]string, 0)
+	m["Purpose"] = "用途"
+	m["EndTime"] = "结束时间"
+	return m
+}
+
+// FieldTrans 翻译需要校验的字段名称
+func (s *RenewNodeReservationRequestStruct) FieldTrans() map[string]string {
+	m := make(map[string]string, 0)
+	m["EndTime"] = "结束时间"
+	return m
+}
@@ -0,0 +1,44 @@
+package request
+
+import "metalflow/pkg/response"
+
+// PerformanceProfileListRequestStruct 获取性能评级规则列表结构体
+type PerformanceProfileListRequestStruct struct {
+	Name              string `json:"name" form:"name"`
+	response.PageInfo        // 分页参数
+}
+
+// CreatePerformanceProfileRequestStruct 创建性能评级规则结构体
+type CreatePerformanceProfileRequestStruct struct {
+	Name            string  `json:"name" form:"name" validate:"required"`
+	Desc            string  `json:"desc" form:"desc"`
+	CpuWeight       float64 `json:"cpuWeight" form:"cpuWeight"`
+	RamWeight       float64 `json:"ramWeight" form:"ramWeight"`
+	DiskWeight      float64 `json:"diskWeight" form:"diskWeight"`
+	IoWeight        float64 `json:"ioWeight" form:"ioWeight"`
+	NetWeight       float64 `json:"netWeight" form:"netWeight"`
+	MediumThreshold float64 `json:"mediumThreshold" form:"mediumThreshold"`
+	HighThreshold   float64 `json:"highThreshold" form:"highThreshold"`
+	IsDefault       *uint   `json:"isDefault" form:"isDefault"`
+	Creator         string  `json:"creator" form:"creator"`
+}
+
+// UpdatePerformanceProfileRequestStruct 更新性能评级规则结构体
+type UpdatePerformanceProfileRequestStruct struct {
+	Desc            *string  `json:"desc" form:"desc"`
+	CpuWeight       *float64 `json:"cpuWeight" form:"cpuWeight"`
+	RamWeight       *float64 `json:"ramWeight" form:"ramWeight"`
+	DiskWeight      *float64 `json:"diskWeight" form:"diskWeight"`
+	IoWeight        *float64 `json:"ioWeight" form:"ioWeight"`
+	NetWeight       *float64 `json:"netWeight" form:"netWeight"`
+	MediumThreshold *float64 `json:"mediumThreshold" form:"mediumThreshold"`
+	HighThreshold   *float64 `json:"highThreshold" form:"highThreshold"`
+	IsDefault       *uint    `json:"isDefault" form:"isDefault"`
+}
+
+// FieldTrans 翻译需要校验的字段名称
+func (s *CreatePerformanceProfileRequestStruct) FieldTrans() map[string]string {
+	m := make(map[string]string, 0)
+	m["Name"] = "规则名称"
+	return m
+}
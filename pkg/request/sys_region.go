@@ -0,0 +1,33 @@
+package request
+
+import "metalflow/pkg/response"
+
+// RegionListRequestStruct 获取地域网段列表结构体
+type RegionListRequestStruct struct {
+	Name              string `json:"name" form:"name"`
+	Cidr              string `json:"cidr" form:"cidr"`
+	response.PageInfo        // 分页参数
+}
+
+// CreateRegionRequestStruct 创建地域网段结构体
+type CreateRegionRequestStruct struct {
+	Name    string `json:"name" form:"name" validate:"required"`
+	Cidr    string `json:"cidr" form:"cidr" validate:"required"` // 支持CIDR、单个ip以及ipv4前缀(如10.12)
+	Remark  string `json:"remark" form:"remark"`
+	Creator string `json:"creator" form:"creator"`
+}
+
+// UpdateRegionRequestStruct 更新地域网段结构体
+type UpdateRegionRequestStruct struct {
+	Name   *string `json:"name" form:"name"`
+	Cidr   *string `json:"cidr" form:"cidr"`
+	Remark *string `json:"remark" form:"remark"`
+}
+
+// FieldTrans 翻译需要校验的字段名称
+func (s *CreateRegionRequestStruct) FieldTrans() map[string]string {
+	m := make(map[string]string, 0)
+	m["Name"] = "地域名称"
+	m["Cidr"] = "网段"
+	return m
+}
@@ -0,0 +1,66 @@
+package request
+
+import (
+	"metalflow/models"
+	"metalflow/pkg/response"
+)
+
+// ScriptListRequestStruct 获取脚本列表结构体
+type ScriptListRequestStruct struct {
+	Name              string `json:"name" form:"name"`
+	Creator           string `json:"creator" form:"creator"`
+	response.PageInfo        // 分页参数
+}
+
+// CreateScriptRequestStruct 创建脚本结构体
+type CreateScriptRequestStruct struct {
+	Name        string               `json:"name" form:"name" validate:"required"`
+	Desc        string               `json:"desc" form:"desc"`
+	Interpreter string               `json:"interpreter" form:"interpreter"` // 为空时为bash
+	Params      []models.ScriptParam `json:"params" form:"params"`
+	Content     string               `json:"content" form:"content" validate:"required"`
+	Remark      string               `json:"remark" form:"remark"` // 版本变更说明
+	Creator     string               `json:"creator" form:"creator"`
+}
+
+// UpdateScriptRequestStruct 更新脚本结构体, 内容、参数或解释器变化时生成新版本
+type UpdateScriptRequestStruct struct {
+	Name        *string               `json:"name" form:"name"`
+	Desc        *string               `json:"desc" form:"desc"`
+	Interpreter *string               `json:"interpreter" form:"interpreter"`
+	Params      *[]models.ScriptParam `json:"params" form:"params"`
+	Content     *string               `json:"content" form:"content"`
+	Remark      string                `json:"remark" form:"remark"` // 版本变更说明
+	Creator     string                `json:"creator" form:"creator"`
+}
+
+// ScriptDiffRequestStruct 对比脚本版本结构体
+type ScriptDiffRequestStruct struct {
+	From uint `json:"from" form:"from" validate:"required"`
+	To   uint `json:"to" form:"to"` // 为0时为当前版本
+}
+
+// RunScriptRequestStruct 在机器上执行脚本结构体, 机器为ids与标签选择器匹配机器的并集
+type RunScriptRequestStruct struct {
+	NodeBatchRequestStruct
+	CommandJobExecRequestStruct
+	Version   uint              `json:"version" form:"version"` // 为0时为当前版本
+	Params    map[string]string `json:"params" form:"params"`
+	RemoteDir string            `json:"remoteDir" form:"remoteDir"` // 上传到机器上的目录, 为空时为/tmp/
+	Creator   string            `json:"creator" form:"creator"`
+}
+
+// FieldTrans 翻译需要校验的字段名称
+func (s *CreateScriptRequestStruct) FieldTrans() map[string]string {
+	m := make(map[string]string, 0)
+	m["Name"] = "脚本名称"
+	m["Content"] = "脚本内容"
+	return m
+}
+
+// FieldTrans 翻译需要校验的字段名称
+func (s *ScriptDiffRequestStruct) FieldTrans() map[string]string {
+	m := make(map[string]string, 0)
+	m["From"] = "对比的版本"
+	return m
+}
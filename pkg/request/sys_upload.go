@@ -37,9 +37,12 @@ type FilePartInfo struct {
 
 type FileMergeInfo struct {
 	AddressIds string `json:"addressIds" form:"addressIds"` // 批量运行脚本时机器id集合字符串
+	Selector   string `json:"selector" form:"selector"`     // 标签选择器, 与addressIds取并集
+	Force      bool   `json:"force" form:"force"`           // 是否强制操作维护中的机器
 	RemoteDir  string `json:"remoteDir" form:"remoteDir"`   // 要传输到远程机器的哪个目录下
 	Runnable   bool   `json:"runnable" form:"runnable"`     // 是否传输后执行
 	FilePartInfo
+	models.ExecStrategy // 分批执行策略
 }
 
 // CleanIdentifier 从文件唯一标识符中去除特殊字符, 只保留数字字母
@@ -4,6 +4,7 @@ import "metalflow/pkg/response"
 
 type WorkerListRequestStruct struct {
 	Name              string `json:"name,omitempty" form:"name"`
+	Role              string `json:"role,omitempty" form:"role"`
 	AutoDeploy        *uint  `json:"autoDeploy,omitempty" form:"autoDeploy"`
 	Creator           string `json:"creator" form:"creator"`
 	response.PageInfo        // 分页参数
@@ -11,6 +12,9 @@ type WorkerListRequestStruct struct {
 
 type CreateWorkerRequestStruct struct {
 	Name       string `json:"name" form:"name" validate:"required"`
+	Role       string `json:"role" form:"role"`
+	Version    string `json:"version" form:"version"`
+	IsPrimary  *uint  `json:"isPrimary" form:"isPrimary"`
 	Desc       string `json:"desc" form:"desc"`
 	AutoDeploy *uint  `json:"autoDeploy" form:"autoDeploy" validate:"required"`
 	Port       int    `json:"port" form:"port" validate:"required"`
@@ -25,6 +29,9 @@ type CreateWorkerRequestStruct struct {
 
 type UpdateWorkerRequestStruct struct {
 	Name       string `json:"name" form:"name"`
+	Role       string `json:"role" form:"role"`
+	Version    string `json:"version" form:"version"`
+	IsPrimary  *uint  `json:"isPrimary" form:"isPrimary"`
 	Desc       string `json:"desc" form:"desc"`
 	AutoDeploy *uint  `json:"autoDeploy" form:"autoDeploy"`
 	Port       int    `json:"port" form:"port"`
@@ -45,3 +52,25 @@ func (s *CreateWorkerRequestStruct) FieldTrans() map[string]string {
 	m["StopCmd"] = "worker停止命令"
 	return m
 }
+
+// NodeWorkerMatrixRequestStruct 机器与worker部署状态矩阵结构体
+type NodeWorkerMatrixRequestStruct struct {
+	Address           string `json:"address" form:"address"`
+	Role              string `json:"role" form:"role"`     // 只展示该角色的worker
+	Status            *uint  `json:"status" form:"status"` // 只展示存在该部署状态worker的机器
+	response.PageInfo        // 分页参数
+}
+
+// WorkerCmdPreviewRequestStruct 预览worker部署命令渲染结果结构体
+type WorkerCmdPreviewRequestStruct struct {
+	WorkerId  uint   `json:"workerId" form:"workerId"`
+	NodeId    uint   `json:"nodeId" form:"nodeId" validate:"required"`
+	DeployCmd string `json:"deployCmd" form:"deployCmd"` // 为空时使用worker已保存的部署命令
+}
+
+// FieldTrans 翻译需要校验的字段名称
+func (s *WorkerCmdPreviewRequestStruct) FieldTrans() map[string]string {
+	m := make(map[string]string, 0)
+	m["NodeId"] = "机器"
+	return m
+}
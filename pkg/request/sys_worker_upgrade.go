@@ -0,0 +1,36 @@
+package request
+
+import "metalflow/pkg/response"
+
+// WorkerUpgradeListRequestStruct 获取worker升级任务列表结构体
+type WorkerUpgradeListRequestStruct struct {
+	WorkerId          uint   `json:"workerId" form:"workerId"`
+	Status            *uint  `json:"status" form:"status"`
+	Creator           string `json:"creator" form:"creator"`
+	response.PageInfo        // 分页参数
+}
+
+// CreateWorkerUpgradeRequestStruct 创建worker升级任务结构体, 升级的机器为ids与标签选择器匹配机器的并集
+type CreateWorkerUpgradeRequestStruct struct {
+	NodeBatchRequestStruct
+	WorkerId    uint   `json:"workerId" form:"workerId" validate:"required"`
+	Version     string `json:"version" form:"version" validate:"required"`
+	DeployCmd   string `json:"deployCmd" form:"deployCmd" validate:"required"` // 目标版本的部署命令, 格式与worker部署命令相同
+	CanaryIds   []uint `json:"canaryIds" form:"canaryIds"`                     // 金丝雀机器, 为空时取第一台机器
+	BatchSize   uint   `json:"batchSize" form:"batchSize" validate:"required"`
+	MaxFailures uint   `json:"maxFailures" form:"maxFailures"` // 单批允许的最大失败机器数
+	OnFailure   *uint  `json:"onFailure" form:"onFailure" validate:"omitempty,oneof=0 1"`
+	CheckDelay  uint   `json:"checkDelay" form:"checkDelay"` // 每批部署后等待多少秒再进行健康检查
+	Creator     string `json:"creator" form:"creator"`
+}
+
+// FieldTrans 翻译需要校验的字段名称
+func (s *CreateWorkerUpgradeRequestStruct) FieldTrans() map[string]string {
+	m := make(map[string]string, 0)
+	m["WorkerId"] = "worker"
+	m["Version"] = "目标版本"
+	m["DeployCmd"] = "部署命令"
+	m["BatchSize"] = "每批机器数"
+	m["OnFailure"] = "失败处理方式"
+	return m
+}
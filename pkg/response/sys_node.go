@@ -11,16 +11,31 @@ type NodeListResponseStruct struct {
 	CreatedAt   string            `json:"createdAt"`
 	Manager     string            `json:"manager"`
 	Metrics     string            `json:"metrics"`
+	CpuCores    uint              `json:"cpuCores"`
+	CpuModel    string            `json:"cpuModel"`
+	RamBytes    uint64            `json:"ramBytes"`
+	DiskBytes   uint64            `json:"diskBytes"`
+	NicSpeed    uint              `json:"nicSpeed"`
 	SshPort     int               `json:"sshPort"`
 	Asset       string            `json:"asset"`
 	Health      *uint             `json:"health"`
 	PingStat    *uint             `json:"pingStat"`
 	Performance *uint             `json:"performance"`
+	ProfileId   uint              `json:"profileId"`
 	Region      string            `json:"region"`
 	Remark      string            `json:"remark"`
 	Creator     string            `json:"creator"`
 	Labels      []models.SysLabel `json:"labels"`
 	Information datatypes.JSON    `json:"information"`
+	// 维护模式
+	Maintenance       *uint            `json:"maintenance"`
+	MaintenanceReason string           `json:"maintenanceReason"`
+	MaintenanceOwner  string           `json:"maintenanceOwner"`
+	MaintenanceExpire models.LocalTime `json:"maintenanceExpire"`
+	// 当前持有该机器的预约
+	Reservations []models.SysNodeReservation `json:"reservations"`
+	// 机器上各worker的部署状态与健康检查结果
+	WorkerHealth []models.RelationNodeWorker `json:"workerHealth"`
 }
 
 type ShellWsFilesResponseStruct struct {
@@ -30,3 +45,54 @@ type ShellWsFilesResponseStruct struct {
 	DirCount   uint                `json:"dirCount"`
 	CurrentDir string              `json:"currentDir"`
 }
+
+type NodeChangeListResponseStruct struct {
+	Id        uint             `json:"id"`
+	NodeId    uint             `json:"nodeId"`
+	Address   string           `json:"address"`
+	Field     string           `json:"field"`
+	OldValue  string           `json:"oldValue"`
+	NewValue  string           `json:"newValue"`
+	CreatedAt models.LocalTime `json:"createdAt"`
+}
+
+// NodeMetricsHistoryItemResponseStruct 降采样后的metrics历史数据点
+type NodeMetricsHistoryItemResponseStruct struct {
+	Time      models.LocalTime `json:"time"`
+	CpuUsage  float64          `json:"cpuUsage"`
+	RamUsage  float64          `json:"ramUsage"`
+	DiskUsage float64          `json:"diskUsage"`
+	IoUsage   float64          `json:"ioUsage"`
+	Count     uint             `json:"count"` // 该数据点包含的采样条数
+}
+
+// NodeImportRowResponseStruct 批量导入机器每一行的结果
+type NodeImportRowResponseStruct struct {
+	Row     int      `json:"row"`
+	Address string   `json:"address"`
+	Action  string   `json:"action"` // create/update/error
+	Errors  []string `json:"errors"`
+}
+
+// NodeImportResponseStruct 批量导入机器的结果
+type NodeImportResponseStruct struct {
+	DryRun  bool                          `json:"dryRun"`
+	Total   int                           `json:"total"`
+	Created int                           `json:"created"`
+	Updated int                           `json:"updated"`
+	Failed  int                           `json:"failed"`
+	Rows    []NodeImportRowResponseStruct `json:"rows"`
+}
+
+// NodeRecycleResponseStruct 回收站中的机器
+type NodeRecycleResponseStruct struct {
+	Id             uint              `json:"id"`
+	Address        string            `json:"address"`        // 删除前的原始地址
+	DeletedAddress string            `json:"deletedAddress"` // 删除时重写后的地址
+	Manager        string            `json:"manager"`
+	Region         string            `json:"region"`
+	Creator        string            `json:"creator"`
+	Labels         []models.SysLabel `json:"labels"`
+	DeletedAt      models.DeletedAt  `json:"deletedAt"`
+	Conflict       bool              `json:"conflict"` // 原始地址已被其他机器使用, 无法直接恢复
+}
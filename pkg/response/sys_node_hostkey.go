@@ -0,0 +1,9 @@
+package response
+
+// NodeHostKeyImportResponseStruct 导入known_hosts的结果
+type NodeHostKeyImportResponseStruct struct {
+	Created int      `json:"created"`
+	Updated int      `json:"updated"`
+	Skipped int      `json:"skipped"` // 未变化或无法导入(哈希地址、通配符、证书机构及吊销标记)的条目
+	Hosts   []string `json:"hosts"`   // 无法导入的主机
+}
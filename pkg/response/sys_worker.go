@@ -1,10 +1,16 @@
 package response
 
-import "gorm.io/datatypes"
+import (
+	"gorm.io/datatypes"
+	"metalflow/models"
+)
 
 type WorkerListResponseStruct struct {
 	Id         uint           `json:"id" form:"id"`
 	Name       string         `json:"name" form:"name"`
+	Role       string         `json:"role" form:"role"`
+	Version    string         `json:"version" form:"version"`
+	IsPrimary  *uint          `json:"isPrimary" form:"isPrimary"`
 	Desc       string         `json:"desc" form:"desc"`
 	Port       int            `json:"port" form:"port"`
 	DeployCmd  datatypes.JSON `json:"deployCmd" form:"deployCmd"`
@@ -14,3 +20,57 @@ type WorkerListResponseStruct struct {
 	Creator    string         `json:"creator" form:"creator"`
 	CreatedAt  string         `json:"createdAt" form:"createdAt"`
 }
+
+// NodeWorkerColumnStruct 部署状态矩阵的列(worker)
+type NodeWorkerColumnStruct struct {
+	Id      uint   `json:"id"`
+	Name    string `json:"name"`
+	Role    string `json:"role"`
+	Version string `json:"version"` // worker当前配置的版本
+}
+
+// NodeWorkerStatusResponseStruct worker在机器上的部署状态
+type NodeWorkerStatusResponseStruct struct {
+	SysWorkerId   uint             `json:"sysWorkerId"`
+	Deployed      bool             `json:"deployed"` // 是否有部署记录, 为false时其余字段无意义
+	Status        *uint            `json:"status"`
+	Version       string           `json:"version"`
+	Outdated      bool             `json:"outdated"` // 已部署版本与worker当前版本不一致
+	Health        *uint            `json:"health"`
+	LastCheckTime models.LocalTime `json:"lastCheckTime"`
+	LastError     string           `json:"lastError"`
+	UpdatedAt     models.LocalTime `json:"updatedAt"`
+}
+
+// NodeWorkerMatrixRowStruct 部署状态矩阵的行(机器), Workers与列的顺序一致
+type NodeWorkerMatrixRowStruct struct {
+	NodeId  uint                             `json:"nodeId"`
+	Address string                           `json:"address"`
+	Health  *uint                            `json:"health"`
+	Workers []NodeWorkerStatusResponseStruct `json:"workers"`
+}
+
+// NodeWorkerMatrixResponseStruct 机器×worker部署状态矩阵
+type NodeWorkerMatrixResponseStruct struct {
+	PageInfo
+	Workers []NodeWorkerColumnStruct    `json:"workers"`
+	List    []NodeWorkerMatrixRowStruct `json:"list"`
+}
+
+// WorkerDeployStepStruct 部署步骤的执行结果
+type WorkerDeployStepStruct struct {
+	Step   string `json:"step"` // download/stop/start
+	Output string `json:"output"`
+	Error  string `json:"error"`
+}
+
+// WorkerDeployResultStruct worker在单台机器上的部署结果
+type WorkerDeployResultStruct struct {
+	NodeId  uint                     `json:"nodeId"`
+	Address string                   `json:"address"`
+	Success bool                     `json:"success"`
+	Skipped bool                     `json:"skipped"` // 已运行当前版本, 跳过部署
+	Status  uint                     `json:"status"`  // 执行后worker在机器上的部署状态
+	Error   string                   `json:"error"`
+	Steps   []WorkerDeployStepStruct `json:"steps"`
+}
@@ -3,6 +3,7 @@ package service //nolint:gofmt
 import (
 	"errors"
 	"fmt"
+	"metalflow/models"
 	"metalflow/pkg/global"
 	"metalflow/pkg/response"
 	"metalflow/pkg/utils"
@@ -16,18 +17,27 @@ import (
 )
 
 type MysqlService struct {
-	TX *gorm.DB // 事务对象实例
-	DB *gorm.DB // 无事务对象实例
+	TX       *gorm.DB // 事务对象实例
+	DB       *gorm.DB // 无事务对象实例
+	Operator string   // 当前登录的用户名, 定时任务等后台流程为空
 }
 
 // New 初始化服务
 func New(c *gin.Context) MysqlService {
 	// 获取事务对象
 	tx := global.GetTx(c)
-	return MysqlService{
+	s := MysqlService{
 		TX: tx,
 		DB: global.Mysql,
 	}
+	if c != nil {
+		if user, ok := c.Get("user"); ok {
+			if u, ok := user.(models.SysUser); ok {
+				s.Operator = u.Username
+			}
+		}
+	}
+	return s
 }
 
 var findCountCache = cache.New(5*time.Minute, 48*time.Hour) //nolint:gomnd
@@ -0,0 +1,558 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"metalflow/models"
+	"metalflow/pkg/grpc"
+	"metalflow/pkg/request"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/thoas/go-funk"
+	"gorm.io/gorm"
+)
+
+// 变更申请默认的有效期(小时)
+const defaultChangeRequestExpireHours = 24
+
+var changeActions = []string{
+	models.ChangeActionReboot,
+	models.ChangeActionNodeDelete,
+	models.ChangeActionCronShut,
+	models.ChangeActionSecureFix,
+	models.ChangeActionTuneRollback,
+	models.ChangeActionCommand,
+	models.ChangeActionScript,
+}
+
+// ChangeCronShutPayload 创建或修改定时关机的变更参数, shutId为0时为创建
+type ChangeCronShutPayload struct {
+	ShutId uint                               `json:"shutId"`
+	Create *request.CreateCronShutNodeRequest `json:"create,omitempty"`
+	Update *request.UpdateCronShutNodeRequest `json:"update,omitempty"`
+}
+
+// ChangeScriptPayload 执行脚本的变更参数
+type ChangeScriptPayload struct {
+	ScriptId uint `json:"scriptId"`
+	request.RunScriptRequestStruct
+}
+
+// GetApprovalPolicies 获取审批策略列表
+func (s *MysqlService) GetApprovalPolicies(req *request.ApprovalPolicyListRequestStruct) ([]models.SysApprovalPolicy, error) {
+	list := make([]models.SysApprovalPolicy, 0)
+	query := s.TX.Model(&models.SysApprovalPolicy{}).Preload("ApproverRole").Order("id")
+	name := strings.TrimSpace(req.Name)
+	if name != "" {
+		query = query.Where("name LIKE ?", fmt.Sprintf("%%%s%%", name))
+	}
+	action := strings.TrimSpace(req.Action)
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+	err := s.Find(query, &req.PageInfo, &list)
+	return list, err
+}
+
+// CreateApprovalPolicy 创建审批策略
+func (s *MysqlService) CreateApprovalPolicy(req *request.CreateApprovalPolicyRequestStruct) (*models.SysApprovalPolicy, error) {
+	policy := &models.SysApprovalPolicy{
+		Name:           strings.TrimSpace(req.Name),
+		Action:         strings.TrimSpace(req.Action),
+		Selector:       strings.TrimSpace(req.Selector),
+		ApproverRoleId: req.ApproverRoleId,
+		ExpireHours:    req.ExpireHours,
+		Creator:        req.Creator,
+	}
+	status := models.SysApprovalPolicyEnable
+	if req.Status != nil {
+		status = uint(*req.Status)
+	}
+	policy.Status = &status
+	if err := s.checkApprovalPolicy(policy); err != nil {
+		return nil, err
+	}
+	return policy, s.TX.Create(policy).Error
+}
+
+// UpdateApprovalPolicyById 更新审批策略
+func (s *MysqlService) UpdateApprovalPolicyById(id uint, req *request.UpdateApprovalPolicyRequestStruct) error {
+	var policy models.SysApprovalPolicy
+	if err := s.TX.Where("id = ?", id).First(&policy).Error; err != nil {
+		return err
+	}
+	if req.Name != nil {
+		policy.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Selector != nil {
+		policy.Selector = strings.TrimSpace(*req.Selector)
+	}
+	if req.ApproverRoleId != nil {
+		policy.ApproverRoleId = *req.ApproverRoleId
+	}
+	if req.ExpireHours != nil {
+		policy.ExpireHours = *req.ExpireHours
+	}
+	if req.Status != nil {
+		status := uint(*req.Status)
+		policy.Status = &status
+	}
+	if err := s.checkApprovalPolicy(&policy); err != nil {
+		return err
+	}
+	return s.TX.Model(&models.SysApprovalPolicy{}).Where("id = ?", id).Updates(map[string]any{
+		"name":             policy.Name,
+		"selector":         policy.Selector,
+		"approver_role_id": policy.ApproverRoleId,
+		"expire_hours":     policy.ExpireHours,
+		"status":           *policy.Status,
+	}).Error
+}
+
+// checkApprovalPolicy 校验操作类型、标签选择器与审批人角色
+func (s *MysqlService) checkApprovalPolicy(policy *models.SysApprovalPolicy) error {
+	if policy.Name == "" {
+		return errors.New("the policy name is required")
+	}
+	if !funk.ContainsString(changeActions, policy.Action) {
+		return fmt.Errorf("the action [%s] does not support approval, supported actions: %s",
+			policy.Action, strings.Join(changeActions, ", "))
+	}
+	if policy.Selector != "" {
+		if _, err := s.WhereLabelSelector(s.TX.Model(&models.SysNode{}), policy.Selector); err != nil {
+			return err
+		}
+	}
+	var role models.SysRole
+	if err := s.TX.Where("id = ?", policy.ApproverRoleId).First(&role).Error; err != nil {
+		return fmt.Errorf("the approver role [%d] does not exist", policy.ApproverRoleId)
+	}
+	return nil
+}
+
+// matchApprovalPolicy 获取操作的机器匹配的第一个启用的审批策略, 没有匹配时返回nil
+func (s *MysqlService) matchApprovalPolicy(action string, nodeIds []uint) (*models.SysApprovalPolicy, error) {
+	policies := make([]models.SysApprovalPolicy, 0)
+	err := s.TX.Where("action = ? AND status = ?", action, models.SysApprovalPolicyEnable).Order("id").Find(&policies).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range policies {
+		policy := &policies[i]
+		if policy.Selector == "" {
+			return policy, nil
+		}
+		if len(nodeIds) == 0 {
+			continue
+		}
+		var ids []uint
+		ids, err = s.GetNodeIdsBySelector(policy.Selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range nodeIds {
+			if funk.ContainsUInt(ids, id) {
+				return policy, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// RequestApproval 操作的机器匹配审批策略时创建变更申请, 返回nil时不需要审批, 可直接执行
+func (s *MysqlService) RequestApproval(action string, nodeIds []uint, payload any, reason string) (*models.SysChangeRequest, error) {
+	policy, err := s.matchApprovalPolicy(action, nodeIds)
+	if err != nil || policy == nil {
+		return nil, err
+	}
+	return s.createChangeRequest(policy, action, nodeIds, payload, reason)
+}
+
+// RequestUploadApproval 上传后执行的文件与执行命令使用相同的审批策略, 需要审批时将文件内容保存为执行命令的变更参数
+// 只有不超过64KB的文本脚本可以审批, 批准后按执行命令创建任务
+func (s *MysqlService) RequestUploadApproval(nodeIds []uint, m grpc.FileMetric, req *request.FileMergeInfo, reason string) (
+	*models.SysChangeRequest, error) {
+	policy, err := s.matchApprovalPolicy(models.ChangeActionCommand, nodeIds)
+	if err != nil || policy == nil {
+		return nil, err
+	}
+	content := readCommandJobContent(m)
+	if content == "" {
+		return nil, errors.New("the uploaded file requires approval to run, only text scripts up to 64KB can be approved")
+	}
+	fileName := path.Base(req.Filename)
+	payload := &request.CreateCommandJobRequestStruct{
+		Name:      fmt.Sprintf("upload %s", fileName),
+		Content:   content,
+		RemoteDir: req.RemoteDir,
+	}
+	if commandJobFileNameReg.MatchString(fileName) {
+		payload.FileName = fileName
+	}
+	payload.Force = req.Force
+	payload.Strategy = req.ExecStrategy
+	return s.createChangeRequest(policy, models.ChangeActionCommand, nodeIds, payload, reason)
+}
+
+// createChangeRequest 按匹配的审批策略创建待审批的变更申请
+func (s *MysqlService) createChangeRequest(policy *models.SysApprovalPolicy, action string, nodeIds []uint, payload any,
+	reason string) (*models.SysChangeRequest, error) {
+	var err error
+	expireHours := policy.ExpireHours
+	if expireHours == 0 {
+		expireHours = defaultChangeRequestExpireHours
+	}
+	status := models.SysChangeRequestPending
+	cr := &models.SysChangeRequest{
+		Action:         action,
+		PolicyId:       policy.Id,
+		Reason:         strings.TrimSpace(reason),
+		Requester:      s.Operator,
+		ApproverRoleId: policy.ApproverRoleId,
+		ExpiredAt:      models.LocalTime{Time: time.Now().Add(time.Duration(expireHours) * time.Hour)},
+		Status:         &status,
+	}
+	if cr.NodeIds, err = json.Marshal(nodeIds); err != nil {
+		return nil, err
+	}
+	if err = checkChangePayload(payload); err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		if cr.Payload, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+	return cr, s.TX.Create(cr).Error
+}
+
+// checkChangePayload 变更参数明文保存, 需要审批的命令及脚本不能使用ssh密码, 只能使用凭据
+func checkChangePayload(payload any) error {
+	var exec *request.CommandJobExecRequestStruct
+	switch p := payload.(type) {
+	case *request.CreateCommandJobRequestStruct:
+		exec = &p.CommandJobExecRequestStruct
+	case *ChangeScriptPayload:
+		exec = &p.CommandJobExecRequestStruct
+	default:
+		return nil
+	}
+	if exec.Password != "" {
+		return errors.New("the ssh password can not be saved in a change request, please use a credential instead")
+	}
+	return nil
+}
+
+// GetChangeRequests 获取变更申请列表, 查询前将已过期的申请记为过期
+func (s *MysqlService) GetChangeRequests(req *request.ChangeRequestListRequestStruct) ([]models.SysChangeRequest, error) {
+	if err := s.expireChangeRequests(); err != nil {
+		return nil, err
+	}
+	list := make([]models.SysChangeRequest, 0)
+	query := s.TX.Model(&models.SysChangeRequest{}).Order("created_at DESC")
+	action := strings.TrimSpace(req.Action)
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+	requester := strings.TrimSpace(req.Requester)
+	if requester != "" {
+		query = query.Where("requester LIKE ?", fmt.Sprintf("%%%s%%", requester))
+	}
+	err := s.Find(query, &req.PageInfo, &list)
+	return list, err
+}
+
+// GetChangeRequestById 获取变更申请及审批记录
+func (s *MysqlService) GetChangeRequestById(id uint) (models.SysChangeRequest, error) {
+	var cr models.SysChangeRequest
+	err := s.TX.Preload("Decisions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Where("id = ?", id).First(&cr).Error
+	return cr, err
+}
+
+// expireChangeRequests 将超过有效期仍未审批的申请记为过期
+func (s *MysqlService) expireChangeRequests() error {
+	return s.DB.Model(&models.SysChangeRequest{}).
+		Where("status = ? AND expired_at < ?", models.SysChangeRequestPending, time.Now()).
+		Update("status", models.SysChangeRequestExpired).Error
+}
+
+// getPendingChangeRequest 获取待审批的变更申请, 已过期时记为过期并返回错误
+func (s *MysqlService) getPendingChangeRequest(id uint) (*models.SysChangeRequest, error) {
+	cr, err := s.GetChangeRequestById(id)
+	if err != nil {
+		return nil, err
+	}
+	if *cr.Status != models.SysChangeRequestPending {
+		return nil, fmt.Errorf("the change request [%d] is not pending", id)
+	}
+	if cr.ExpiredAt.Before(time.Now()) {
+		if err = s.claimChangeRequest(&cr, models.SysChangeRequestExpired); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("the change request [%d] has expired", id)
+	}
+	return &cr, nil
+}
+
+// claimChangeRequest 仅在申请仍待审批时更新状态, 避免同时审批的请求重复执行操作
+func (s *MysqlService) claimChangeRequest(cr *models.SysChangeRequest, status uint) error {
+	result := s.DB.Model(&models.SysChangeRequest{}).
+		Where("id = ? AND status = ?", cr.Id, models.SysChangeRequestPending).
+		Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("the change request [%d] has already been handled", cr.Id)
+	}
+	cr.Status = &status
+	return nil
+}
+
+// checkChangeApprover 审批人不能是申请人, 且需要是策略指定的角色
+func checkChangeApprover(cr *models.SysChangeRequest, approver *models.SysUser) error {
+	if approver.Username == cr.Requester {
+		return errors.New("the requester can not approve or reject the own change request")
+	}
+	if approver.RoleId != cr.ApproverRoleId {
+		return errors.New("the role of the current user is not allowed to approve the change request")
+	}
+	return nil
+}
+
+// setChangeRequestStatus 更新变更申请状态, 使用无事务的连接, 避免执行失败回滚时丢失记录
+func (s *MysqlService) setChangeRequestStatus(cr *models.SysChangeRequest, status uint, result string) error {
+	cr.Status = &status
+	cr.Result = result
+	updates := map[string]any{
+		"status": status,
+		"result": result,
+	}
+	if status == models.SysChangeRequestExecuted || status == models.SysChangeRequestFailed {
+		cr.ExecutedAt = models.LocalTime{Time: time.Now()}
+		updates["executed_at"] = cr.ExecutedAt.Time
+	}
+	return s.DB.Model(&models.SysChangeRequest{}).Where("id = ?", cr.Id).Updates(updates).Error
+}
+
+// recordChangeDecision 记录审批决定
+func (s *MysqlService) recordChangeDecision(cr *models.SysChangeRequest, approver, decision, comment string) error {
+	d := models.SysChangeDecision{
+		RequestId: cr.Id,
+		Approver:  approver,
+		Decision:  decision,
+		Comment:   strings.TrimSpace(comment),
+	}
+	if err := s.DB.Create(&d).Error; err != nil {
+		return err
+	}
+	cr.Decisions = append(cr.Decisions, d)
+	return nil
+}
+
+// ApproveChangeRequest 批准变更申请并以申请人的身份执行操作, 审批记录及执行结果不随执行失败回滚
+func (s *MysqlService) ApproveChangeRequest(id uint, approver *models.SysUser, comment string) (*models.SysChangeRequest, error) {
+	cr, err := s.getPendingChangeRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkChangeApprover(cr, approver); err != nil {
+		return nil, err
+	}
+	if err = s.claimChangeRequest(cr, models.SysChangeRequestApproved); err != nil {
+		return nil, err
+	}
+	if err = s.recordChangeDecision(cr, approver.Username, models.SysChangeDecisionApprove, comment); err != nil {
+		return nil, err
+	}
+	executor := *s
+	executor.Operator = cr.Requester
+	result, execErr := executor.executeChangeRequest(cr)
+	status := models.SysChangeRequestExecuted
+	if execErr != nil {
+		status = models.SysChangeRequestFailed
+		result = execErr.Error()
+	}
+	if err = s.setChangeRequestStatus(cr, status, result); err != nil {
+		return nil, err
+	}
+	return cr, execErr
+}
+
+// RejectChangeRequest 拒绝变更申请
+func (s *MysqlService) RejectChangeRequest(id uint, approver *models.SysUser, comment string) (*models.SysChangeRequest, error) {
+	cr, err := s.getPendingChangeRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkChangeApprover(cr, approver); err != nil {
+		return nil, err
+	}
+	if err = s.claimChangeRequest(cr, models.SysChangeRequestRejected); err != nil {
+		return nil, err
+	}
+	return cr, s.recordChangeDecision(cr, approver.Username, models.SysChangeDecisionReject, comment)
+}
+
+// CancelChangeRequest 申请人撤回待审批的变更申请
+func (s *MysqlService) CancelChangeRequest(id uint, username string) error {
+	cr, err := s.getPendingChangeRequest(id)
+	if err != nil {
+		return err
+	}
+	if cr.Requester != username {
+		return errors.New("only the requester can cancel the change request")
+	}
+	return s.claimChangeRequest(cr, models.SysChangeRequestCanceled)
+}
+
+// executeChangeRequest 执行变更申请的操作, 返回执行结果
+func (s *MysqlService) executeChangeRequest(cr *models.SysChangeRequest) (string, error) {
+	// 审批期间机器可能被加入禁止名单或进入维护, 执行前重新检查
+	ids, err := s.GetBatchNodeIds(cr.GetNodeIds(), "", changeRequestForce(cr))
+	if err != nil {
+		return "", err
+	}
+	switch cr.Action {
+	case models.ChangeActionReboot:
+		var payload request.NodeRebootRequestStruct
+		if err := unmarshalChangePayload(cr, &payload); err != nil {
+			return "", err
+		}
+		job, err := s.BatchRebootNodesByIds(ids, payload.Strategy)
+		if err != nil {
+			return "", err
+		}
+		if job != nil {
+			return fmt.Sprintf("command job [%d] started", job.Id), nil
+		}
+		return fmt.Sprintf("%d nodes rebooted", len(ids)), nil
+	case models.ChangeActionNodeDelete:
+		if err := s.DeleteNodeByIds(ids); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d nodes deleted", len(ids)), nil
+	case models.ChangeActionCronShut:
+		var payload ChangeCronShutPayload
+		if err := unmarshalChangePayload(cr, &payload); err != nil {
+			return "", err
+		}
+		if payload.Create != nil {
+			return "cron shut task created", s.CreateCronShutNode(payload.Create)
+		}
+		if payload.Update == nil {
+			return "", errors.New("the cron shut task in the change request is empty")
+		}
+		return "cron shut task updated", s.UpdateCronShutNodeById(payload.ShutId, payload.Update)
+	case models.ChangeActionSecureFix:
+		var payload request.SecureFix
+		if err := unmarshalChangePayload(cr, &payload); err != nil {
+			return "", err
+		}
+		for _, id := range ids {
+			if err := s.FixSecureRisk(id, payload.CveId); err != nil {
+				return "", err
+			}
+		}
+		return "security issues fixed", nil
+	case models.ChangeActionTuneRollback:
+		var payload request.TuneRollbackRequest
+		if err := unmarshalChangePayload(cr, &payload); err != nil {
+			return "", err
+		}
+		for _, id := range ids {
+			if err := s.Rollback(id, payload.LogId); err != nil {
+				return "", err
+			}
+		}
+		return "tuning rolled back", nil
+	case models.ChangeActionCommand:
+		var payload request.CreateCommandJobRequestStruct
+		if err := unmarshalChangePayload(cr, &payload); err != nil {
+			return "", err
+		}
+		job, err := s.CreateCommandJob(&payload, ids)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("command job [%d] started", job.Id), nil
+	case models.ChangeActionScript:
+		var payload ChangeScriptPayload
+		if err := unmarshalChangePayload(cr, &payload); err != nil {
+			return "", err
+		}
+		job, err := s.RunScript(payload.ScriptId, &payload.RunScriptRequestStruct, ids)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("command job [%d] started", job.Id), nil
+	default:
+		return "", fmt.Errorf("the action [%s] is not supported", cr.Action)
+	}
+}
+
+// changeRequestForce 重启、删除及执行命令脚本按申请时是否强制操作维护中的机器检查, 其他操作只检查禁止名单
+func changeRequestForce(cr *models.SysChangeRequest) bool {
+	switch cr.Action {
+	case models.ChangeActionReboot, models.ChangeActionNodeDelete, models.ChangeActionCommand, models.ChangeActionScript:
+		var payload request.NodeBatchRequestStruct
+		if err := unmarshalChangePayload(cr, &payload); err != nil {
+			return false
+		}
+		return payload.Force
+	default:
+		return true
+	}
+}
+
+func unmarshalChangePayload(cr *models.SysChangeRequest, payload any) error {
+	if len(cr.Payload) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(cr.Payload, payload); err != nil {
+		return fmt.Errorf("the payload of the change request is invalid: %v", err)
+	}
+	return nil
+}
+
+// GetCronShutTargetIds 获取定时关机任务操作的机器, 为指定的机器与标签选择器匹配机器的并集, 修改时未指定的部分使用原任务的设置
+func (s *MysqlService) GetCronShutTargetIds(shutId uint, nodeIds []uint, selector *string) ([]uint, error) {
+	ids := append([]uint(nil), nodeIds...)
+	sel := ""
+	if selector != nil {
+		sel = *selector
+	}
+	if shutId > 0 {
+		var csn models.SysCronShutNode
+		if err := s.TX.Preload("Nodes").Where("id = ?", shutId).First(&csn).Error; err != nil {
+			return nil, err
+		}
+		if len(nodeIds) == 0 {
+			for _, node := range csn.Nodes {
+				ids = append(ids, node.Id)
+			}
+		}
+		if selector == nil {
+			sel = csn.Selector
+		}
+	}
+	if strings.TrimSpace(sel) != "" {
+		selected, err := s.GetNodeIdsBySelector(sel)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, selected...)
+	}
+	return funk.UniqUInt(ids), nil
+}
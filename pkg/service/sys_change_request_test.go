@@ -0,0 +1,300 @@
+package service
+
+import (
+	"metalflow/models"
+	"metalflow/pkg/grpc"
+	"metalflow/pkg/request"
+	tests2 "metalflow/tests"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMysqlService_RequestApproval(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	s.Operator = "alice"
+	columns := []string{"id", "action", "selector", "approver_role_id", "expire_hours", "status"}
+	tests := []struct {
+		name    string
+		invoke  func()
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "no policy",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_approval_policy`").
+					WithArgs(models.ChangeActionReboot, models.SysApprovalPolicyEnable).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "policy matches all nodes",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_approval_policy`").
+					WithArgs(models.ChangeActionReboot, models.SysApprovalPolicyEnable).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(1, models.ChangeActionReboot, "", 2, 0, 1))
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `tb_sys_change_request`").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			want:    true,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke()
+			cr, err := s.RequestApproval(models.ChangeActionReboot, []uint{1, 2}, nil, "maintenance")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RequestApproval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (cr != nil) != tt.want {
+				t.Fatalf("RequestApproval() = %v, want %v", cr, tt.want)
+			}
+			if cr != nil {
+				if cr.Requester != "alice" || cr.ApproverRoleId != 2 || len(cr.GetNodeIds()) != 2 {
+					t.Errorf("RequestApproval() = %+v", cr)
+				}
+				if d := time.Until(cr.ExpiredAt.Time); d < 23*time.Hour || d > 24*time.Hour {
+					t.Errorf("RequestApproval() expiredAt = %v, want 24 hours later", cr.ExpiredAt)
+				}
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("RequestApproval() %v", err)
+			}
+		})
+	}
+}
+
+func TestMysqlService_RequestUploadApproval(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	s.Operator = "alice"
+	columns := []string{"id", "action", "selector", "approver_role_id", "expire_hours", "status"}
+	req := &request.FileMergeInfo{RemoteDir: "/opt/", Runnable: true}
+	req.Filename = "deploy.sh"
+	tests := []struct {
+		name    string
+		content string
+		invoke  func()
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:    "no policy",
+			content: "\x7fELF\xff",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_approval_policy`").
+					WithArgs(models.ChangeActionCommand, models.SysApprovalPolicyEnable).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name:    "binary file",
+			content: "\x7fELF\xff",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_approval_policy`").
+					WithArgs(models.ChangeActionCommand, models.SysApprovalPolicyEnable).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(1, models.ChangeActionCommand, "", 2, 0, 1))
+			},
+			want:    false,
+			wantErr: true,
+		},
+		{
+			name:    "script",
+			content: "#!/bin/bash\nuptime",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_approval_policy`").
+					WithArgs(models.ChangeActionCommand, models.SysApprovalPolicyEnable).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(1, models.ChangeActionCommand, "", 2, 0, 1))
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `tb_sys_change_request`").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			want:    true,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke()
+			m := grpc.FileMetric{FilePath: "deploy.sh", RemoteDir: "/opt/", IsRunnable: true, FileGetter: &cronShellInfo{content: tt.content}}
+			cr, err := s.RequestUploadApproval([]uint{1}, m, req, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RequestUploadApproval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (cr != nil) != tt.want {
+				t.Fatalf("RequestUploadApproval() = %v, want %v", cr, tt.want)
+			}
+			if cr != nil {
+				var payload request.CreateCommandJobRequestStruct
+				if err = unmarshalChangePayload(cr, &payload); err != nil || payload.Content != tt.content ||
+					payload.FileName != "deploy.sh" || payload.RemoteDir != "/opt/" {
+					t.Errorf("RequestUploadApproval() payload = %+v, err %v", payload, err)
+				}
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("RequestUploadApproval() %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckChangeApprover(t *testing.T) {
+	cr := &models.SysChangeRequest{Requester: "alice", ApproverRoleId: 2}
+	tests := []struct {
+		name     string
+		approver models.SysUser
+		wantErr  bool
+	}{
+		{
+			name:     "success",
+			approver: models.SysUser{Username: "bob", RoleId: 2},
+			wantErr:  false,
+		},
+		{
+			name:     "approve own request",
+			approver: models.SysUser{Username: "alice", RoleId: 2},
+			wantErr:  true,
+		},
+		{
+			name:     "role not allowed",
+			approver: models.SysUser{Username: "bob", RoleId: 3},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkChangeApprover(cr, &tt.approver); (err != nil) != tt.wantErr {
+				t.Errorf("checkChangeApprover() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckChangePayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload any
+		wantErr bool
+	}{
+		{
+			name:    "no payload",
+			payload: nil,
+			wantErr: false,
+		},
+		{
+			name: "command with credential",
+			payload: &request.CreateCommandJobRequestStruct{
+				CommandJobExecRequestStruct: request.CommandJobExecRequestStruct{CredentialId: 1},
+			},
+			wantErr: false,
+		},
+		{
+			name: "command with password",
+			payload: &request.CreateCommandJobRequestStruct{
+				CommandJobExecRequestStruct: request.CommandJobExecRequestStruct{Username: "root", Password: "123456"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "script with password",
+			payload: &ChangeScriptPayload{
+				ScriptId: 1,
+				RunScriptRequestStruct: request.RunScriptRequestStruct{
+					CommandJobExecRequestStruct: request.CommandJobExecRequestStruct{Username: "root", Password: "123456"},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkChangePayload(tt.payload); (err != nil) != tt.wantErr {
+				t.Errorf("checkChangePayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMysqlService_ApproveChangeRequest(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	columns := []string{"id", "action", "node_ids", "requester", "approver_role_id", "expired_at", "status"}
+	tests := []struct {
+		name   string
+		invoke func()
+	}{
+		{
+			name: "not pending",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_change_request`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(1, models.ChangeActionReboot, "[1]", "alice", 2, time.Now().Add(time.Hour), models.SysChangeRequestRejected))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_change_decision`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+		},
+		{
+			name: "approve own request",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_change_request`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(1, models.ChangeActionReboot, "[1]", "bob", 2, time.Now().Add(time.Hour), models.SysChangeRequestPending))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_change_decision`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+		},
+		{
+			name: "expired",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_change_request`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(1, models.ChangeActionReboot, "[1]", "alice", 2, time.Now().Add(-time.Hour), models.SysChangeRequestPending))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_change_decision`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `tb_sys_change_request`").
+					WithArgs(models.SysChangeRequestExpired, tests2.AnyTime{}, 1, models.SysChangeRequestPending).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "handled by another approver",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_change_request`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(1, models.ChangeActionReboot, "[1]", "alice", 2, time.Now().Add(time.Hour), models.SysChangeRequestPending))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_change_decision`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `tb_sys_change_request`").
+					WithArgs(models.SysChangeRequestApproved, tests2.AnyTime{}, 1, models.SysChangeRequestPending).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+	}
+	approver := &models.SysUser{Username: "bob", RoleId: 2}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke()
+			if _, err := s.ApproveChangeRequest(1, approver, "ok"); err == nil {
+				t.Errorf("ApproveChangeRequest() error = nil, wantErr true")
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("ApproveChangeRequest() %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,685 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/grpc"
+	"metalflow/pkg/request"
+	"metalflow/pkg/utils"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+// 记录的脚本内容最大长度, 超过或不是文本时不记录
+const commandJobMaxContentSize = 64 * 1024
+
+var commandJobFileNameReg = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// commandJobAction 在单台机器上执行的操作, 返回输出; 能实时获取输出时通过onLine逐行回调(stream为stdout或stderr)
+type commandJobAction func(address string, onLine func(stream, line string)) (string, error)
+
+// uploadAction 通过metaltask上传(并执行)文件, 执行结束后才返回输出
+func uploadAction(m grpc.FileMetric, port int) commandJobAction {
+	return func(address string, _ func(stream, line string)) (string, error) {
+		return grpc.Upload(address, port, m)
+	}
+}
+
+// sshAction 通过ssh上传并执行脚本, 实时回调输出, 超时后断开连接; 连接时校验主机公钥
+func sshAction(db *gorm.DB, nodes []*models.SysNode, credential *SshCredential, m grpc.FileMetric, content string) commandJobAction {
+	ports := make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		ports[node.Address] = int(node.SshPort)
+	}
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = time.Duration(global.Conf.System.ExecuteTimeout) * time.Second
+	}
+	remotePath := path.Join(m.RemoteDir, m.FilePath)
+	cmd := fmt.Sprintf(`f=%s; cat > "$f" && chmod +x "$f" && "$f"`, models.ShellQuote(remotePath))
+	return func(address string, onLine func(stream, line string)) (string, error) {
+		port := ports[address]
+		if port == 0 {
+			port = 22 //nolint:gomnd
+		}
+		hostKey, err := hostKeyOption(db, address)
+		if err != nil {
+			return "", err
+		}
+		client, err := utils.GetSshClient(credential.Config(address, port, hostKey))
+		if err != nil {
+			return "", err
+		}
+		defer func() {
+			_ = client.Close()
+		}()
+		var timedOut int32
+		timer := time.AfterFunc(timeout, func() {
+			atomic.StoreInt32(&timedOut, 1)
+			_ = client.Close()
+		})
+		defer timer.Stop()
+		err = utils.RunSshCommand(client, cmd, strings.NewReader(content), func(stderr bool, line string) {
+			if stderr {
+				onLine("stderr", line)
+			} else {
+				onLine("stdout", line)
+			}
+		})
+		if atomic.LoadInt32(&timedOut) == 1 {
+			return "", fmt.Errorf("执行超过%s, 已断开连接", timeout)
+		}
+		return "", err
+	}
+}
+
+// GetCommandJobs 获取批量命令任务列表
+func (s *MysqlService) GetCommandJobs(req *request.CommandJobListRequestStruct) ([]models.SysCommandJob, error) {
+	list := make([]models.SysCommandJob, 0)
+	query := s.TX.Model(&models.SysCommandJob{}).Order("created_at DESC")
+	kind := strings.TrimSpace(req.Kind)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	name := strings.TrimSpace(req.Name)
+	if name != "" {
+		query = query.Where("name LIKE ?", fmt.Sprintf("%%%s%%", name))
+	}
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+	creator := strings.TrimSpace(req.Creator)
+	if creator != "" {
+		query = query.Where("creator LIKE ?", fmt.Sprintf("%%%s%%", creator))
+	}
+	trigger := strings.TrimSpace(req.Trigger)
+	if trigger != "" {
+		query = query.Where("`trigger` = ?", trigger)
+	}
+	address := strings.TrimSpace(req.Address)
+	if req.NodeId > 0 || address != "" {
+		nodeQuery := s.TX.Model(&models.SysCommandJobNode{}).Select("job_id")
+		if req.NodeId > 0 {
+			nodeQuery = nodeQuery.Where("node_id = ?", req.NodeId)
+		}
+		if address != "" {
+			nodeQuery = nodeQuery.Where("address LIKE ?", fmt.Sprintf("%%%s%%", address))
+		}
+		query = query.Where("id IN (?)", nodeQuery)
+	}
+	if req.StartTime != "" {
+		query = query.Where("created_at >= ?", new(models.LocalTime).SetString(req.StartTime).Time)
+	}
+	if req.EndTime != "" {
+		query = query.Where("created_at <= ?", new(models.LocalTime).SetString(req.EndTime).Time)
+	}
+	err := s.Find(query, &req.PageInfo, &list)
+	return list, err
+}
+
+// GetCommandJobById 获取批量命令任务及每台机器的执行结果
+func (s *MysqlService) GetCommandJobById(id uint) (models.SysCommandJob, error) {
+	var job models.SysCommandJob
+	err := s.TX.Preload("Nodes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Where("id = ?", id).First(&job).Error
+	return job, err
+}
+
+// GetCommandJobOutput 以文本形式导出批量命令任务的输出, nodeId大于0时只导出该机器
+func (s *MysqlService) GetCommandJobOutput(id, nodeId uint) ([]byte, error) {
+	job, err := s.GetCommandJobById(id)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, node := range job.Nodes { //nolint:gocritic
+		if nodeId > 0 && node.NodeId != nodeId {
+			continue
+		}
+		exitCode := "-"
+		if node.ExitCode != nil {
+			exitCode = fmt.Sprintf("%d", *node.ExitCode)
+		}
+		fmt.Fprintf(&buf, "==================== %s (node %d) ====================\n", node.Address, node.NodeId)
+		fmt.Fprintf(&buf, "status: %s, exit code: %s, duration: %dms, started at: %s\n",
+			commandJobNodeStatusName(node.Status), exitCode, node.Duration, node.StartedAt.Format(global.SecLocalTimeFormat))
+		fmt.Fprintf(&buf, "---------- stdout ----------\n%s\n", node.Stdout)
+		fmt.Fprintf(&buf, "---------- stderr ----------\n%s\n\n", node.Stderr)
+	}
+	if buf.Len() == 0 {
+		return nil, errors.New("the node is not in the command job")
+	}
+	return buf.Bytes(), nil
+}
+
+func commandJobNodeStatusName(status *uint) string {
+	if status == nil {
+		return "pending"
+	}
+	switch *status {
+	case models.SysCommandJobNodeRunning:
+		return "running"
+	case models.SysCommandJobNodeSuccess:
+		return "success"
+	case models.SysCommandJobNodeFailed:
+		return "failed"
+	case models.SysCommandJobNodeSkipped:
+		return "skipped"
+	default:
+		return "pending"
+	}
+}
+
+// CreateCommandJob 创建在多台机器上执行命令/脚本的任务并在后台执行, 返回的任务可用于查询执行结果
+func (s *MysqlService) CreateCommandJob(req *request.CreateCommandJobRequestStruct, nodeIds []uint) (*models.SysCommandJob, error) {
+	content := strings.TrimSpace(req.Content)
+	if !strings.HasPrefix(content, "#!") {
+		content = "#!/bin/bash\n" + content
+	}
+	fileName := strings.TrimSpace(req.FileName)
+	if fileName == "" {
+		fileName = fmt.Sprintf("metalflow-command-%d.sh", time.Now().UnixNano())
+	} else if !commandJobFileNameReg.MatchString(fileName) {
+		return nil, fmt.Errorf("the file name [%s] may only contain letters, digits, dots, underscores and hyphens", fileName)
+	}
+	dir, err := getCommandJobRemoteDir(req.RemoteDir)
+	if err != nil {
+		return nil, err
+	}
+	job := s.newCommandJob(models.SysCommandJobKindCommand, nil)
+	job.Name = strings.TrimSpace(req.Name)
+	job.Content = content
+	if req.Creator != "" {
+		job.Creator = req.Creator
+	}
+	if err = s.startCommandJob(job, fileName, dir, nodeIds, &req.CommandJobExecRequestStruct); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// newCommandJob 创建由当前用户发起的操作记录, 没有登录用户时为系统发起, params不为空时记录为json
+func (s *MysqlService) newCommandJob(kind string, params any) *models.SysCommandJob {
+	job := &models.SysCommandJob{
+		Kind:    kind,
+		Trigger: models.SysCommandJobTriggerSystem,
+		Creator: s.Operator,
+	}
+	if s.Operator != "" {
+		job.Trigger = models.SysCommandJobTriggerUser
+	}
+	if params != nil {
+		if b, err := json.Marshal(params); err == nil {
+			job.Params = b
+		}
+	}
+	return job
+}
+
+// getCommandJobRemoteDir 获取上传到机器上的目录, 为空时为默认目录
+func getCommandJobRemoteDir(dir string) (string, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return remoteDir, nil
+	}
+	if !path.IsAbs(dir) {
+		return "", fmt.Errorf("the remote dir [%s] must be an absolute path", dir)
+	}
+	return dir, nil
+}
+
+// getExecCredential 获取ssh执行方式的认证信息, 指定了凭据时使用当前用户可使用的凭据, 传了用户名时覆盖凭据的默认用户名
+func (s *MysqlService) getExecCredential(exec *request.CommandJobExecRequestStruct, nodeIds []uint) (*SshCredential, error) {
+	username := strings.TrimSpace(exec.Username)
+	if exec.CredentialId == 0 {
+		return &SshCredential{Username: username, Password: exec.Password}, nil
+	}
+	credential, err := s.GetSshCredential(exec.CredentialId, s.Operator, nodeIds)
+	if err != nil {
+		return nil, err
+	}
+	if username != "" {
+		credential.Username = username
+	}
+	if credential.Username == "" {
+		return nil, errors.New("the ssh username is required")
+	}
+	return credential, nil
+}
+
+// startCommandJob 创建执行job.Content的任务并按执行方式在后台执行
+func (s *MysqlService) startCommandJob(job *models.SysCommandJob, fileName, dir string, nodeIds []uint,
+	exec *request.CommandJobExecRequestStruct) error {
+	executor := strings.TrimSpace(exec.Executor)
+	if executor == "" {
+		executor = models.SysCommandJobExecutorMetaltask
+	}
+	if executor != models.SysCommandJobExecutorMetaltask && executor != models.SysCommandJobExecutorSsh {
+		return fmt.Errorf("the executor [%s] is not supported", executor)
+	}
+	if executor == models.SysCommandJobExecutorSsh && exec.CredentialId == 0 && strings.TrimSpace(exec.Username) == "" {
+		return errors.New("the ssh username is required")
+	}
+	if err := exec.Strategy.Validate(); err != nil {
+		return err
+	}
+	nodes := make([]*models.SysNode, 0)
+	if err := s.TX.Where("id IN (?)", nodeIds).Order("id").Find(&nodes).Error; err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return errors.New("no nodes are selected")
+	}
+	metric := grpc.FileMetric{
+		FilePath:   fileName,
+		RemoteDir:  dir,
+		IsRunnable: true,
+		FileGetter: &cronShellInfo{content: job.Content},
+		Timeout:    time.Duration(exec.Timeout) * time.Second,
+	}
+	var action commandJobAction
+	if executor == models.SysCommandJobExecutorSsh {
+		credential, err := s.getExecCredential(exec, nodeIds)
+		if err != nil {
+			return err
+		}
+		action = sshAction(s.DB, nodes, credential, metric, job.Content)
+	} else {
+		metaltask, err := getWorkerByRole(global.Mysql, models.SysWorkerRoleTask)
+		if err != nil {
+			return fmt.Errorf("search worker metaltask from database error:%v", err)
+		}
+		action = uploadAction(metric, metaltask.Port)
+	}
+	job.Executor = executor
+	job.Strategy = exec.Strategy.JSON()
+	// 请求可能处于事务中, 任务在后台执行, 使用无事务的连接记录
+	if err := createCommandJob(s.DB, job, metric, nodes); err != nil {
+		return err
+	}
+	// 后台执行时使用副本, 避免与返回的任务同时读写
+	running := *job
+	running.Nodes = append([]models.SysCommandJobNode(nil), job.Nodes...)
+	go runCommandJob(s.DB, &running, action)
+	return nil
+}
+
+// RunCommandJob 在多台机器上上传并执行文件, 等待执行结束, 每台机器的输出记录在job中
+// 只有任务本身无法执行时返回错误, 机器执行失败可通过job.Error()获取
+func (s *MysqlService) RunCommandJob(job *models.SysCommandJob, m grpc.FileMetric, ids []uint) error {
+	nodes := make([]*models.SysNode, 0)
+	err := s.TX.Where("id in (?)", ids).Order("id").Find(&nodes).Error
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	// get metaltask info from database
+	metaltask, err := getWorkerByRole(global.Mysql, models.SysWorkerRoleTask)
+	if err != nil {
+		return fmt.Errorf("search worker metaltask from database error:%v", err)
+	}
+	if job.Trigger == "" {
+		job.Trigger = s.newCommandJob(job.Kind, nil).Trigger
+		job.Creator = s.Operator
+	}
+	job.Executor = models.SysCommandJobExecutorMetaltask
+	if job.Content == "" {
+		job.Content = readCommandJobContent(m)
+	}
+	// 执行记录不随请求事务回滚
+	if err = createCommandJob(s.DB, job, m, nodes); err != nil {
+		return err
+	}
+	runCommandJob(s.DB, job, uploadAction(m, metaltask.Port))
+	return nil
+}
+
+// runNodeAction 在单台机器上执行操作并记录为kind类型的操作记录, 返回操作本身的输出和错误
+func (s *MysqlService) runNodeAction(kind string, params any, node *models.SysNode, action commandJobAction) (string, error) {
+	job := s.newCommandJob(kind, params)
+	// 执行记录不随请求事务回滚
+	if err := createCommandJob(s.DB, job, grpc.FileMetric{}, []*models.SysNode{node}); err != nil {
+		return "", err
+	}
+	var (
+		output string
+		err    error
+	)
+	runCommandJob(s.DB, job, func(address string, onLine func(stream, line string)) (string, error) {
+		output, err = action(address, onLine)
+		return output, err
+	})
+	return output, err
+}
+
+// readCommandJobContent 读取需要执行的脚本内容用于记录, 不执行、过大或不是文本时返回空
+func readCommandJobContent(m grpc.FileMetric) string {
+	if !m.IsRunnable || m.FileGetter == nil {
+		return ""
+	}
+	file, err := m.FileGetter.GetFile()
+	if err != nil {
+		return ""
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	content, err := io.ReadAll(io.LimitReader(file, commandJobMaxContentSize+1))
+	if err != nil || len(content) > commandJobMaxContentSize || !utf8.Valid(content) {
+		return ""
+	}
+	return string(content)
+}
+
+// createCommandJob 创建批量命令任务及待执行的机器
+func createCommandJob(tx *gorm.DB, job *models.SysCommandJob, m grpc.FileMetric, nodes []*models.SysNode) error {
+	status := models.SysCommandJobRunning
+	job.FilePath = m.FilePath
+	job.RemoteDir = m.RemoteDir
+	job.IsRunnable = m.IsRunnable
+	job.Status = &status
+	job.NodeCount = uint(len(nodes))
+	job.Nodes = make([]models.SysCommandJobNode, 0, len(nodes))
+	for _, node := range nodes {
+		nodeStatus := models.SysCommandJobNodePending
+		job.Nodes = append(job.Nodes, models.SysCommandJobNode{
+			NodeId:  node.Id,
+			Address: node.Address,
+			Status:  &nodeStatus,
+		})
+	}
+	if err := tx.Create(job).Error; err != nil {
+		return err
+	}
+	openCommandJobStream(job.Id)
+	return nil
+}
+
+// runCommandJob 按任务的执行策略分批并发执行操作, 记录每台机器的结果后更新任务状态
+// 失败过多中止时, 剩余的机器记为未执行
+func runCommandJob(tx *gorm.DB, job *models.SysCommandJob, action commandJobAction) {
+	strategy := models.ParseExecStrategy(job.Strategy)
+	total := len(job.Nodes)
+	var (
+		wg     = sync.WaitGroup{}
+		lock   sync.Mutex
+		failed int
+		// 限制grpc连接的并发数量
+		tokens = make(chan struct{}, strategy.GetParallelism())
+	)
+	stopped := func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return strategy.ShouldStop(failed, total)
+	}
+	batchSize := strategy.GetBatchSize(total)
+	for start := 0; start < total && !stopped(); start += batchSize {
+		if start > 0 && strategy.PauseSeconds > 0 {
+			time.Sleep(time.Duration(strategy.PauseSeconds) * time.Second)
+		}
+		end := start + batchSize
+		if end > total {
+			end = total
+		}
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			go func(node *models.SysCommandJobNode) {
+				defer wg.Done()
+				tokens <- struct{}{}
+				defer func() {
+					<-tokens
+				}()
+				// 等待期间其他机器失败过多时不再执行
+				if stopped() {
+					return
+				}
+				runCommandJobNode(tx, node, action)
+				if *node.Status == models.SysCommandJobNodeFailed {
+					lock.Lock()
+					failed++
+					lock.Unlock()
+				}
+			}(&job.Nodes[i])
+		}
+		wg.Wait()
+	}
+	skipCommandJobNodes(tx, job)
+
+	status := models.SysCommandJobCompleted
+	job.SuccessCount, job.FailedCount, job.SkippedCount = 0, 0, 0
+	for _, node := range job.Nodes { //nolint:gocritic
+		switch *node.Status {
+		case models.SysCommandJobNodeSuccess:
+			job.SuccessCount++
+		case models.SysCommandJobNodeSkipped:
+			job.SkippedCount++
+		default:
+			job.FailedCount++
+		}
+	}
+	if job.SkippedCount > 0 {
+		status = models.SysCommandJobAborted
+	} else if job.FailedCount > 0 {
+		status = models.SysCommandJobFailed
+	}
+	job.Status = &status
+	job.FinishedAt = models.LocalTime{Time: time.Now()}
+	// 只更新仍在执行中的任务, 避免覆盖执行期间被记为其他状态的任务
+	err := tx.Model(&models.SysCommandJob{}).Where("id = ? AND status = ?", job.Id, models.SysCommandJobRunning).Updates(map[string]any{
+		"status":        status,
+		"success_count": job.SuccessCount,
+		"failed_count":  job.FailedCount,
+		"skipped_count": job.SkippedCount,
+		"finished_at":   job.FinishedAt.Time,
+	}).Error
+	if err != nil {
+		global.Log.Errorf("record command job [%d] result failed: %v", job.Id, err)
+	}
+	closeCommandJobStream(job)
+}
+
+// skipCommandJobNodes 将中止后未执行的机器记为未执行
+func skipCommandJobNodes(tx *gorm.DB, job *models.SysCommandJob) {
+	skipped := false
+	for i := range job.Nodes {
+		node := &job.Nodes[i]
+		if *node.Status != models.SysCommandJobNodePending {
+			continue
+		}
+		status := models.SysCommandJobNodeSkipped
+		node.Status = &status
+		skipped = true
+		publishCommandJobEvent(CommandJobEvent{JobId: job.Id, NodeId: node.NodeId, Address: node.Address, Type: CommandJobEventSkipped})
+	}
+	if !skipped {
+		return
+	}
+	err := tx.Model(&models.SysCommandJobNode{}).
+		Where("job_id = ? AND status = ?", job.Id, models.SysCommandJobNodePending).
+		Update("status", models.SysCommandJobNodeSkipped).Error
+	if err != nil {
+		global.Log.Errorf("record command job [%d] skipped nodes failed: %v", job.Id, err)
+	}
+}
+
+// 服务重启时执行中的机器的错误输出
+const commandJobInterruptedStderr = "服务重启, 执行中断"
+
+// InterruptCommandJobs 服务启动时将重启前未执行完的任务记为已中断, 使其事件流能够结束
+func InterruptCommandJobs() {
+	ids := make([]uint, 0)
+	err := global.Mysql.Model(&models.SysCommandJob{}).Where("status = ?", models.SysCommandJobRunning).Pluck("id", &ids).Error
+	if err != nil {
+		global.Log.Error("[批量命令]查询未完成的任务失败：", err)
+		return
+	}
+	for _, id := range ids {
+		global.Log.Infof("[批量命令]任务%d因服务重启中断", id)
+		if err = interruptCommandJob(global.Mysql, id); err != nil {
+			global.Log.Errorf("[批量命令]记录任务%d中断失败：%v", id, err)
+		}
+	}
+}
+
+// interruptCommandJob 执行中的机器结果未知记为失败, 未执行的机器记为未执行, 并统计结果
+func interruptCommandJob(tx *gorm.DB, id uint) error {
+	now := time.Now()
+	return tx.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.SysCommandJobNode{}).
+			Where("job_id = ? AND status = ?", id, models.SysCommandJobNodeRunning).
+			Updates(map[string]any{
+				"status":      models.SysCommandJobNodeFailed,
+				"stderr":      commandJobInterruptedStderr,
+				"finished_at": now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.SysCommandJobNode{}).
+			Where("job_id = ? AND status = ?", id, models.SysCommandJobNodePending).
+			Update("status", models.SysCommandJobNodeSkipped).Error
+		if err != nil {
+			return err
+		}
+		statuses := make([]uint, 0)
+		err = tx.Model(&models.SysCommandJobNode{}).Where("job_id = ?", id).Pluck("status", &statuses).Error
+		if err != nil {
+			return err
+		}
+		var success, failed, skipped uint
+		for _, status := range statuses {
+			switch status {
+			case models.SysCommandJobNodeSuccess:
+				success++
+			case models.SysCommandJobNodeSkipped:
+				skipped++
+			default:
+				failed++
+			}
+		}
+		return tx.Model(&models.SysCommandJob{}).Where("id = ? AND status = ?", id, models.SysCommandJobRunning).Updates(map[string]any{
+			"status":        models.SysCommandJobInterrupted,
+			"success_count": success,
+			"failed_count":  failed,
+			"skipped_count": skipped,
+			"finished_at":   now,
+		}).Error
+	})
+}
+
+// runCommandJobNode 在单台机器上执行操作, 记录输出、退出码与耗时
+func runCommandJobNode(tx *gorm.DB, node *models.SysCommandJobNode, action commandJobAction) {
+	start := time.Now()
+	err := tx.Model(&models.SysCommandJobNode{}).Where("id = ?", node.Id).Updates(map[string]any{
+		"status":     models.SysCommandJobNodeRunning,
+		"started_at": start,
+	}).Error
+	if err != nil {
+		global.Log.Errorf("record command job node [%s] status failed: %v", node.Address, err)
+	}
+
+	event := CommandJobEvent{JobId: node.JobId, NodeId: node.NodeId, Address: node.Address}
+	started := event
+	started.Type = CommandJobEventStarted
+	started.Time = models.LocalTime{Time: start}
+	publishCommandJobEvent(started)
+
+	var (
+		lock           sync.Mutex
+		streamed       bool
+		stdout, stderr strings.Builder
+	)
+	output, err := action(node.Address, func(stream, line string) {
+		lock.Lock()
+		streamed = true
+		buf := &stdout
+		if stream == "stderr" {
+			buf = &stderr
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		lock.Unlock()
+		e := event
+		e.Type = CommandJobEventOutput
+		e.Stream = stream
+		e.Line = line
+		publishCommandJobEvent(e)
+	})
+	status := models.SysCommandJobNodeSuccess
+	node.Stdout = output
+	node.Stderr = ""
+	node.ExitCode = nil
+	if streamed {
+		node.Stdout = stdout.String()
+		node.Stderr = stderr.String()
+	}
+	var (
+		execErr *grpc.ExecError
+		exitErr *ssh.ExitError
+	)
+	switch {
+	case err == nil:
+		exitCode := 0
+		node.ExitCode = &exitCode
+	case errors.As(err, &execErr):
+		// metaltask不返回实际的退出码, 脚本执行报错统一记为1
+		status = models.SysCommandJobNodeFailed
+		exitCode := 1
+		node.ExitCode = &exitCode
+		node.Stderr = execErr.Output
+	case errors.As(err, &exitErr):
+		status = models.SysCommandJobNodeFailed
+		exitCode := exitErr.ExitStatus()
+		node.ExitCode = &exitCode
+		if node.Stderr == "" {
+			node.Stderr = err.Error()
+		}
+	default:
+		status = models.SysCommandJobNodeFailed
+		node.Stderr += err.Error()
+	}
+	if !streamed {
+		publishCommandJobOutput(node, "stdout", node.Stdout)
+		publishCommandJobOutput(node, "stderr", node.Stderr)
+	}
+	finish := time.Now()
+	node.Status = &status
+	node.StartedAt = models.LocalTime{Time: start}
+	node.FinishedAt = models.LocalTime{Time: finish}
+	node.Duration = finish.Sub(start).Milliseconds()
+	err = tx.Model(&models.SysCommandJobNode{}).Where("id = ?", node.Id).Updates(map[string]any{
+		"status":      status,
+		"stdout":      node.Stdout,
+		"stderr":      node.Stderr,
+		"exit_code":   node.ExitCode,
+		"duration":    node.Duration,
+		"finished_at": finish,
+	}).Error
+	if err != nil {
+		global.Log.Errorf("record command job node [%s] result failed: %v", node.Address, err)
+	}
+	finished := event
+	finished.Type = CommandJobEventFinished
+	if status == models.SysCommandJobNodeFailed {
+		finished.Type = CommandJobEventFailed
+	}
+	finished.ExitCode = node.ExitCode
+	finished.Time = node.FinishedAt
+	publishCommandJobEvent(finished)
+}
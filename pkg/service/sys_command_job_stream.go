@@ -0,0 +1,207 @@
+package service
+
+import (
+	"metalflow/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 命令任务的实时事件类型
+const (
+	CommandJobEventStarted  = "started"  // 机器开始执行
+	CommandJobEventOutput   = "output"   // 机器输出一行
+	CommandJobEventFinished = "finished" // 机器执行成功
+	CommandJobEventFailed   = "failed"   // 机器执行失败
+	CommandJobEventSkipped  = "skipped"  // 任务中止, 机器未执行
+	CommandJobEventDone     = "done"     // 任务结束
+)
+
+const (
+	// 每个任务保留用于回放的事件数, 超过后不再保留输出事件(仍会实时推送)
+	commandJobStreamMaxEvents = 10000
+	// 每个订阅者的缓冲事件数, 订阅者处理过慢时断开, 重新订阅时回放
+	commandJobStreamBuffer = 1024
+)
+
+// CommandJobEvent 命令任务的实时事件, 每台机器为一个通道(以nodeId区分)
+type CommandJobEvent struct {
+	JobId    uint             `json:"jobId"`
+	NodeId   uint             `json:"nodeId,omitempty"`
+	Address  string           `json:"address,omitempty"`
+	Type     string           `json:"type"`
+	Stream   string           `json:"stream,omitempty"`   // 输出事件的来源(stdout/stderr)
+	Line     string           `json:"line,omitempty"`     // 输出的一行
+	ExitCode *int             `json:"exitCode,omitempty"` // 机器执行结束时的退出码
+	Status   *uint            `json:"status,omitempty"`   // 任务结束时的状态
+	Time     models.LocalTime `json:"time"`
+}
+
+// commandJobStream 执行中任务的事件及订阅者
+type commandJobStream struct {
+	lock   sync.Mutex
+	events []CommandJobEvent
+	subs   map[chan CommandJobEvent]struct{}
+}
+
+var commandJobStreams = struct {
+	lock sync.RWMutex
+	data map[uint]*commandJobStream
+}{
+	data: make(map[uint]*commandJobStream),
+}
+
+// openCommandJobStream 任务开始执行前创建事件流
+func openCommandJobStream(jobId uint) {
+	commandJobStreams.lock.Lock()
+	commandJobStreams.data[jobId] = &commandJobStream{
+		subs: make(map[chan CommandJobEvent]struct{}),
+	}
+	commandJobStreams.lock.Unlock()
+}
+
+// closeCommandJobStream 任务结束后推送结束事件并关闭事件流, 之后的订阅从执行记录回放
+func closeCommandJobStream(job *models.SysCommandJob) {
+	publishCommandJobEvent(CommandJobEvent{JobId: job.Id, Type: CommandJobEventDone, Status: job.Status})
+	commandJobStreams.lock.Lock()
+	stream, ok := commandJobStreams.data[job.Id]
+	delete(commandJobStreams.data, job.Id)
+	commandJobStreams.lock.Unlock()
+	if !ok {
+		return
+	}
+	stream.lock.Lock()
+	for ch := range stream.subs {
+		close(ch)
+	}
+	stream.subs = nil
+	stream.lock.Unlock()
+}
+
+// publishCommandJobEvent 推送事件, 不阻塞任务执行
+func publishCommandJobEvent(event CommandJobEvent) {
+	if event.Time.IsZero() {
+		event.Time = models.LocalTime{Time: time.Now()}
+	}
+	commandJobStreams.lock.RLock()
+	stream, ok := commandJobStreams.data[event.JobId]
+	commandJobStreams.lock.RUnlock()
+	if !ok {
+		return
+	}
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	if event.Type != CommandJobEventOutput || len(stream.events) < commandJobStreamMaxEvents {
+		stream.events = append(stream.events, event)
+	}
+	for ch := range stream.subs {
+		select {
+		case ch <- event:
+		default:
+			delete(stream.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// publishCommandJobOutput 将一次返回的输出按行推送
+func publishCommandJobOutput(node *models.SysCommandJobNode, stream, output string) {
+	output = strings.TrimRight(output, "\n")
+	if output == "" {
+		return
+	}
+	for _, line := range strings.Split(output, "\n") {
+		publishCommandJobEvent(CommandJobEvent{
+			JobId:   node.JobId,
+			NodeId:  node.NodeId,
+			Address: node.Address,
+			Type:    CommandJobEventOutput,
+			Stream:  stream,
+			Line:    line,
+		})
+	}
+}
+
+// WatchCommandJob 订阅任务的实时事件, 先返回已产生的事件
+// 任务不在执行中时根据执行记录生成事件, 返回的events为nil; 订阅结束后需调用cancel
+func (s *MysqlService) WatchCommandJob(id uint) (history []CommandJobEvent, events <-chan CommandJobEvent, cancel func(), err error) {
+	commandJobStreams.lock.RLock()
+	stream, ok := commandJobStreams.data[id]
+	commandJobStreams.lock.RUnlock()
+	if ok {
+		stream.lock.Lock()
+		// 事件流已关闭时从执行记录回放
+		if stream.subs != nil {
+			ch := make(chan CommandJobEvent, commandJobStreamBuffer)
+			stream.subs[ch] = struct{}{}
+			history = append(history, stream.events...)
+			stream.lock.Unlock()
+			cancel = func() {
+				stream.lock.Lock()
+				if _, ok := stream.subs[ch]; ok {
+					delete(stream.subs, ch)
+					close(ch)
+				}
+				stream.lock.Unlock()
+			}
+			return history, ch, cancel, nil
+		}
+		stream.lock.Unlock()
+	}
+	job, err := s.GetCommandJobById(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return commandJobHistory(&job), nil, func() {}, nil
+}
+
+// commandJobHistory 根据执行记录生成事件
+func commandJobHistory(job *models.SysCommandJob) []CommandJobEvent {
+	history := make([]CommandJobEvent, 0)
+	for i := range job.Nodes {
+		node := &job.Nodes[i]
+		if node.Status == nil || *node.Status == models.SysCommandJobNodePending {
+			continue
+		}
+		event := CommandJobEvent{JobId: job.Id, NodeId: node.NodeId, Address: node.Address}
+		if *node.Status == models.SysCommandJobNodeSkipped {
+			event.Type = CommandJobEventSkipped
+			event.Time = job.FinishedAt
+			history = append(history, event)
+			continue
+		}
+		started := event
+		started.Type = CommandJobEventStarted
+		started.Time = node.StartedAt
+		history = append(history, started)
+		if *node.Status == models.SysCommandJobNodeRunning {
+			continue
+		}
+		for _, output := range []struct{ stream, content string }{{"stdout", node.Stdout}, {"stderr", node.Stderr}} {
+			content := strings.TrimRight(output.content, "\n")
+			if content == "" {
+				continue
+			}
+			for _, line := range strings.Split(content, "\n") {
+				e := event
+				e.Type = CommandJobEventOutput
+				e.Stream = output.stream
+				e.Line = line
+				e.Time = node.FinishedAt
+				history = append(history, e)
+			}
+		}
+		finished := event
+		finished.Type = CommandJobEventFinished
+		if *node.Status == models.SysCommandJobNodeFailed {
+			finished.Type = CommandJobEventFailed
+		}
+		finished.ExitCode = node.ExitCode
+		finished.Time = node.FinishedAt
+		history = append(history, finished)
+	}
+	if job.Status != nil && *job.Status != models.SysCommandJobRunning {
+		history = append(history, CommandJobEvent{JobId: job.Id, Type: CommandJobEventDone, Status: job.Status, Time: job.FinishedAt})
+	}
+	return history
+}
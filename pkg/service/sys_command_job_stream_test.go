@@ -0,0 +1,69 @@
+package service
+
+import (
+	"metalflow/models"
+	"testing"
+)
+
+func TestMysqlService_WatchCommandJob(t *testing.T) {
+	s := New(nil)
+	job := &models.SysCommandJob{Model: models.Model{Id: 1}}
+	node := &models.SysCommandJobNode{JobId: 1, NodeId: 2, Address: "127.0.0.1"}
+	openCommandJobStream(job.Id)
+	publishCommandJobEvent(CommandJobEvent{JobId: 1, NodeId: 2, Address: "127.0.0.1", Type: CommandJobEventStarted})
+
+	history, events, cancel, err := s.WatchCommandJob(job.Id)
+	if err != nil {
+		t.Fatalf("WatchCommandJob() error = %v", err)
+	}
+	defer cancel()
+	if len(history) != 1 || history[0].Type != CommandJobEventStarted {
+		t.Errorf("WatchCommandJob() history = %+v, want the started event", history)
+	}
+
+	publishCommandJobOutput(node, "stdout", "line1\nline2\n")
+	status := models.SysCommandJobCompleted
+	job.Status = &status
+	closeCommandJobStream(job)
+
+	got := make([]CommandJobEvent, 0)
+	for event := range events {
+		got = append(got, event)
+	}
+	if len(got) != 3 || got[0].Line != "line1" || got[1].Line != "line2" || got[2].Type != CommandJobEventDone {
+		t.Errorf("WatchCommandJob() events = %+v, want 2 output lines and done", got)
+	}
+	if got[0].Time.IsZero() || got[0].NodeId != 2 {
+		t.Errorf("WatchCommandJob() event = %+v, want time and node", got[0])
+	}
+}
+
+func TestCommandJobHistory(t *testing.T) {
+	success, failed, running := models.SysCommandJobNodeSuccess, models.SysCommandJobNodeFailed, models.SysCommandJobRunning
+	exitCode := 1
+	job := &models.SysCommandJob{
+		Model:  models.Model{Id: 1},
+		Status: &running,
+		Nodes: []models.SysCommandJobNode{
+			{NodeId: 1, Address: "10.0.0.1", Status: &success, Stdout: "ok\n"},
+			{NodeId: 2, Address: "10.0.0.2", Status: &failed, Stderr: "e1\ne2", ExitCode: &exitCode},
+			{NodeId: 3, Address: "10.0.0.3"},
+		},
+	}
+	want := []string{
+		CommandJobEventStarted, CommandJobEventOutput, CommandJobEventFinished,
+		CommandJobEventStarted, CommandJobEventOutput, CommandJobEventOutput, CommandJobEventFailed,
+	}
+	history := commandJobHistory(job)
+	if len(history) != len(want) {
+		t.Fatalf("commandJobHistory() = %+v, want %d events", history, len(want))
+	}
+	for i, event := range history { //nolint:gocritic
+		if event.Type != want[i] {
+			t.Errorf("commandJobHistory()[%d] = %s, want %s", i, event.Type, want[i])
+		}
+	}
+	if history[5].Stream != "stderr" || history[5].Line != "e2" || *history[6].ExitCode != 1 {
+		t.Errorf("commandJobHistory() failed node events = %+v", history[4:])
+	}
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"errors"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/grpc"
+	"metalflow/pkg/request"
+	tests2 "metalflow/tests"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMysqlService_RunCommandJob(t *testing.T) {
+	mock := tests2.GetMock()
+	tests2.SetLog()
+	s := New(nil)
+	metric := grpc.FileMetric{
+		FilePath:   "reboot.sh",
+		RemoteDir:  remoteDir,
+		IsRunnable: true,
+		FileGetter: &cronShellInfo{content: "#!/bin/bash\nreboot"},
+	}
+	tests := []struct {
+		name      string
+		invoke    func()
+		wantErr   bool
+		wantNodes int
+	}{
+		{
+			name: "no nodes",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr:   false,
+			wantNodes: 0,
+		},
+		{
+			name: "no metaltask",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "address"}).AddRow(1, "127.0.0.1"))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_worker`").WithArgs(models.SysWorkerRoleTask).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr:   true,
+			wantNodes: 0,
+		},
+		{
+			name: "node failed",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "address"}).AddRow(1, "127.0.0.1"))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_worker`").WithArgs(models.SysWorkerRoleTask).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "port"}).AddRow(1, 1))
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `tb_sys_command_job`").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO `tb_sys_command_job_node`").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `tb_sys_command_job_node` SET").
+					WithArgs(sqlmock.AnyArg(), models.SysCommandJobNodeRunning, sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `tb_sys_command_job_node` SET").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `tb_sys_command_job` SET").
+					WithArgs(uint(1), sqlmock.AnyArg(), uint(0), models.SysCommandJobFailed, uint(0), sqlmock.AnyArg(), 1, models.SysCommandJobRunning).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr:   false,
+			wantNodes: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke()
+			job := &models.SysCommandJob{Kind: models.SysCommandJobKindReboot}
+			err := s.RunCommandJob(job, metric, []uint{1})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RunCommandJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(job.Nodes) != tt.wantNodes {
+				t.Errorf("RunCommandJob() nodes = %d, want %d", len(job.Nodes), tt.wantNodes)
+			}
+			if tt.wantNodes > 0 {
+				node := job.Nodes[0]
+				if *node.Status != models.SysCommandJobNodeFailed || node.ExitCode != nil || node.Stderr == "" {
+					t.Errorf("RunCommandJob() node = %+v, want failed without exit code", node)
+				}
+				if job.Trigger != models.SysCommandJobTriggerSystem {
+					t.Errorf("RunCommandJob() trigger = %s, want %s", job.Trigger, models.SysCommandJobTriggerSystem)
+				}
+				if job.Content != "#!/bin/bash\nreboot" {
+					t.Errorf("RunCommandJob() content = %q", job.Content)
+				}
+				if e := job.Error(); e == nil || !strings.Contains(e.Error(), "[127.0.0.1]") {
+					t.Errorf("SysCommandJob.Error() = %v", e)
+				}
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("RunCommandJob() %v", err)
+			}
+		})
+	}
+}
+
+func TestMysqlService_CreateCommandJob(t *testing.T) {
+	tests2.GetMock()
+	s := New(nil)
+	tests := []struct {
+		name string
+		req  request.CreateCommandJobRequestStruct
+	}{
+		{
+			name: "invalid file name",
+			req:  request.CreateCommandJobRequestStruct{Content: "uptime", FileName: "../uptime.sh"},
+		},
+		{
+			name: "relative remote dir",
+			req:  request.CreateCommandJobRequestStruct{Content: "uptime", RemoteDir: "tmp"},
+		},
+		{
+			name: "unsupported executor",
+			req: request.CreateCommandJobRequestStruct{Content: "uptime",
+				CommandJobExecRequestStruct: request.CommandJobExecRequestStruct{Executor: "telnet"}},
+		},
+		{
+			name: "ssh without username",
+			req: request.CreateCommandJobRequestStruct{Content: "uptime",
+				CommandJobExecRequestStruct: request.CommandJobExecRequestStruct{Executor: models.SysCommandJobExecutorSsh}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.CreateCommandJob(&tt.req, []uint{1}); err == nil {
+				t.Errorf("CreateCommandJob() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestMysqlService_newCommandJob(t *testing.T) {
+	tests := []struct {
+		name        string
+		operator    string
+		params      any
+		wantTrigger string
+		wantParams  string
+	}{
+		{
+			name:        "system",
+			wantTrigger: models.SysCommandJobTriggerSystem,
+		},
+		{
+			name:        "user with params",
+			operator:    "admin",
+			params:      map[string]any{"scene": "web"},
+			wantTrigger: models.SysCommandJobTriggerUser,
+			wantParams:  `{"scene":"web"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := MysqlService{Operator: tt.operator}
+			job := s.newCommandJob(models.SysCommandJobKindTuneScene, tt.params)
+			if job.Trigger != tt.wantTrigger || job.Creator != tt.operator || string(job.Params) != tt.wantParams {
+				t.Errorf("newCommandJob() = %+v, want trigger %s, creator %s, params %s",
+					job, tt.wantTrigger, tt.operator, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestExecStrategy_GetBatchSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy models.ExecStrategy
+		total    int
+		want     int
+	}{
+		{name: "no batch", strategy: models.ExecStrategy{}, total: 200, want: 200},
+		{name: "batch size", strategy: models.ExecStrategy{BatchSize: 20}, total: 200, want: 20},
+		{name: "batch size larger than total", strategy: models.ExecStrategy{BatchSize: 20}, total: 5, want: 5},
+		{name: "batch percent", strategy: models.ExecStrategy{BatchPercent: 10}, total: 200, want: 20},
+		{name: "batch percent at least one", strategy: models.ExecStrategy{BatchPercent: 10}, total: 5, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.strategy.GetBatchSize(tt.total); got != tt.want {
+				t.Errorf("GetBatchSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunCommandJob_StopOnFailure(t *testing.T) {
+	mock := tests2.GetMock()
+	tests2.SetLog()
+	pending := models.SysCommandJobNodePending
+	job := &models.SysCommandJob{Model: models.Model{Id: 1}}
+	job.Strategy = models.ExecStrategy{Parallelism: 1, BatchSize: 1, StopOnFailure: true}.JSON()
+	for i := 1; i <= 3; i++ {
+		status := pending
+		job.Nodes = append(job.Nodes, models.SysCommandJobNode{Model: models.Model{Id: uint(i)}, JobId: 1, Status: &status})
+	}
+	for _, sql := range []string{"UPDATE `tb_sys_command_job_node` SET", "UPDATE `tb_sys_command_job_node` SET"} {
+		mock.ExpectBegin()
+		mock.ExpectExec(sql).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `tb_sys_command_job_node` SET").
+		WithArgs(models.SysCommandJobNodeSkipped, sqlmock.AnyArg(), 1, models.SysCommandJobNodePending).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `tb_sys_command_job` SET").
+		WithArgs(uint(1), sqlmock.AnyArg(), uint(2), models.SysCommandJobAborted, uint(0), sqlmock.AnyArg(), 1, models.SysCommandJobRunning).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	runs := 0
+	runCommandJob(global.Mysql, job, func(address string, _ func(stream, line string)) (string, error) {
+		runs++
+		return "", errors.New("connect failed")
+	})
+	if runs != 1 || job.FailedCount != 1 || job.SkippedCount != 2 || *job.Status != models.SysCommandJobAborted {
+		t.Errorf("runCommandJob() runs = %d, job = %+v, want 1 run and 2 skipped nodes", runs, job)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("runCommandJob() %v", err)
+	}
+}
+
+func TestInterruptCommandJob(t *testing.T) {
+	mock := tests2.GetMock()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `tb_sys_command_job_node` SET").
+		WithArgs(sqlmock.AnyArg(), models.SysCommandJobNodeFailed, commandJobInterruptedStderr, sqlmock.AnyArg(),
+			1, models.SysCommandJobNodeRunning).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE `tb_sys_command_job_node` SET").
+		WithArgs(models.SysCommandJobNodeSkipped, sqlmock.AnyArg(), 1, models.SysCommandJobNodePending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT `status` FROM `tb_sys_command_job_node`").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).
+			AddRow(models.SysCommandJobNodeSuccess).
+			AddRow(models.SysCommandJobNodeFailed).
+			AddRow(models.SysCommandJobNodeSkipped))
+	// 只更新仍在执行中的任务
+	mock.ExpectExec("UPDATE `tb_sys_command_job` SET").
+		WithArgs(uint(1), sqlmock.AnyArg(), uint(1), models.SysCommandJobInterrupted, uint(1), sqlmock.AnyArg(), 1, models.SysCommandJobRunning).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := interruptCommandJob(global.Mysql, 1); err != nil {
+		t.Errorf("interruptCommandJob() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("interruptCommandJob() %v", err)
+	}
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"metalflow/pkg/utils"
+	"strings"
+
+	"github.com/thoas/go-funk"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+// SshCredential 解密后的ssh认证信息
+type SshCredential struct {
+	Username   string
+	Password   string
+	PrivateKey string
+	Passphrase string
+}
+
+// Config 生成连接机器的ssh配置
+func (c *SshCredential) Config(address string, port int, options ...utils.Option) *utils.SshConfig {
+	options = append([]utils.Option{utils.PrivateKey(c.PrivateKey, c.Passphrase)}, options...)
+	return utils.NewSshConfig(address, port, c.Username, c.Password, options...)
+}
+
+// GetCredentials 获取ssh凭据列表, 不返回密码及私钥
+func (s *MysqlService) GetCredentials(req *request.CredentialListRequestStruct) ([]models.SysCredential, error) {
+	list := make([]models.SysCredential, 0)
+	query := s.TX.Model(&models.SysCredential{}).Order("id")
+	name := strings.TrimSpace(req.Name)
+	if name != "" {
+		query = query.Where("name LIKE ?", fmt.Sprintf("%%%s%%", name))
+	}
+	credentialType := strings.TrimSpace(req.Type)
+	if credentialType != "" {
+		query = query.Where("type = ?", credentialType)
+	}
+	err := s.Find(query, &req.PageInfo, &list)
+	return list, err
+}
+
+// CreateCredential 创建ssh凭据, 密码、私钥及私钥密码加密保存
+func (s *MysqlService) CreateCredential(req *request.CreateCredentialRequestStruct) (*models.SysCredential, error) {
+	credential := &models.SysCredential{
+		Name:      strings.TrimSpace(req.Name),
+		Username:  strings.TrimSpace(req.Username),
+		Usernames: joinCredentialUsernames(req.Usernames),
+		RoleIds:   joinCredentialRoleIds(req.RoleIds),
+		Selector:  strings.TrimSpace(req.Selector),
+		Remark:    req.Remark,
+		Creator:   req.Creator,
+	}
+	if err := s.checkCredential(0, credential); err != nil {
+		return nil, err
+	}
+	if err := setCredentialSecrets(credential, req.Password, req.PrivateKey, req.Passphrase); err != nil {
+		return nil, err
+	}
+	return credential, s.TX.Create(credential).Error
+}
+
+// UpdateCredentialById 更新ssh凭据, 修改密码、私钥或私钥密码时重新加密
+func (s *MysqlService) UpdateCredentialById(id uint, req *request.UpdateCredentialRequestStruct) error {
+	var credential models.SysCredential
+	if err := s.TX.Where("id = ?", id).First(&credential).Error; err != nil {
+		return err
+	}
+	if req.Name != nil {
+		credential.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Username != nil {
+		credential.Username = strings.TrimSpace(*req.Username)
+	}
+	if req.Usernames != nil {
+		credential.Usernames = joinCredentialUsernames(*req.Usernames)
+	}
+	if req.RoleIds != nil {
+		credential.RoleIds = joinCredentialRoleIds(*req.RoleIds)
+	}
+	if req.Selector != nil {
+		credential.Selector = strings.TrimSpace(*req.Selector)
+	}
+	if req.Remark != nil {
+		credential.Remark = *req.Remark
+	}
+	if err := s.checkCredential(id, &credential); err != nil {
+		return err
+	}
+	if req.Password != nil || req.PrivateKey != nil || req.Passphrase != nil {
+		secret, err := decryptCredential(&credential)
+		if err != nil {
+			return err
+		}
+		if req.Password != nil {
+			secret.Password = *req.Password
+		}
+		if req.PrivateKey != nil {
+			secret.PrivateKey = *req.PrivateKey
+		}
+		if req.Passphrase != nil {
+			secret.Passphrase = *req.Passphrase
+		}
+		if err = setCredentialSecrets(&credential, secret.Password, secret.PrivateKey, secret.Passphrase); err != nil {
+			return err
+		}
+	}
+	return s.TX.Model(&models.SysCredential{}).Where("id = ?", id).Updates(map[string]any{
+		"name":        credential.Name,
+		"type":        credential.Type,
+		"username":    credential.Username,
+		"password":    credential.Password,
+		"private_key": credential.PrivateKey,
+		"passphrase":  credential.Passphrase,
+		"fingerprint": credential.Fingerprint,
+		"usernames":   credential.Usernames,
+		"role_ids":    credential.RoleIds,
+		"selector":    credential.Selector,
+		"remark":      credential.Remark,
+	}).Error
+}
+
+// checkCredential 凭据名称不能为空且不能重复, 标签选择器需合法
+func (s *MysqlService) checkCredential(id uint, credential *models.SysCredential) error {
+	if credential.Name == "" {
+		return errors.New("the credential name is empty")
+	}
+	err := s.TX.Where("name = ? AND id != ?", credential.Name, id).First(&models.SysCredential{}).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("the credential [%s] already exists", credential.Name)
+	}
+	if credential.Selector != "" {
+		if _, err = utils.ParseSelector(credential.Selector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setCredentialSecrets 校验私钥并加密保存密码、私钥及私钥密码
+func setCredentialSecrets(credential *models.SysCredential, password, privateKey, passphrase string) error {
+	privateKey = strings.TrimSpace(privateKey)
+	if password == "" && privateKey == "" {
+		return errors.New("the password or private key is required")
+	}
+	credential.Type = models.SysCredentialPassword
+	credential.Fingerprint = ""
+	if privateKey != "" {
+		signer, err := utils.ParsePrivateKey(privateKey, passphrase)
+		if err != nil {
+			return err
+		}
+		credential.Type = models.SysCredentialKey
+		credential.Fingerprint = ssh.FingerprintSHA256(signer.PublicKey())
+	} else {
+		passphrase = ""
+	}
+	key, err := credentialKey()
+	if err != nil {
+		return err
+	}
+	if credential.Password, err = utils.EncryptString(key, password); err != nil {
+		return err
+	}
+	if credential.PrivateKey, err = utils.EncryptString(key, privateKey); err != nil {
+		return err
+	}
+	credential.Passphrase, err = utils.EncryptString(key, passphrase)
+	return err
+}
+
+// credentialKey 获取ssh凭据加密的主密钥, 未配置或为示例值时拒绝加解密
+func credentialKey() (string, error) {
+	key := global.Conf.System.CredentialKey
+	if key == "" {
+		return "", fmt.Errorf("the credential master key is not configured, please set %s or system.credential-key-file",
+			global.CredentialKeyEnv)
+	}
+	if key == global.CredentialKeyPlaceholder {
+		return "", errors.New("the credential master key must not be the placeholder value")
+	}
+	return key, nil
+}
+
+// decryptCredential 使用主密钥解密凭据
+func decryptCredential(credential *models.SysCredential) (*SshCredential, error) {
+	key, err := credentialKey()
+	if err != nil {
+		return nil, err
+	}
+	secret := &SshCredential{Username: credential.Username}
+	if secret.Password, err = utils.DecryptString(key, credential.Password); err != nil {
+		return nil, err
+	}
+	if secret.PrivateKey, err = utils.DecryptString(key, credential.PrivateKey); err != nil {
+		return nil, err
+	}
+	if secret.Passphrase, err = utils.DecryptString(key, credential.Passphrase); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func joinCredentialUsernames(usernames []string) string {
+	list := make([]string, 0, len(usernames))
+	for _, username := range usernames {
+		username = strings.TrimSpace(username)
+		if username != "" && !funk.ContainsString(list, username) {
+			list = append(list, username)
+		}
+	}
+	return strings.Join(list, ",")
+}
+
+func joinCredentialRoleIds(roleIds []uint) string {
+	list := make([]string, 0, len(roleIds))
+	for _, id := range funk.UniqUInt(roleIds) {
+		if id > 0 {
+			list = append(list, fmt.Sprintf("%d", id))
+		}
+	}
+	return strings.Join(list, ",")
+}
+
+// canUseCredential 用户是否可使用凭据, 超级管理员可使用所有凭据
+func canUseCredential(credential *models.SysCredential, user *models.SysUser) bool {
+	if credential.Usernames == "" && credential.RoleIds == "" {
+		return true
+	}
+	if user.Role.Keyword == "super" {
+		return true
+	}
+	if credential.Usernames != "" && funk.ContainsString(strings.Split(credential.Usernames, ","), user.Username) {
+		return true
+	}
+	return credential.RoleIds != "" && funk.ContainsUInt(utils.Str2UintArr(credential.RoleIds), user.RoleId)
+}
+
+// GetSshCredential 获取用户在机器上可使用的凭据并解密, 机器需全部匹配凭据的标签选择器
+func (s *MysqlService) GetSshCredential(id uint, username string, nodeIds []uint) (*SshCredential, error) {
+	var credential models.SysCredential
+	if err := s.TX.Where("id = ?", id).First(&credential).Error; err != nil {
+		return nil, fmt.Errorf("the credential [%d] does not exist", id)
+	}
+	var user models.SysUser
+	if err := s.TX.Preload("Role").Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("the user [%s] does not exist", username)
+	}
+	if !canUseCredential(&credential, &user) {
+		return nil, fmt.Errorf("the user [%s] is not allowed to use the credential [%s]", username, credential.Name)
+	}
+	if credential.Selector != "" {
+		if len(nodeIds) == 0 {
+			return nil, fmt.Errorf("the credential [%s] can only be used on the nodes matching [%s]", credential.Name, credential.Selector)
+		}
+		ids, err := s.GetNodeIdsBySelector(credential.Selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, nodeId := range nodeIds {
+			if !funk.ContainsUInt(ids, nodeId) {
+				return nil, fmt.Errorf("the credential [%s] can not be used on the node [%d]", credential.Name, nodeId)
+			}
+		}
+	}
+	return decryptCredential(&credential)
+}
+
+// GetSshCredentialByAddress 获取用户在指定地址的机器上可使用的凭据并解密, 地址不是已添加的机器时只能使用不限机器的凭据
+func (s *MysqlService) GetSshCredentialByAddress(id uint, username, address string) (*SshCredential, error) {
+	ids := make([]uint, 0)
+	if err := s.TX.Model(&models.SysNode{}).Where("address = ?", address).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return s.GetSshCredential(id, username, ids)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/utils"
+	tests2 "metalflow/tests"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCanUseCredential(t *testing.T) {
+	user := &models.SysUser{Username: "alice", RoleId: 2}
+	tests := []struct {
+		name       string
+		credential models.SysCredential
+		user       *models.SysUser
+		want       bool
+	}{
+		{
+			name:       "unrestricted",
+			credential: models.SysCredential{},
+			user:       user,
+			want:       true,
+		},
+		{
+			name:       "allowed username",
+			credential: models.SysCredential{Usernames: "bob,alice"},
+			user:       user,
+			want:       true,
+		},
+		{
+			name:       "allowed role",
+			credential: models.SysCredential{Usernames: "bob", RoleIds: "1,2"},
+			user:       user,
+			want:       true,
+		},
+		{
+			name:       "not allowed",
+			credential: models.SysCredential{Usernames: "bob", RoleIds: "1"},
+			user:       user,
+			want:       false,
+		},
+		{
+			name:       "super admin",
+			credential: models.SysCredential{Usernames: "bob"},
+			user:       &models.SysUser{Username: "admin", RoleId: 1, Role: models.SysRole{Keyword: "super"}},
+			want:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canUseCredential(&tt.credential, tt.user); got != tt.want {
+				t.Errorf("canUseCredential() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCredentialKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		wantErr bool
+	}{
+		{key: "", wantErr: true},
+		{key: global.CredentialKeyPlaceholder, wantErr: true},
+		{key: "test-key", wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			global.Conf.System.CredentialKey = tt.key
+			if _, err := credentialKey(); (err != nil) != tt.wantErr {
+				t.Errorf("credentialKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMysqlService_GetSshCredential(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	global.Conf.System.CredentialKey = "test-key"
+	password, err := utils.EncryptString(global.Conf.System.CredentialKey, "p@ssw0rd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := []string{"id", "name", "type", "username", "password", "usernames", "role_ids", "selector"}
+	tests := []struct {
+		name    string
+		invoke  func()
+		wantErr bool
+	}{
+		{
+			name: "success",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_credential`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(1, "ops", models.SysCredentialPassword, "root", password, "", "2", ""))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_user`").WithArgs("alice").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username", "role_id"}).AddRow(1, "alice", 2))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_role`").WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "keyword"}).AddRow(2, "ops"))
+			},
+			wantErr: false,
+		},
+		{
+			name: "role not allowed",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_credential`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(1, "ops", models.SysCredentialPassword, "root", password, "", "3", ""))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_user`").WithArgs("alice").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username", "role_id"}).AddRow(1, "alice", 2))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_role`").WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "keyword"}).AddRow(2, "ops"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown node with selector",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_credential`").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(1, "ops", models.SysCredentialPassword, "root", password, "", "", "env=prod"))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_user`").WithArgs("alice").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username", "role_id"}).AddRow(1, "alice", 2))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_role`").WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "keyword"}).AddRow(2, "ops"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke()
+			credential, err := s.GetSshCredential(1, "alice", nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSshCredential() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (credential.Username != "root" || credential.Password != "p@ssw0rd") {
+				t.Errorf("GetSshCredential() = %+v", credential)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("GetSshCredential() %v", err)
+			}
+		})
+	}
+}
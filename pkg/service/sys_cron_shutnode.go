@@ -14,6 +14,8 @@ import (
 	"metalflow/pkg/global"
 	"metalflow/pkg/grpc"
 	"metalflow/pkg/request"
+	"metalflow/pkg/utils"
+	"net"
 	"strings"
 	"sync"
 	"time"
@@ -22,6 +24,12 @@ import (
 func (s *MysqlService) CreateCronShutNode(req *request.CreateCronShutNodeRequest) error {
 	nodes := make([]*models.SysNode, 0)
 
+	if _, err := utils.ParseSelector(req.Selector); err != nil {
+		return err
+	}
+	if err := req.Strategy.Validate(); err != nil {
+		return err
+	}
 	err := s.TX.Model(&models.SysNode{}).Where("id in (?)", req.NodeIds).Find(&nodes).Error
 	if err != nil {
 		return err
@@ -33,11 +41,13 @@ func (s *MysqlService) CreateCronShutNode(req *request.CreateCronShutNodeRequest
 		Keyword:   req.Keyword,
 		Status:    (*uint)(req.Status),
 		Creator:   req.Creator,
+		Selector:  req.Selector,
+		Strategy:  req.Strategy.JSON(),
 		Nodes:     nodes,
 	}
 	// 如果定时任务状态为正常，则添加定时开关机任务
 	if *cronShutNode.Status == models.SysCronShutNodeEnable {
-		jobNodes := &JobNodes{Nodes: nodes}
+		jobNodes := &JobNodes{Nodes: nodes, Selector: req.Selector, Keyword: req.Keyword, Strategy: req.Strategy}
 		var startModel, shutModel *cronlib.JobModel
 		startModel, err = cronlib.NewJobModel(req.StartTime, jobNodes.RunStartTask)
 		if err != nil {
@@ -93,6 +103,20 @@ func (s *MysqlService) UpdateCronShutNodeById(shutId uint, req *request.UpdateCr
 	if err != nil {
 		return err
 	}
+	selector := csn.Selector
+	if req.Selector != nil {
+		if _, err = utils.ParseSelector(*req.Selector); err != nil {
+			return err
+		}
+		selector = *req.Selector
+	}
+	strategy := models.ParseExecStrategy(csn.Strategy)
+	if req.Strategy != nil {
+		if err = req.Strategy.Validate(); err != nil {
+			return err
+		}
+		strategy = *req.Strategy
+	}
 
 	// 根据机器状态判断是否需要停用
 	if *csn.Status != uint(*req.Status) && uint(*req.Status) == models.SysCronShutNodeDisable {
@@ -103,7 +127,7 @@ func (s *MysqlService) UpdateCronShutNodeById(shutId uint, req *request.UpdateCr
 		global.Cron.Stop <- stopStartJob
 	} else {
 		// 更新并启动定时任务
-		jobNodes := &JobNodes{Nodes: nodes}
+		jobNodes := &JobNodes{Nodes: nodes, Selector: selector, Keyword: csn.Keyword, Strategy: strategy}
 		var startModel, shutModel *cronlib.JobModel
 		startModel, err = cronlib.NewJobModel(req.StartTime, jobNodes.RunStartTask)
 		if err != nil {
@@ -137,6 +161,18 @@ func (s *MysqlService) UpdateCronShutNodeById(shutId uint, req *request.UpdateCr
 	if err != nil {
 		return err
 	}
+	if req.Selector != nil {
+		err = query.Update("selector", selector).Error
+		if err != nil {
+			return err
+		}
+	}
+	if req.Strategy != nil {
+		err = query.Update("strategy", strategy.JSON()).Error
+		if err != nil {
+			return err
+		}
+	}
 	// 更新机器节点
 	if len(req.NodeIds) > 0 {
 		// 更新机器节点对应的labels
@@ -166,7 +202,71 @@ func (s *MysqlService) DeleteCronShutTaskByIds(ids []uint) error {
 }
 
 type JobNodes struct {
-	Nodes []*models.SysNode
+	Nodes    []*models.SysNode
+	Selector string              // 标签选择器, 每次执行时重新匹配机器
+	Keyword  string              // 定时任务关键字, 作为操作记录的发起人
+	Strategy models.ExecStrategy // 定时关机的分批执行策略
+}
+
+// cronCommandJob 创建由该定时任务发起的操作记录
+func (j *JobNodes) cronCommandJob(kind string, params any) *models.SysCommandJob {
+	s := New(nil)
+	job := s.newCommandJob(kind, params)
+	job.Trigger = models.SysCommandJobTriggerCron
+	job.Creator = j.Keyword
+	return job
+}
+
+// getNodes 获取任务需要执行的机器, 为固定机器与标签选择器匹配机器的并集, 跳过维护中以及被隐藏或禁止的机器
+func (j *JobNodes) getNodes() []*models.SysNode {
+	ids := make([]uint, 0, len(j.Nodes))
+	for _, node := range j.Nodes {
+		ids = append(ids, node.Id)
+	}
+	// 每次执行时重新查询, 以获取最新的维护状态
+	candidates := make([]*models.SysNode, 0)
+	if len(ids) > 0 {
+		if err := global.Mysql.Model(&models.SysNode{}).Where("id IN (?)", ids).Find(&candidates).Error; err != nil {
+			global.Log.Errorf("查询定时任务的机器失败：%v", err)
+			candidates = append(candidates, j.Nodes...)
+		}
+	}
+	if strings.TrimSpace(j.Selector) != "" {
+		s := New(nil)
+		query, err := s.WhereLabelSelector(global.Mysql.Model(&models.SysNode{}), j.Selector)
+		if err != nil {
+			global.Log.Errorf("解析标签选择器%s失败：%v", j.Selector, err)
+		} else {
+			matched := make([]*models.SysNode, 0)
+			if err = query.Find(&matched).Error; err != nil {
+				global.Log.Errorf("查询标签选择器%s匹配的机器失败：%v", j.Selector, err)
+			}
+			candidates = append(candidates, matched...)
+		}
+	}
+	denyList, err := GetNodeDenyList()
+	if err != nil {
+		global.Log.Errorf("查询机器隐藏/禁止名单失败：%v", err)
+		return nil
+	}
+	nodes := make([]*models.SysNode, 0, len(candidates))
+	exists := make(map[uint]bool)
+	for _, node := range candidates {
+		if exists[node.Id] {
+			continue
+		}
+		exists[node.Id] = true
+		if denyList.Denied(node.Address) {
+			global.Log.Infof("机器%s已被隐藏或禁止，跳过定时开关机任务", node.Address)
+			continue
+		}
+		if node.InMaintenance() {
+			global.Log.Infof("机器%s处于维护模式，跳过定时开关机任务", node.Address)
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
 }
 
 const (
@@ -184,20 +284,26 @@ type addressInfo struct {
 // RunStartTask 因路由及同网段限制，因此只能是相同网段去发送wol命令来执行远程唤醒
 func (j *JobNodes) RunStartTask() {
 	global.Log.Info("开始执行定时开机任务...")
-	var metaltask models.SysWorker
-	err := global.Mysql.Model(new(models.SysWorker)).Where("id = ?", 2).First(&metaltask).Error //nolint:gomnd
+	metaltask, err := getWorkerByRole(global.Mysql, models.SysWorkerRoleTask)
 	if err != nil {
 		global.Log.Errorf("search worker metaltask from database error:%v", err)
 		return
 	}
 	grpcPort := metaltask.Port
+	// 远程唤醒只能在同一二层网段内发送, 地域网段比该网段更小时按地域网段寻找相邻的机器
+	regions := make([]models.SysRegion, 0)
+	if err = global.Mysql.Model(&models.SysRegion{}).Select("name", "cidr").Find(&regions).Error; err != nil {
+		global.Log.Errorf("search regions from database error:%v", err)
+		return
+	}
 
 	var wg sync.WaitGroup
-	for _, sysNode := range j.Nodes {
+	for _, sysNode := range j.getNodes() {
 		wg.Add(1)
 		go func(node *models.SysNode, port int) {
 			global.Log.Infof("远程唤醒:%s开始...", node.Address)
 			defer wg.Done()
+			s := New(nil)
 			// 解析该节点的 mac地址, 组成可执行远程开机唤醒命令的shell文件
 			var metric grpc.Metric
 			er := json.Unmarshal(node.Information, &metric)
@@ -214,17 +320,11 @@ func (j *JobNodes) RunStartTask() {
 				IsRunnable: true,
 				FileGetter: startShellInfo,
 			}
-			// 获取该ip地址相邻的同网段信息
-			addressData := strings.Split(node.Address, ".")
-			netSegment := fmt.Sprintf("%s.%s.%s.", addressData[0], addressData[1], addressData[2])
 			// 查询该ip的其他同网段ip
-			sameNetSegments := make([]*models.SysNode, 0)
-			er = global.Mysql.Model(&models.SysNode{}).
-				Where("address LIKE ? AND address != ?", fmt.Sprintf("%%%s%%", netSegment), node.Address).
-				Find(&sameNetSegments).Error
+			sameNetSegments, er := s.getSameSubnetNodes(regions, node.Address)
 			if er != nil {
-				global.Log.Errorf("查询%s的同网段ip失败", node.Address)
-				sendStartMail(node.Address, fmt.Sprintf("查询%s的同网段ip失败", node.Address))
+				global.Log.Errorf("查询%s的同网段ip失败:%v", node.Address, er)
+				sendStartMail(node.Address, fmt.Sprintf("查询%s的同网段ip失败:%v", node.Address, er))
 				return
 			}
 			if len(sameNetSegments) == 0 {
@@ -248,10 +348,13 @@ func (j *JobNodes) RunStartTask() {
 			}
 			select {
 			case ai := <-nodeChan:
-				s := New(nil)
 				ids := []uint{ai.id}
 				global.Log.Infof("使用同网段[%s]执行远程唤醒:%s...", ai.address, node.Address)
-				e := s.BatchUploadByIds(fileMetric, ids)
+				job := j.cronCommandJob(models.SysCommandJobKindStart, map[string]any{"target": node.Address, "mac": metric.Mac})
+				e := s.RunCommandJob(job, fileMetric, ids)
+				if e == nil {
+					e = job.Error()
+				}
 				if e != nil {
 					global.Log.Errorf("使用同网段[%s]远程唤醒:%s失败:%v", ai.address, node.Address, e)
 					sendStartMail(node.Address, fmt.Sprintf("使用同网段[%s]远程唤醒:%s失败:%v", ai.address, node.Address, e))
@@ -267,6 +370,70 @@ func (j *JobNodes) RunStartTask() {
 	global.Log.Info("定时开机任务执行结束...")
 }
 
+// 远程唤醒的魔术包只能在二层广播域内传播, 默认将ipv4 /24、ipv6 /64视为同一广播域
+const (
+	wolSubnetV4Bits = 24
+	wolSubnetV6Bits = 64
+)
+
+// getWolSubnet 获取地址所在的二层网段, 所属地域的网段更小时使用地域网段, 同时返回所属地域名称(不属于任何地域时为空)
+func getWolSubnet(regions []models.SysRegion, address string) (*net.IPNet, string, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil, "", fmt.Errorf("the address %s is invalid", address)
+	}
+	bits, size := wolSubnetV4Bits, net.IPv4len*8
+	if ip.To4() == nil {
+		bits, size = wolSubnetV6Bits, net.IPv6len*8
+	}
+	mask := net.CIDRMask(bits, size)
+	subnet := &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+	cidrs := make([]string, 0, len(regions))
+	for _, region := range regions { //nolint:gocritic
+		cidrs = append(cidrs, region.Cidr)
+	}
+	i := utils.MatchCidr(address, cidrs)
+	if i < 0 {
+		return subnet, "", nil
+	}
+	_, regionNet, err := net.ParseCIDR(regions[i].Cidr)
+	if err != nil {
+		return nil, "", err
+	}
+	if ones, _ := regionNet.Mask.Size(); ones > bits {
+		subnet = regionNet
+	}
+	return subnet, regions[i].Name, nil
+}
+
+// getSameSubnetNodes 获取与该地址处于同一二层网段的其他机器, 属于某个地域时只查询该地域的机器再按网段过滤
+// 唤醒命令在这些机器上执行, 排除被隐藏、禁止及维护中的机器
+func (s *MysqlService) getSameSubnetNodes(regions []models.SysRegion, address string) ([]*models.SysNode, error) {
+	subnet, region, err := getWolSubnet(regions, address)
+	if err != nil {
+		return nil, err
+	}
+	query := s.TX.Model(&models.SysNode{}).Select("id", "address", "maintenance", "maintenance_expire").
+		Where("address != ?", address)
+	if region != "" {
+		query = query.Where("region = ?", region)
+	}
+	if query, err = s.whereNotDenied(query); err != nil {
+		return nil, err
+	}
+	nodes := make([]*models.SysNode, 0)
+	if err = query.Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	sameSubnet := make([]*models.SysNode, 0, len(nodes))
+	for _, node := range nodes {
+		if ip := net.ParseIP(node.Address); ip != nil && subnet.Contains(ip) && !node.InMaintenance() {
+			sameSubnet = append(sameSubnet, node)
+		}
+	}
+	return sameSubnet, nil
+}
+
 func sendStartMail(address, content string) {
 	// 发送邮件
 	mail := &async.Mail{
@@ -289,7 +456,7 @@ func getEmailAddr(usernames []string) []string {
 func (j *JobNodes) RunShutTask() {
 	global.Log.Info("开始执行定时关机任务...")
 	ids := make([]uint, 0)
-	for _, node := range j.Nodes {
+	for _, node := range j.getNodes() {
 		ids = append(ids, node.Id)
 	}
 	shutShellInfo := &cronShellInfo{
@@ -302,7 +469,12 @@ func (j *JobNodes) RunShutTask() {
 		FileGetter: shutShellInfo,
 	}
 	s := New(nil)
-	err := s.BatchUploadByIds(fileMetric, ids)
+	job := j.cronCommandJob(models.SysCommandJobKindShut, nil)
+	job.Strategy = j.Strategy.JSON()
+	err := s.RunCommandJob(job, fileMetric, ids)
+	if err == nil {
+		err = job.Error()
+	}
 	if err != nil {
 		global.Log.Errorf("定时关机任务执行失败：%v", err)
 	}
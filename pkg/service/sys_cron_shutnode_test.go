@@ -0,0 +1,107 @@
+package service
+
+import (
+	"metalflow/models"
+	tests2 "metalflow/tests"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetSameSubnetNodes(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	regions := []models.SysRegion{
+		{Name: "beijing", Cidr: "10.12.0.0/16"},
+		{Name: "beijing-lab", Cidr: "10.12.34.0/26"},
+	}
+	expectDeny := func() {
+		mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
+	}
+	tests := []struct {
+		name    string
+		address string
+		invoke  func()
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "invalid address",
+			address: "node-1",
+			invoke:  func() {},
+			wantErr: true,
+		},
+		{
+			name:    "no region",
+			address: "192.168.1.1",
+			invoke: func() {
+				expectDeny()
+				mock.ExpectQuery("SELECT `id`,`address`,`maintenance`,`maintenance_expire` FROM `tb_sys_node`").WithArgs("192.168.1.1").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "address"}).
+						AddRow(2, "192.168.1.2").AddRow(3, "192.168.2.1"))
+			},
+			want:    []string{"192.168.1.2"},
+			wantErr: false,
+		},
+		{
+			name:    "denied and maintenance nodes",
+			address: "192.168.1.1",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").
+					WillReturnRows(sqlmock.NewRows([]string{"cidr", "action"}).AddRow("192.168.1.2/32", models.SysNodeDenyBlock))
+				mock.ExpectQuery("SELECT `id`,`address` FROM `tb_sys_node`").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "address"}).AddRow(2, "192.168.1.2"))
+				mock.ExpectQuery("SELECT `id`,`address`,`maintenance`,`maintenance_expire` FROM `tb_sys_node`").
+					WithArgs("192.168.1.1", 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "address", "maintenance"}).
+						AddRow(3, "192.168.1.3", models.SysNodeMaintenanceOn).AddRow(4, "192.168.1.4", 0))
+			},
+			want:    []string{"192.168.1.4"},
+			wantErr: false,
+		},
+		{
+			name:    "region wider than broadcast domain",
+			address: "10.12.1.5",
+			invoke: func() {
+				expectDeny()
+				mock.ExpectQuery("SELECT `id`,`address`,`maintenance`,`maintenance_expire` FROM `tb_sys_node`").WithArgs("10.12.1.5", "beijing").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "address"}).
+						AddRow(2, "10.12.1.6").AddRow(3, "10.12.2.1"))
+			},
+			want:    []string{"10.12.1.6"},
+			wantErr: false,
+		},
+		{
+			name:    "region narrower than broadcast domain",
+			address: "10.12.34.56",
+			invoke: func() {
+				expectDeny()
+				mock.ExpectQuery("SELECT `id`,`address`,`maintenance`,`maintenance_expire` FROM `tb_sys_node`").WithArgs("10.12.34.56", "beijing-lab").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "address"}).
+						AddRow(2, "10.12.34.57").AddRow(3, "10.12.34.100"))
+			},
+			want:    []string{"10.12.34.57"},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke()
+			nodes, err := s.getSameSubnetNodes(regions, tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getSameSubnetNodes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(nodes) != len(tt.want) {
+				t.Fatalf("getSameSubnetNodes() = %d nodes, want %v", len(nodes), tt.want)
+			}
+			for i, node := range nodes {
+				if node.Address != tt.want[i] {
+					t.Errorf("getSameSubnetNodes() = %s, want %s", node.Address, tt.want[i])
+				}
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("getSameSubnetNodes() %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"metalflow/models"
+	"metalflow/pkg/request"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 变量名称需能在模板中以{{.Vars.name}}的形式使用
+var deployVarNameReg = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// getDeployVars 获取机器所在地域可使用的部署变量, 地域变量覆盖同名的全局变量
+func getDeployVars(tx *gorm.DB, region string) (map[string]string, error) {
+	list := make([]models.SysDeployVar, 0)
+	err := tx.Model(&models.SysDeployVar{}).Select("name", "region", "value").
+		Where("region = ? OR region = ?", "", region).Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	vars := make(map[string]string, len(list))
+	for _, item := range list { //nolint:gocritic
+		if item.Region == "" {
+			vars[item.Name] = item.Value
+		}
+	}
+	for _, item := range list { //nolint:gocritic
+		if item.Region != "" {
+			vars[item.Name] = item.Value
+		}
+	}
+	return vars, nil
+}
+
+// GetDeployVars 获取部署变量列表
+func (s *MysqlService) GetDeployVars(req *request.DeployVarListRequestStruct) ([]models.SysDeployVar, error) {
+	list := make([]models.SysDeployVar, 0)
+	query := s.TX.Model(&models.SysDeployVar{}).Order("name, region")
+	name := strings.TrimSpace(req.Name)
+	if name != "" {
+		query = query.Where("name LIKE ?", fmt.Sprintf("%%%s%%", name))
+	}
+	if req.Region != nil {
+		query = query.Where("region = ?", strings.TrimSpace(*req.Region))
+	}
+	err := s.Find(query, &req.PageInfo, &list)
+	return list, err
+}
+
+// checkDeployVar 校验变量名称, 同一地域的变量名称不能重复
+func (s *MysqlService) checkDeployVar(id uint, name, region string) error {
+	if !deployVarNameReg.MatchString(name) {
+		return fmt.Errorf("the variable name [%s] may only contain letters, digits and underscores", name)
+	}
+	err := s.TX.Where("name = ? AND region = ? AND id != ?", name, region, id).First(&models.SysDeployVar{}).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("the variable [%s] already exists in region [%s]", name, region)
+	}
+	return nil
+}
+
+// CreateDeployVar 创建部署变量
+func (s *MysqlService) CreateDeployVar(req *request.CreateDeployVarRequestStruct) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Region = strings.TrimSpace(req.Region)
+	if err := s.checkDeployVar(0, req.Name, req.Region); err != nil {
+		return err
+	}
+	return s.Create(req, new(models.SysDeployVar))
+}
+
+// UpdateDeployVarById 更新部署变量
+func (s *MysqlService) UpdateDeployVarById(id uint, req *request.UpdateDeployVarRequestStruct) error {
+	if req.Name != nil || req.Region != nil {
+		var oldVar models.SysDeployVar
+		if err := s.TX.Where("id = ?", id).First(&oldVar).Error; err != nil {
+			return err
+		}
+		name, region := oldVar.Name, oldVar.Region
+		if req.Name != nil {
+			name = strings.TrimSpace(*req.Name)
+			req.Name = &name
+		}
+		if req.Region != nil {
+			region = strings.TrimSpace(*req.Region)
+			req.Region = &region
+		}
+		if err := s.checkDeployVar(id, name, region); err != nil {
+			return err
+		}
+	}
+	return s.UpdateById(id, req, new(models.SysDeployVar))
+}
@@ -1,10 +1,15 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"metalflow/models"
 	"metalflow/pkg/request"
+	"metalflow/pkg/utils"
 	"strings"
+
+	"github.com/thoas/go-funk"
+	"gorm.io/gorm"
 )
 
 // GetLabels 获取label列表
@@ -17,6 +22,11 @@ func (s *MysqlService) GetLabels(req *request.LabelListRequestStruct) ([]models.
 		query = query.Where("name LIKE ?", fmt.Sprintf("%%%s%%", name))
 	}
 
+	key := strings.TrimSpace(req.Key)
+	if key != "" {
+		query = query.Where("label_key = ?", key)
+	}
+
 	creator := strings.TrimSpace(req.Creator)
 	if creator != "" {
 		query = query.Where("creator LIKE ?", fmt.Sprintf("%%%s%%", creator))
@@ -26,3 +36,96 @@ func (s *MysqlService) GetLabels(req *request.LabelListRequestStruct) ([]models.
 	err := s.Find(query, &req.PageInfo, &list)
 	return list, err
 }
+
+// WhereLabelSelector 为机器节点查询添加标签选择器条件, 如"env=ci,arch in (x86,arm),!deprecated"
+func (s *MysqlService) WhereLabelSelector(query *gorm.DB, selector string) (*gorm.DB, error) {
+	requirements, err := utils.ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range requirements {
+		switch r.Operator {
+		case utils.SelectorEquals, utils.SelectorIn:
+			query = query.Where("id IN (?)", s.labelNodeIds(r.Key, r.Values))
+		case utils.SelectorNotEquals, utils.SelectorNotIn:
+			query = query.Where("id NOT IN (?)", s.labelNodeIds(r.Key, r.Values))
+		case utils.SelectorExists:
+			query = query.Where("id IN (?)", s.labelNodeIds(r.Key, nil))
+		case utils.SelectorNotExists:
+			query = query.Where("id NOT IN (?)", s.labelNodeIds(r.Key, nil))
+		}
+	}
+	return query, nil
+}
+
+// labelNodeIds 拥有指定标签的机器id子查询, values为空时只匹配键
+func (s *MysqlService) labelNodeIds(key string, values []string) *gorm.DB {
+	labelTable := new(models.SysLabel).TableName()
+	relationTable := models.RelationNodeLabel{}.TableName()
+	query := s.TX.Table(relationTable).
+		Select(relationTable+".sys_node_id").
+		Joins(fmt.Sprintf("JOIN %s ON %s.id = %s.sys_label_id", labelTable, labelTable, relationTable)).
+		Where(labelTable+".label_key = ? AND "+labelTable+".deleted_at IS NULL", key)
+	if len(values) > 0 {
+		query = query.Where(labelTable+".label_value IN (?)", values)
+	}
+	return query
+}
+
+// GetNodeIdsBySelector 根据标签选择器获取机器id
+func (s *MysqlService) GetNodeIdsBySelector(selector string) ([]uint, error) {
+	query, err := s.WhereLabelSelector(s.TX.Model(&models.SysNode{}), selector)
+	if err != nil {
+		return nil, err
+	}
+	// 选择器不匹配被隐藏或禁止的机器
+	if query, err = s.whereNotDenied(query); err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0)
+	err = query.Pluck("id", &ids).Error
+	return ids, err
+}
+
+// GetBatchNodeIds 获取批量操作的机器id, 为指定的机器与标签选择器匹配机器的并集(与定时任务一致); 拒绝被禁止的机器, 非强制操作时拒绝维护中的机器
+func (s *MysqlService) GetBatchNodeIds(ids []uint, selector string, force bool) ([]uint, error) {
+	if strings.TrimSpace(selector) != "" {
+		matched, err := s.GetNodeIdsBySelector(selector)
+		if err != nil {
+			return nil, err
+		}
+		if len(matched) == 0 && len(ids) == 0 {
+			return nil, fmt.Errorf("no node matches the selector [%s]", selector)
+		}
+		ids = funk.UniqUInt(append(append(make([]uint, 0, len(ids)+len(matched)), ids...), matched...))
+	}
+	// 被禁止的机器即使强制也不能操作
+	if err := s.checkNodeIdsBlocked(ids); err != nil {
+		return nil, err
+	}
+	if !force {
+		if err := s.checkMaintenance(ids); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// findOrCreateLabels 根据"key=value"格式的标签名获取标签, 不存在时创建
+func (s *MysqlService) findOrCreateLabels(names []string, creator string) ([]models.SysLabel, error) {
+	labels := make([]models.SysLabel, 0, len(names))
+	for _, name := range names {
+		key, value := utils.ParseLabel(name)
+		var label models.SysLabel
+		err := s.TX.Where("label_key = ? AND label_value = ?", key, value).First(&label).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			label = models.SysLabel{Name: utils.FormatLabel(key, value), Key: key, Value: value, Creator: creator}
+			err = s.TX.Create(&label).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
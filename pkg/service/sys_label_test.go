@@ -6,6 +6,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"metalflow/pkg/request"
 	tests2 "metalflow/tests"
+	"reflect"
 	"testing"
 )
 
@@ -64,3 +65,109 @@ func TestMysqlService_GetLabels(t *testing.T) {
 		})
 	}
 }
+
+func TestMysqlService_GetNodeIdsBySelector(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	tests := []struct {
+		name     string
+		selector string
+		invoke   func()
+		want     int
+		wantErr  bool
+	}{
+		{
+			name:     "fail1",
+			selector: "arch in (x86",
+			invoke:   func() {},
+			wantErr:  true,
+		},
+		{
+			name:     "fail2",
+			selector: "env=ci",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
+				mock.ExpectQuery("SELECT `id` FROM `tb_sys_node` WHERE id IN \\(SELECT (.*)label_key = \\?").
+					WithArgs("env", "ci").
+					WillReturnError(errors.New("DB error"))
+			},
+			wantErr: true,
+		},
+		{
+			name:     "success",
+			selector: "env=ci,arch in (x86,arm),!deprecated",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
+				mock.ExpectQuery("SELECT `id` FROM `tb_sys_node` WHERE (.*) AND id NOT IN \\(SELECT").
+					WithArgs("env", "ci", "arch", "x86", "arm", "deprecated").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+			},
+			want:    2,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke()
+			ids, err := s.GetNodeIdsBySelector(tt.selector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetNodeIdsBySelector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(ids) != tt.want {
+				t.Errorf("GetNodeIdsBySelector() = %v, want %d ids", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestMysqlService_GetBatchNodeIds(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	tests := []struct {
+		name     string
+		ids      []uint
+		selector string
+		invoke   func()
+		want     []uint
+		wantErr  bool
+	}{
+		{
+			name:     "no node matches",
+			selector: "env=ci",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
+				mock.ExpectQuery("SELECT `id` FROM `tb_sys_node`").WithArgs("env", "ci").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: true,
+		},
+		{
+			name:     "union of ids and selector",
+			ids:      []uint{3, 1},
+			selector: "env=ci",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
+				mock.ExpectQuery("SELECT `id` FROM `tb_sys_node`").WithArgs("env", "ci").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
+			},
+			want:    []uint{3, 1, 2},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke()
+			ids, err := s.GetBatchNodeIds(tt.ids, tt.selector, true)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetBatchNodeIds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(ids, tt.want) && len(tt.want) > 0 {
+				t.Errorf("GetBatchNodeIds() = %v, want %v", ids, tt.want)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("GetBatchNodeIds() %v", err)
+			}
+		})
+	}
+}
@@ -19,15 +19,20 @@ import (
 func (s *MysqlService) GetNodes(req *request.NodeListRequestStruct) ([]models.SysNode, error) {
 	var err error
 	list := make([]models.SysNode, 0)
+	now := time.Now()
 	query := s.TX.
 		Model(&models.SysNode{}).
 		Preload("Labels").
-		Order("created_at DESC")
+		Preload("Reservations", "status = ? AND start_time <= ? AND end_time > ?",
+			models.SysNodeReservationActive, now, now).
+		Preload("WorkerHealth", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sys_worker_id")
+		}).
+		Order(getNodeOrder(req))
 	// Eliminate machines that need to be hidden
-	hide := strings.TrimSpace(global.Conf.NodeConf.Hide)
-	if hide != "" {
-		hideNodes := strings.Split(hide, ",")
-		query = query.Where("address NOT IN (?)", hideNodes)
+	query, err = s.whereNotDenied(query)
+	if err != nil {
+		return list, err
 	}
 
 	address := strings.TrimSpace(req.Address)
@@ -57,21 +62,67 @@ func (s *MysqlService) GetNodes(req *request.NodeListRequestStruct) ([]models.Sy
 	if req.Health != nil {
 		query = query.Where("health = ?", *req.Health)
 	}
+	// 按容量筛选
+	cpuModel := strings.TrimSpace(req.CpuModel)
+	if cpuModel != "" {
+		query = query.Where("cpu_model LIKE ?", fmt.Sprintf("%%%s%%", cpuModel))
+	}
+	if req.MinCpuCores > 0 {
+		query = query.Where("cpu_cores >= ?", req.MinCpuCores)
+	}
+	if req.MinRamGb > 0 {
+		query = query.Where("ram_bytes >= ?", uint64(req.MinRamGb)<<30) //nolint:gomnd
+	}
+	if req.MinDiskGb > 0 {
+		query = query.Where("disk_bytes >= ?", uint64(req.MinDiskGb)<<30) //nolint:gomnd
+	}
+	if req.MinNicSpeed > 0 {
+		query = query.Where("nic_speed >= ?", req.MinNicSpeed)
+	}
+	// 按当前预约人筛选
+	holder := strings.TrimSpace(req.Holder)
+	if holder != "" {
+		query = query.Where("id IN (?)", s.TX.Model(&models.SysNodeReservation{}).Select("node_id").
+			Where("username = ? AND status = ? AND start_time <= ? AND end_time > ?",
+				holder, models.SysNodeReservationActive, now, now))
+	}
+	// 按标签选择器筛选
+	if strings.TrimSpace(req.Selector) != "" {
+		query, err = s.WhereLabelSelector(query, req.Selector)
+		if err != nil {
+			return list, err
+		}
+	}
 	// 查询列表
 	err = s.Find(query, &req.PageInfo, &list)
 	return list, err
 }
 
+// 机器列表允许排序的字段
+var nodeSortColumns = map[string]string{
+	"createdAt": "created_at",
+	"cpuCores":  "cpu_cores",
+	"ramBytes":  "ram_bytes",
+	"diskBytes": "disk_bytes",
+	"nicSpeed":  "nic_speed",
+}
+
+// getNodeOrder 获取机器列表排序, 未知字段按创建时间排序
+func getNodeOrder(req *request.NodeListRequestStruct) string {
+	column, ok := nodeSortColumns[req.SortBy]
+	if !ok {
+		column = "created_at"
+	}
+	if req.SortDesc != nil && !*req.SortDesc {
+		return column + " ASC"
+	}
+	return column + " DESC"
+}
+
 func (s *MysqlService) CreateNode(req *request.CreateNodeRequestStruct) error {
-	// Eliminate machines that need to be hidden
-	hide := strings.TrimSpace(global.Conf.NodeConf.Hide)
-	if hide != "" {
-		hideNodes := strings.Split(hide, ",")
-		for _, node := range hideNodes {
-			if req.Address == node {
-				return fmt.Errorf("for security reasons, address [%s] is not allowed", req.Address)
-			}
-		}
+	// Eliminate machines that are blocked
+	if err := s.CheckNodeBlocked(req.Address); err != nil {
+		return err
 	}
 
 	query := s.TX.Where("address = ?", req.Address).First(&models.SysNode{})
@@ -90,7 +141,7 @@ func (s *MysqlService) CreateNode(req *request.CreateNodeRequestStruct) error {
 			Asset:       req.Asset,
 			Health:      req.Health,
 			Performance: req.Performance,
-			Region:      GetAddrByIp(req.Address),
+			Region:      getRegionByIp(s.TX, req.Address),
 			ServicePort: req.ServicePort,
 			Remark:      req.Remark,
 			Creator:     req.Creator,
@@ -117,6 +168,13 @@ func (s *MysqlService) UpdateNodeById(nodeId uint, req *request.UpdateNodeReques
 			return
 		}
 	}
+	// 更新性能评级规则
+	if req.ProfileId != nil {
+		err = query.Update("profile_id", *req.ProfileId).Error
+		if err != nil {
+			return
+		}
+	}
 	// 更新机器标签
 	if len(req.LabelIds) > 0 {
 		labels := make([]models.SysLabel, 0)
@@ -132,14 +190,14 @@ func (s *MysqlService) UpdateNodeById(nodeId uint, req *request.UpdateNodeReques
 
 func (s *MysqlService) DeleteNodeByIds(ids []uint) error {
 	// 为了解决软删除与索引唯一的冲突，删除前先将unique键address进行更新重写
+	// 关联的标签/worker/收藏等数据保留, 以便从回收站恢复
 	for _, id := range ids {
 		var node models.SysNode
 		err := s.TX.Where("id = ?", id).First(&node).Error
 		if err != nil {
 			return err
 		}
-		err = s.TX.Model(new(models.SysNode)).Where("id = ?", id).Update("address",
-			fmt.Sprintf("%s|D|%s", time.Now().Format(global.MsecLocalTimeFormat), node.Address)).Error
+		err = s.TX.Model(new(models.SysNode)).Where("id = ?", id).Update("address", getDeletedAddress(node.Address)).Error
 		if err != nil {
 			return err
 		}
@@ -162,10 +220,12 @@ func (s *MysqlService) RefreshNodeInfoById(id uint) error {
 	if time.Since(node.RefreshLastTime.Time).Minutes() < 5 { //nolint:gomnd
 		return fmt.Errorf("五分钟内已有其他人刷新该节点信息，无需重复刷新")
 	}
+	if err := s.CheckNodeBlocked(node.Address); err != nil {
+		return err
+	}
 	// 启动异步任务刷新机器节点信息
 	if global.Machinery != nil {
-		var worker models.SysWorker
-		err := global.Mysql.Model(new(models.SysWorker)).Where("id = ?", 1).First(&worker).Error
+		worker, err := getWorkerByRole(global.Mysql, models.SysWorkerRoleMetrics)
 		if err != nil {
 			global.Log.Error("search worker from database failed")
 			return err
@@ -191,8 +251,9 @@ const (
 	remoteDir        = "/tmp/"
 )
 
-// BatchRebootNodesByIds used to batch reboot nodes
-func (s *MysqlService) BatchRebootNodesByIds(ids []uint) error {
+// BatchRebootNodesByIds used to batch reboot nodes, waits for the reboot when the strategy is not set,
+// otherwise reboots the nodes batch by batch in background and returns the job.
+func (s *MysqlService) BatchRebootNodesByIds(ids []uint, strategy models.ExecStrategy) (*models.SysCommandJob, error) {
 	fsInfo := &FSInfo{path: rebootScriptPath}
 	metric := grpc.FileMetric{
 		FilePath:   rebootScriptPath,
@@ -200,7 +261,19 @@ func (s *MysqlService) BatchRebootNodesByIds(ids []uint) error {
 		FileGetter: fsInfo,
 		IsRunnable: true,
 	}
-	return s.BatchUploadByIds(metric, ids)
+	if strategy.IsZero() {
+		return nil, s.BatchUploadByIds(models.SysCommandJobKindReboot, nil, strategy, metric, ids)
+	}
+	job := s.newCommandJob(models.SysCommandJobKindReboot, nil)
+	job.Content = readCommandJobContent(metric)
+	if job.Content == "" {
+		return nil, errors.New("read the reboot script failed")
+	}
+	err := s.startCommandJob(job, rebootScriptPath, remoteDir, ids, &request.CommandJobExecRequestStruct{Strategy: strategy})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
 }
 
 type FSInfo struct {
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"metalflow/models"
+	"metalflow/pkg/grpc"
+	"metalflow/pkg/request"
+	"strings"
+)
+
+// changeField 需要检测变更的metrics字段, cpu/ram/disk只比较容量, 忽略使用率
+type changeField struct {
+	name  string
+	value func(m *grpc.Metric) string
+}
+
+var changeFields = []changeField{
+	{name: "cpu", value: func(m *grpc.Metric) string { return grpc.GetMetricCapacity(m.Cpu) }},
+	{name: "ram", value: func(m *grpc.Metric) string { return grpc.GetMetricCapacity(m.Ram) }},
+	{name: "disk", value: func(m *grpc.Metric) string { return grpc.GetMetricCapacity(m.Disk) }},
+	{name: "kernel", value: func(m *grpc.Metric) string { return m.Kernel }},
+	{name: "os", value: func(m *grpc.Metric) string { return m.Os }},
+	{name: "mac", value: func(m *grpc.Metric) string { return m.Mac }},
+	{name: "eth", value: func(m *grpc.Metric) string { return m.Eth }},
+	{name: "users", value: func(m *grpc.Metric) string { return m.Users }},
+}
+
+// detectNodeChanges 比较节点已保存的information与本次获取的metric, 返回所有变更记录
+func detectNodeChanges(node *models.SysNode, metric *grpc.Metric) []models.SysNodeChange {
+	changes := make([]models.SysNodeChange, 0)
+	// 首次刷新没有历史信息, 不产生变更记录
+	if len(node.Information) == 0 || string(node.Information) == models.NullString {
+		return changes
+	}
+	var old grpc.Metric
+	if err := json.Unmarshal(node.Information, &old); err != nil {
+		return changes
+	}
+	for _, field := range changeFields {
+		oldValue := strings.TrimSpace(field.value(&old))
+		newValue := strings.TrimSpace(field.value(metric))
+		if oldValue == newValue {
+			continue
+		}
+		changes = append(changes, models.SysNodeChange{
+			NodeId:   node.Id,
+			Address:  node.Address,
+			Field:    field.name,
+			OldValue: oldValue,
+			NewValue: newValue,
+		})
+	}
+	return changes
+}
+
+// GetNodeChanges 获取机器节点的变更记录, nodeId为0时查询所有节点
+func (s *MysqlService) GetNodeChanges(nodeId uint, req *request.NodeChangeListRequestStruct) ([]models.SysNodeChange, error) {
+	list := make([]models.SysNodeChange, 0)
+	query := s.TX.
+		Model(&models.SysNodeChange{}).
+		Order("created_at DESC")
+	if nodeId > 0 {
+		query = query.Where("node_id = ?", nodeId)
+	}
+	address := strings.TrimSpace(req.Address)
+	if address != "" {
+		query = query.Where("address LIKE ?", fmt.Sprintf("%%%s%%", address))
+	}
+	field := strings.TrimSpace(req.Field)
+	if field != "" {
+		query = query.Where("field = ?", field)
+	}
+	if req.StartTime != "" {
+		query = query.Where("created_at >= ?", new(models.LocalTime).SetString(req.StartTime).Time)
+	}
+	if req.EndTime != "" {
+		query = query.Where("created_at <= ?", new(models.LocalTime).SetString(req.EndTime).Time)
+	}
+	// 查询列表
+	err := s.Find(query, &req.PageInfo, &list)
+	return list, err
+}
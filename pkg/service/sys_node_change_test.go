@@ -0,0 +1,50 @@
+package service
+
+import (
+	"metalflow/models"
+	"metalflow/pkg/grpc"
+	"testing"
+)
+
+func TestDetectNodeChanges(t *testing.T) {
+	oldMetric := grpc.Metric{
+		ModelMetric: grpc.ModelMetric{
+			Cpu: "8 CPU (10.0% used)",
+			Ram: "32 GB (20.0% used)",
+		},
+		Kernel: "5.4.0",
+		Mac:    "00:11:22:33:44:55",
+	}
+	information, _ := grpc.GetNodeInformation(&oldMetric)
+	node := &models.SysNode{
+		Model:       models.Model{Id: 1},
+		Address:     "10.12.0.1",
+		Information: []byte(information),
+	}
+
+	// only usage changes, no change record.
+	newMetric := oldMetric
+	newMetric.Cpu = "8 CPU (90.0% used)"
+	if changes := detectNodeChanges(node, &newMetric); len(changes) != 0 {
+		t.Errorf("detectNodeChanges() = %v, want no changes", changes)
+	}
+
+	// ram removed and kernel upgraded.
+	newMetric.Ram = "16 GB (40.0% used)"
+	newMetric.Kernel = "5.15.0"
+	changes := detectNodeChanges(node, &newMetric)
+	if len(changes) != 2 {
+		t.Fatalf("detectNodeChanges() got %d changes, want 2", len(changes))
+	}
+	if changes[0].Field != "ram" || changes[0].OldValue != "32 GB" || changes[0].NewValue != "16 GB" {
+		t.Errorf("detectNodeChanges() ram change = %+v", changes[0])
+	}
+	if changes[1].Field != "kernel" || changes[1].NodeId != 1 || changes[1].Address != "10.12.0.1" {
+		t.Errorf("detectNodeChanges() kernel change = %+v", changes[1])
+	}
+
+	// first refresh without information.
+	if changes := detectNodeChanges(&models.SysNode{}, &newMetric); len(changes) != 0 {
+		t.Errorf("detectNodeChanges() on first refresh = %v, want no changes", changes)
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"metalflow/pkg/utils"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// NodeDenyList 机器隐藏/禁止名单
+type NodeDenyList []models.SysNodeDeny
+
+// Match 获取地址命中的规则, 同时命中隐藏与禁止时优先返回禁止规则, 未命中时返回nil
+func (l NodeDenyList) Match(address string) *models.SysNodeDeny {
+	var hide *models.SysNodeDeny
+	for i := range l {
+		if utils.MatchCidr(address, []string{l[i].Cidr}) < 0 {
+			continue
+		}
+		if l[i].IsBlock() {
+			return &l[i]
+		}
+		if hide == nil {
+			hide = &l[i]
+		}
+	}
+	return hide
+}
+
+// Denied 地址是否被隐藏或禁止
+func (l NodeDenyList) Denied(address string) bool {
+	return l.Match(address) != nil
+}
+
+// CheckBlocked 地址被禁止时返回错误
+func (l NodeDenyList) CheckBlocked(address string) error {
+	if rule := l.Match(address); rule != nil && rule.IsBlock() {
+		if rule.Reason != "" {
+			return fmt.Errorf("for security reasons, address [%s] is not allowed: %s", address, rule.Reason)
+		}
+		return fmt.Errorf("for security reasons, address [%s] is not allowed", address)
+	}
+	return nil
+}
+
+// getNodeDenyList 获取机器隐藏/禁止名单
+func getNodeDenyList(tx *gorm.DB) (NodeDenyList, error) {
+	list := make(NodeDenyList, 0)
+	err := tx.Model(&models.SysNodeDeny{}).Select("cidr", "action", "reason").Find(&list).Error
+	return list, err
+}
+
+// GetNodeDenyList 获取机器隐藏/禁止名单, 用于定时任务、consul注册等后台流程
+func GetNodeDenyList() (NodeDenyList, error) {
+	return getNodeDenyList(global.Mysql)
+}
+
+// CheckNodeBlocked 检查地址是否被禁止
+func (s *MysqlService) CheckNodeBlocked(address string) error {
+	list, err := getNodeDenyList(s.TX)
+	if err != nil {
+		return err
+	}
+	return list.CheckBlocked(address)
+}
+
+// getUnblockedNode 获取机器, 机器被禁止时返回错误
+func (s *MysqlService) getUnblockedNode(nodeId uint) (*models.SysNode, error) {
+	var node models.SysNode
+	if err := s.TX.Model(&models.SysNode{}).Where("id = ?", nodeId).First(&node).Error; err != nil {
+		return nil, err
+	}
+	if err := s.CheckNodeBlocked(node.Address); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// checkNodeIdsBlocked 检查机器中是否有被禁止的机器
+func (s *MysqlService) checkNodeIdsBlocked(ids []uint) error {
+	list, err := getNodeDenyList(s.TX)
+	if err != nil || len(list) == 0 || len(ids) == 0 {
+		return err
+	}
+	addresses := make([]string, 0)
+	if err = s.TX.Model(&models.SysNode{}).Where("id IN (?)", ids).Pluck("address", &addresses).Error; err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		if err = list.CheckBlocked(address); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// whereNotDenied 排除被隐藏或禁止的机器
+func (s *MysqlService) whereNotDenied(query *gorm.DB) (*gorm.DB, error) {
+	list, err := getNodeDenyList(s.TX)
+	if err != nil || len(list) == 0 {
+		return query, err
+	}
+	nodes := make([]models.SysNode, 0)
+	if err = s.TX.Model(&models.SysNode{}).Select("id", "address").Find(&nodes).Error; err != nil {
+		return query, err
+	}
+	ids := make([]uint, 0)
+	for _, node := range nodes { //nolint:gocritic
+		if list.Denied(node.Address) {
+			ids = append(ids, node.Id)
+		}
+	}
+	if len(ids) > 0 {
+		query = query.Where("id NOT IN (?)", ids)
+	}
+	return query, nil
+}
+
+// GetNodeDenies 获取机器隐藏/禁止规则列表
+func (s *MysqlService) GetNodeDenies(req *request.NodeDenyListRequestStruct) ([]models.SysNodeDeny, error) {
+	list := make([]models.SysNodeDeny, 0)
+	query := s.TX.Model(&models.SysNodeDeny{}).Order("created_at DESC")
+	cidr := strings.TrimSpace(req.Cidr)
+	if cidr != "" {
+		query = query.Where("cidr LIKE ?", fmt.Sprintf("%%%s%%", cidr))
+	}
+	if req.Action != nil {
+		query = query.Where("action = ?", *req.Action)
+	}
+	err := s.Find(query, &req.PageInfo, &list)
+	return list, err
+}
+
+// checkNodeDenyCidr 校验并格式化网段, 同一网段只能有一条规则
+func (s *MysqlService) checkNodeDenyCidr(id uint, cidr string) (string, error) {
+	cidr, err := utils.NormalizeCidr(cidr)
+	if err != nil {
+		return "", err
+	}
+	err = s.TX.Where("cidr = ? AND id != ?", cidr, id).First(&models.SysNodeDeny{}).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("the cidr %s already exists in the deny list", cidr)
+	}
+	return cidr, nil
+}
+
+// CreateNodeDeny 创建机器隐藏/禁止规则
+func (s *MysqlService) CreateNodeDeny(req *request.CreateNodeDenyRequestStruct) (err error) {
+	req.Cidr, err = s.checkNodeDenyCidr(0, req.Cidr)
+	if err != nil {
+		return
+	}
+	return s.Create(req, new(models.SysNodeDeny))
+}
+
+// UpdateNodeDenyById 更新机器隐藏/禁止规则
+func (s *MysqlService) UpdateNodeDenyById(id uint, req *request.UpdateNodeDenyRequestStruct) error {
+	if req.Cidr != nil {
+		cidr, err := s.checkNodeDenyCidr(id, *req.Cidr)
+		if err != nil {
+			return err
+		}
+		req.Cidr = &cidr
+	}
+	return s.UpdateById(id, req, new(models.SysNodeDeny))
+}
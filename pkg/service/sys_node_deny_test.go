@@ -0,0 +1,62 @@
+package service
+
+import (
+	"metalflow/models"
+	tests2 "metalflow/tests"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestNodeDenyList_Match(t *testing.T) {
+	hide, block := models.SysNodeDenyHide, models.SysNodeDenyBlock
+	list := NodeDenyList{
+		{Cidr: "10.23.0.0/16", Action: &hide},
+		{Cidr: "10.23.45.67/32", Action: &block, Reason: "bastion"},
+		{Cidr: "fd00::/64", Action: &block},
+	}
+	if rule := list.Match("10.23.1.1"); rule == nil || rule.IsBlock() {
+		t.Errorf("Match(10.23.1.1) = %+v, want hide rule", rule)
+	}
+	if err := list.CheckBlocked("10.23.1.1"); err != nil {
+		t.Errorf("CheckBlocked(10.23.1.1) = %v, hidden node should not be blocked", err)
+	}
+	// 同时命中隐藏与禁止时以禁止为准
+	if err := list.CheckBlocked("10.23.45.67"); err == nil {
+		t.Errorf("CheckBlocked(10.23.45.67) should fail")
+	}
+	if !list.Denied("fd00::1") || list.CheckBlocked("fd00::1") == nil {
+		t.Errorf("fd00::1 should be blocked")
+	}
+	if list.Denied("10.24.0.1") {
+		t.Errorf("Denied(10.24.0.1) = true, want false")
+	}
+}
+
+func TestMysqlService_getUnblockedNode(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	tests := []struct {
+		name    string
+		address string
+		wantErr bool
+	}{
+		{name: "allowed", address: "10.23.1.1", wantErr: false},
+		{name: "blocked", address: "10.23.45.67", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "address"}).AddRow(1, tt.address))
+			mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").
+				WillReturnRows(sqlmock.NewRows([]string{"cidr", "action", "reason"}).
+					AddRow("10.23.45.67/32", models.SysNodeDenyBlock, "bastion"))
+			if _, err := s.getUnblockedNode(1); (err != nil) != tt.wantErr {
+				t.Errorf("getUnblockedNode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("getUnblockedNode() %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/utils"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+// GetNodeHostKeys 获取机器主机公钥列表
+func (s *MysqlService) GetNodeHostKeys(req *request.NodeHostKeyListRequestStruct) ([]models.SysNodeHostKey, error) {
+	list := make([]models.SysNodeHostKey, 0)
+	query := s.TX.Model(&models.SysNodeHostKey{}).Order("address").Order("id")
+	address := strings.TrimSpace(req.Address)
+	if address != "" {
+		query = query.Where("address LIKE ?", fmt.Sprintf("%%%s%%", address))
+	}
+	if req.Mismatch {
+		query = query.Where("pending_key != ''")
+	}
+	err := s.Find(query, &req.PageInfo, &list)
+	return list, err
+}
+
+// mysqlDuplicateEntry mysql违反唯一索引的错误码
+const mysqlDuplicateEntry = 1062
+
+// HostKeyOption 生成校验机器主机公钥的ssh选项, 首次连接时保存公钥(trust on first use)
+// 公钥不一致时记录服务端提供的公钥, 按node.host-key-mismatch拒绝连接或告警后继续; 使用无事务的连接, 记录不随请求回滚
+func (s *MysqlService) HostKeyOption(address string) (utils.Option, error) {
+	return hostKeyOption(s.DB, address)
+}
+
+func hostKeyOption(db *gorm.DB, address string) (utils.Option, error) {
+	keys := make([]models.SysNodeHostKey, 0)
+	if err := db.Where("address = ?", address).Order("id").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	var algorithms []string
+	if len(keys) > 0 {
+		keyTypes := make([]string, 0, len(keys))
+		for _, key := range keys {
+			keyTypes = append(keyTypes, key.KeyType)
+		}
+		algorithms = utils.HostKeyAlgorithms(keyTypes)
+	}
+	callback := func(_ string, _ net.Addr, key ssh.PublicKey) error {
+		return verifyHostKey(db, address, keys, key)
+	}
+	return utils.HostKey(callback, algorithms), nil
+}
+
+// verifyHostKey 校验服务端提供的主机公钥
+func verifyHostKey(db *gorm.DB, address string, keys []models.SysNodeHostKey, key ssh.PublicKey) error {
+	fingerprint := ssh.FingerprintSHA256(key)
+	if len(keys) == 0 {
+		// 首次连接, 信任并保存公钥
+		err := db.Create(&models.SysNodeHostKey{
+			Address:     address,
+			KeyType:     key.Type(),
+			PublicKey:   marshalHostKey(key),
+			Fingerprint: fingerprint,
+			Source:      models.SysNodeHostKeySourceTofu,
+		}).Error
+		if !isDuplicateKeyError(err) {
+			return err
+		}
+		// 同时连接的其他请求已保存公钥, 重新读取后比较
+		if err = db.Where("address = ?", address).Order("id").Find(&keys).Error; err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return fmt.Errorf("the host key of %s can not be saved", address)
+		}
+	}
+	known := &keys[0]
+	for i := range keys {
+		if keys[i].KeyType == key.Type() {
+			if keys[i].Fingerprint == fingerprint {
+				return nil
+			}
+			known = &keys[i]
+			break
+		}
+	}
+	err := db.Model(&models.SysNodeHostKey{}).Where("id = ?", known.Id).Updates(map[string]any{
+		"pending_key":         marshalHostKey(key),
+		"pending_fingerprint": fingerprint,
+		"mismatch_at":         time.Now(),
+	}).Error
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("the host key of %s has changed, expected %s %s but got %s %s",
+		address, known.KeyType, known.Fingerprint, key.Type(), fingerprint)
+	if global.Conf.NodeConf.HostKeyMismatch == models.SysNodeHostKeyMismatchWarn {
+		global.Log.Warn(msg)
+		return nil
+	}
+	return fmt.Errorf("%s, the connection is rejected until the new key is accepted by an administrator", msg)
+}
+
+// isDuplicateKeyError 是否为违反唯一索引的错误
+func isDuplicateKeyError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
+
+func marshalHostKey(key ssh.PublicKey) string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+}
+
+// AcceptNodeHostKey 接受不一致时记录的公钥, 替换保存的公钥
+func (s *MysqlService) AcceptNodeHostKey(id uint) error {
+	var hostKey models.SysNodeHostKey
+	if err := s.TX.Where("id = ?", id).First(&hostKey).Error; err != nil {
+		return err
+	}
+	if hostKey.PendingKey == "" {
+		return fmt.Errorf("the host key of %s has no pending key to accept", hostKey.Address)
+	}
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey.PendingKey))
+	if err != nil {
+		return fmt.Errorf("the pending key is invalid: %v", err)
+	}
+	return s.TX.Model(&models.SysNodeHostKey{}).Where("id = ?", id).Updates(map[string]any{
+		"key_type":            key.Type(),
+		"public_key":          marshalHostKey(key),
+		"fingerprint":         ssh.FingerprintSHA256(key),
+		"source":              models.SysNodeHostKeySourceAccept,
+		"pending_key":         "",
+		"pending_fingerprint": "",
+		"mismatch_at":         nil,
+	}).Error
+}
+
+// DeleteNodeHostKeyByIds 删除主机公钥, 直接删除记录以便下次连接时重新保存
+func (s *MysqlService) DeleteNodeHostKeyByIds(ids []uint) error {
+	return s.TX.Unscoped().Where("id IN (?)", ids).Delete(&models.SysNodeHostKey{}).Error
+}
+
+// ImportKnownHosts 从known_hosts导入主机公钥, 已存在的同类型公钥被覆盖并清除待确认公钥
+func (s *MysqlService) ImportKnownHosts(data []byte, creator string) (*response.NodeHostKeyImportResponseStruct, error) {
+	resp := &response.NodeHostKeyImportResponseStruct{Hosts: make([]string, 0)}
+	rest := data
+	for {
+		marker, hosts, key, _, next, err := ssh.ParseKnownHosts(rest)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("the known_hosts is invalid: %v", err)
+		}
+		rest = next
+		for _, host := range hosts {
+			address := knownHostAddress(host)
+			// 证书机构及吊销标记、哈希地址及通配符无法对应到机器地址
+			if marker != "" || address == "" {
+				resp.Skipped++
+				resp.Hosts = append(resp.Hosts, host)
+				continue
+			}
+			var created, updated bool
+			created, updated, err = s.importHostKey(address, key, creator)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case created:
+				resp.Created++
+			case updated:
+				resp.Updated++
+			default:
+				resp.Skipped++
+			}
+		}
+	}
+	return resp, nil
+}
+
+// importHostKey 保存一个地址的主机公钥
+func (s *MysqlService) importHostKey(address string, key ssh.PublicKey, creator string) (created, updated bool, err error) {
+	fingerprint := ssh.FingerprintSHA256(key)
+	var hostKey models.SysNodeHostKey
+	err = s.TX.Where("address = ? AND key_type = ?", address, key.Type()).First(&hostKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = s.TX.Create(&models.SysNodeHostKey{
+			Address:     address,
+			KeyType:     key.Type(),
+			PublicKey:   marshalHostKey(key),
+			Fingerprint: fingerprint,
+			Source:      models.SysNodeHostKeySourceImport,
+			Creator:     creator,
+		}).Error
+		return err == nil, false, err
+	}
+	if err != nil || (hostKey.Fingerprint == fingerprint && hostKey.PendingKey == "") {
+		return false, false, err
+	}
+	err = s.TX.Model(&models.SysNodeHostKey{}).Where("id = ?", hostKey.Id).Updates(map[string]any{
+		"public_key":          marshalHostKey(key),
+		"fingerprint":         fingerprint,
+		"source":              models.SysNodeHostKeySourceImport,
+		"pending_key":         "",
+		"pending_fingerprint": "",
+		"mismatch_at":         nil,
+	}).Error
+	return false, err == nil, err
+}
+
+// knownHostAddress 获取known_hosts中主机对应的地址, 哈希地址、通配符及否定模式返回空
+func knownHostAddress(host string) string {
+	if strings.HasPrefix(host, "|") || strings.HasPrefix(host, "!") || strings.ContainsAny(host, "*?") {
+		return ""
+	}
+	if strings.HasPrefix(host, "[") {
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			return h
+		}
+		return strings.Trim(host, "[]")
+	}
+	return host
+}
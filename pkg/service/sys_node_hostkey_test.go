@@ -0,0 +1,131 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	tests2 "metalflow/tests"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestHostKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestVerifyHostKey(t *testing.T) {
+	mock := tests2.GetMock()
+	tests2.SetLog()
+	key := newTestHostKey(t)
+	known := models.SysNodeHostKey{KeyType: key.Type(), Fingerprint: ssh.FingerprintSHA256(key)}
+	known.Id = 1
+	rotated := models.SysNodeHostKey{KeyType: key.Type(), Fingerprint: ssh.FingerprintSHA256(newTestHostKey(t))}
+	rotated.Id = 1
+	tests := []struct {
+		name     string
+		keys     []models.SysNodeHostKey
+		mismatch string
+		invoke   func()
+		wantErr  bool
+	}{
+		{
+			name: "first use",
+			invoke: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `tb_sys_node_hostkey`").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+		{
+			name: "first use saved concurrently",
+			invoke: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `tb_sys_node_hostkey`").
+					WillReturnError(&mysql.MySQLError{Number: mysqlDuplicateEntry, Message: "Duplicate entry"})
+				mock.ExpectRollback()
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_hostkey`").WithArgs("127.0.0.1").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "address", "key_type", "fingerprint"}).
+						AddRow(1, "127.0.0.1", key.Type(), ssh.FingerprintSHA256(key)))
+			},
+			wantErr: false,
+		},
+		{
+			name:    "known key",
+			keys:    []models.SysNodeHostKey{known},
+			invoke:  func() {},
+			wantErr: false,
+		},
+		{
+			name:     "mismatch rejected",
+			keys:     []models.SysNodeHostKey{rotated},
+			mismatch: models.SysNodeHostKeyMismatchReject,
+			invoke: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `tb_sys_node_hostkey`").
+					WithArgs(tests2.AnyTime{}, ssh.FingerprintSHA256(key), marshalHostKey(key), tests2.AnyTime{}, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: true,
+		},
+		{
+			name:     "mismatch warned",
+			keys:     []models.SysNodeHostKey{rotated},
+			mismatch: models.SysNodeHostKeyMismatchWarn,
+			invoke: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `tb_sys_node_hostkey`").
+					WithArgs(tests2.AnyTime{}, ssh.FingerprintSHA256(key), marshalHostKey(key), tests2.AnyTime{}, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			global.Conf.NodeConf.HostKeyMismatch = tt.mismatch
+			tt.invoke()
+			if err := verifyHostKey(global.Mysql, "127.0.0.1", tt.keys, key); (err != nil) != tt.wantErr {
+				t.Errorf("verifyHostKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("verifyHostKey() %v", err)
+			}
+		})
+	}
+}
+
+func TestKnownHostAddress(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "10.12.0.1", want: "10.12.0.1"},
+		{host: "[10.12.0.1]:2222", want: "10.12.0.1"},
+		{host: "[fd00::1]:22", want: "fd00::1"},
+		{host: "|1|JfKTdBh7rNbXkVAQCRp4OQoPfmI=|USECr3SWf1JUPsms5AqfD5QfxkM=", want: ""},
+		{host: "10.12.*", want: ""},
+		{host: "!10.12.0.2", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := knownHostAddress(tt.host); got != tt.want {
+				t.Errorf("knownHostAddress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"metalflow/models"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/utils"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	NodeFileFormatCsv  = "csv"
+	NodeFileFormatYaml = "yaml"
+
+	nodeImportActionCreate = "create"
+	nodeImportActionUpdate = "update"
+	nodeImportActionError  = "error"
+
+	// csv中多个标签的分隔符
+	nodeCsvLabelSep = ";"
+)
+
+// csv文件的列, 与NodeRecordStruct的json名称一致
+var nodeCsvHeader = []string{"address", "sshPort", "servicePort", "os", "manager", "asset", "remark", "labels"}
+
+// nodeImportRow 待导入的一行机器信息
+type nodeImportRow struct {
+	row    int
+	record request.NodeRecordStruct
+	errs   []string
+}
+
+// GetNodeFileFormat 获取导入导出的文件格式, 未指定时根据文件名后缀判断
+func GetNodeFileFormat(format, filename string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	}
+	switch format {
+	case NodeFileFormatCsv:
+		return NodeFileFormatCsv, nil
+	case NodeFileFormatYaml, "yml":
+		return NodeFileFormatYaml, nil
+	}
+	return "", fmt.Errorf("unsupported file format [%s], only csv and yaml are supported", format)
+}
+
+// parseNodeRows 解析导入文件, 单元格格式错误记录在对应行中
+func parseNodeRows(format string, data []byte) ([]nodeImportRow, error) {
+	if format == NodeFileFormatYaml {
+		records := make([]request.NodeRecordStruct, 0)
+		if err := yaml.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("failed to parse yaml: %v", err)
+		}
+		rows := make([]nodeImportRow, 0, len(records))
+		for i, record := range records { //nolint:gocritic
+			rows = append(rows, nodeImportRow{row: i + 1, record: record})
+		}
+		return rows, nil
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		// 去掉excel导出csv时可能带有的BOM
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if !utils.Contains(nodeCsvHeader, name) {
+			return nil, fmt.Errorf("unknown csv column [%s], supported columns: %s", name, strings.Join(nodeCsvHeader, ","))
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["address"]; !ok {
+		return nil, fmt.Errorf("the csv column [address] is required")
+	}
+	rows := make([]nodeImportRow, 0)
+	// 第一行为表头, 数据从第二行开始
+	for line := 2; ; line++ {
+		cells, e := reader.Read()
+		if errors.Is(e, io.EOF) {
+			break
+		}
+		row := nodeImportRow{row: line}
+		if e != nil {
+			row.errs = append(row.errs, e.Error())
+			rows = append(rows, row)
+			continue
+		}
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(cells) {
+				return strings.TrimSpace(cells[i])
+			}
+			return ""
+		}
+		row.record = request.NodeRecordStruct{
+			Address: get("address"),
+			Os:      get("os"),
+			Manager: get("manager"),
+			Asset:   get("asset"),
+			Remark:  get("remark"),
+		}
+		if v := get("sshPort"); v != "" {
+			port, pe := strconv.ParseUint(v, 10, 16)
+			if pe != nil {
+				row.errs = append(row.errs, fmt.Sprintf("invalid sshPort [%s]", v))
+			}
+			row.record.SshPort = uint(port)
+		}
+		if v := get("servicePort"); v != "" {
+			port, pe := strconv.ParseUint(v, 10, 16)
+			if pe != nil {
+				row.errs = append(row.errs, fmt.Sprintf("invalid servicePort [%s]", v))
+			}
+			row.record.ServicePort = int(port)
+		}
+		for _, label := range strings.Split(get("labels"), nodeCsvLabelSep) {
+			if label = strings.TrimSpace(label); label != "" {
+				row.record.Labels = append(row.record.Labels, label)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ImportNodes 批量导入机器, 已存在的机器按地址更新非空字段; 任一行校验失败或预览时不写入数据库
+func (s *MysqlService) ImportNodes(format string, data []byte, dryRun bool, creator string) (
+	*response.NodeImportResponseStruct, error) {
+	rows, err := parseNodeRows(format, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("the import file has no nodes")
+	}
+
+	denyList, err := getNodeDenyList(s.TX)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, 0, len(rows))
+	for _, row := range rows { //nolint:gocritic
+		addresses = append(addresses, strings.TrimSpace(row.record.Address))
+	}
+	existing := make([]models.SysNode, 0)
+	if err = s.TX.Model(&models.SysNode{}).Where("address IN (?)", addresses).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	existingNodes := make(map[string]models.SysNode, len(existing))
+	for _, node := range existing { //nolint:gocritic
+		existingNodes[node.Address] = node
+	}
+
+	resp := &response.NodeImportResponseStruct{DryRun: dryRun, Total: len(rows)}
+	seen := make(map[string]int)
+	for i := range rows {
+		row := &rows[i]
+		_, exists := existingNodes[strings.TrimSpace(row.record.Address)]
+		row.errs = append(row.errs, validateNodeRecord(&row.record, exists, denyList)...)
+		if first, ok := seen[row.record.Address]; ok && row.record.Address != "" {
+			row.errs = append(row.errs, fmt.Sprintf("duplicate address, first defined in row %d", first))
+		} else {
+			seen[row.record.Address] = row.row
+		}
+		item := response.NodeImportRowResponseStruct{Row: row.row, Address: row.record.Address, Errors: row.errs}
+		switch {
+		case len(row.errs) > 0:
+			item.Action = nodeImportActionError
+			resp.Failed++
+		case exists:
+			item.Action = nodeImportActionUpdate
+			resp.Updated++
+		default:
+			item.Action = nodeImportActionCreate
+			resp.Created++
+		}
+		resp.Rows = append(resp.Rows, item)
+	}
+	if dryRun || resp.Failed > 0 {
+		return resp, nil
+	}
+
+	for i := range rows {
+		node, exists := existingNodes[rows[i].record.Address]
+		if err = s.upsertNodeRecord(&rows[i].record, &node, exists, creator); err != nil {
+			return nil, fmt.Errorf("row %d [%s] import failed: %v", rows[i].row, rows[i].record.Address, err)
+		}
+	}
+	return resp, nil
+}
+
+// validateNodeRecord 校验导入的一行机器信息, 并清理首尾空格
+func validateNodeRecord(record *request.NodeRecordStruct, exists bool, denyList NodeDenyList) []string {
+	errs := make([]string, 0)
+	record.Address = strings.TrimSpace(record.Address)
+	record.Os = strings.TrimSpace(record.Os)
+	if record.Address == "" {
+		errs = append(errs, "address is required")
+	} else if net.ParseIP(record.Address) == nil {
+		errs = append(errs, fmt.Sprintf("invalid address [%s]", record.Address))
+	}
+	if err := denyList.CheckBlocked(record.Address); err != nil && record.Address != "" {
+		errs = append(errs, err.Error())
+	}
+	if !exists && record.Os == "" {
+		errs = append(errs, "os is required for new nodes")
+	}
+	for _, label := range record.Labels {
+		if err := utils.ValidateLabel(utils.ParseLabel(label)); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	return errs
+}
+
+// upsertNodeRecord 创建机器或更新已有机器的非空字段, 传了标签时替换机器的标签
+func (s *MysqlService) upsertNodeRecord(record *request.NodeRecordStruct, node *models.SysNode, exists bool, creator string) error {
+	labels, err := s.findOrCreateLabels(record.Labels, creator)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		*node = models.SysNode{
+			Address:     record.Address,
+			SshPort:     record.SshPort,
+			ServicePort: record.ServicePort,
+			Os:          record.Os,
+			Manager:     record.Manager,
+			Asset:       record.Asset,
+			Remark:      record.Remark,
+			Region:      getRegionByIp(s.TX, record.Address),
+			Creator:     creator,
+			Labels:      labels,
+		}
+		return s.TX.Create(node).Error
+	}
+	updates := make(map[string]any)
+	if record.SshPort > 0 {
+		updates["ssh_port"] = record.SshPort
+	}
+	if record.ServicePort > 0 {
+		updates["service_port"] = record.ServicePort
+	}
+	for column, value := range map[string]string{
+		"os": record.Os, "manager": record.Manager, "asset": record.Asset, "remark": record.Remark,
+	} {
+		if value != "" {
+			updates[column] = value
+		}
+	}
+	if len(updates) > 0 {
+		if err = s.TX.Model(node).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	if len(labels) > 0 {
+		return s.TX.Model(node).Association("Labels").Replace(labels)
+	}
+	return nil
+}
+
+// ExportNodes 按机器列表的筛选条件导出所有机器
+func (s *MysqlService) ExportNodes(req *request.NodeExportRequestStruct) ([]byte, error) {
+	format := NodeFileFormatCsv
+	if req.Format != "" {
+		var err error
+		if format, err = GetNodeFileFormat(req.Format, ""); err != nil {
+			return nil, err
+		}
+	}
+	req.NoPagination = true
+	nodes, err := s.GetNodes(&req.NodeListRequestStruct)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]request.NodeRecordStruct, 0, len(nodes))
+	for _, node := range nodes { //nolint:gocritic
+		record := request.NodeRecordStruct{
+			Address:     node.Address,
+			SshPort:     node.SshPort,
+			ServicePort: node.ServicePort,
+			Os:          node.Os,
+			Manager:     node.Manager,
+			Asset:       node.Asset,
+			Remark:      node.Remark,
+		}
+		for _, label := range node.Labels { //nolint:gocritic
+			record.Labels = append(record.Labels, utils.FormatLabel(label.Key, label.Value))
+		}
+		records = append(records, record)
+	}
+	if format == NodeFileFormatYaml {
+		return yaml.Marshal(records)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write(nodeCsvHeader)
+	for _, r := range records { //nolint:gocritic
+		_ = writer.Write([]string{
+			r.Address,
+			strconv.FormatUint(uint64(r.SshPort), 10),
+			strconv.Itoa(r.ServicePort),
+			r.Os,
+			r.Manager,
+			r.Asset,
+			r.Remark,
+			strings.Join(r.Labels, nodeCsvLabelSep),
+		})
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"metalflow/pkg/request"
+	tests2 "metalflow/tests"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestParseNodeRows(t *testing.T) {
+	csvData := "address,sshPort,os,manager,labels\n" +
+		"10.1.1.1,22,linux,tester,env=ci;arch=x86\n" +
+		"10.1.1.2,abc,linux,,\n"
+	rows, err := parseNodeRows(NodeFileFormatCsv, []byte(csvData))
+	if err != nil {
+		t.Fatalf("parseNodeRows() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("parseNodeRows() got %d rows, want 2", len(rows))
+	}
+	want := request.NodeRecordStruct{
+		Address: "10.1.1.1",
+		SshPort: 22,
+		Os:      "linux",
+		Manager: "tester",
+		Labels:  []string{"env=ci", "arch=x86"},
+	}
+	if !reflect.DeepEqual(rows[0].record, want) || rows[0].row != 2 {
+		t.Errorf("parseNodeRows() first row = %+v, want %+v", rows[0].record, want)
+	}
+	if len(rows[1].errs) != 1 {
+		t.Errorf("parseNodeRows() second row errors = %v, want invalid sshPort", rows[1].errs)
+	}
+
+	yamlData := "- address: 10.1.1.3\n  os: linux\n  labels: [env=ci]\n"
+	rows, err = parseNodeRows(NodeFileFormatYaml, []byte(yamlData))
+	if err != nil || len(rows) != 1 || rows[0].record.Labels[0] != "env=ci" {
+		t.Errorf("parseNodeRows() yaml = %+v, error = %v", rows, err)
+	}
+
+	if _, err = parseNodeRows(NodeFileFormatCsv, []byte("host,os\n")); err == nil {
+		t.Errorf("parseNodeRows() with unknown column should fail")
+	}
+}
+
+func TestMysqlService_ImportNodes(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	csvData := "address,sshPort,os\n" +
+		"10.1.1.1,22,\n" +
+		"10.1.1.2,22,linux\n" +
+		"10.1.1.2,22,linux\n" +
+		"bad,22,linux\n"
+	mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
+	mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "address"}).AddRow(1, "10.1.1.1"))
+	resp, err := s.ImportNodes(NodeFileFormatCsv, []byte(csvData), true, "tester")
+	if err != nil {
+		t.Fatalf("ImportNodes() error = %v", err)
+	}
+	actions := make([]string, 0)
+	for _, row := range resp.Rows {
+		actions = append(actions, row.Action)
+	}
+	want := []string{nodeImportActionUpdate, nodeImportActionCreate, nodeImportActionError, nodeImportActionError}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("ImportNodes() actions = %v, want %v", actions, want)
+	}
+	if resp.Total != 4 || resp.Created != 1 || resp.Updated != 1 || resp.Failed != 2 {
+		t.Errorf("ImportNodes() summary = %+v", resp)
+	}
+}
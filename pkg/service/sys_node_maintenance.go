@@ -0,0 +1,65 @@
+package service
+
+import (
+	"fmt"
+	"metalflow/models"
+	"metalflow/pkg/request"
+	"strings"
+	"time"
+)
+
+// whereInMaintenance 维护中且未过期的机器
+func whereInMaintenance() (string, []any) {
+	return "maintenance = ? AND (maintenance_expire IS NULL OR maintenance_expire > ?)",
+		[]any{models.SysNodeMaintenanceOn, time.Now()}
+}
+
+// EnterMaintenance 机器进入维护模式
+func (s *MysqlService) EnterMaintenance(ids []uint, req *request.NodeMaintenanceRequestStruct) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("no node is selected")
+	}
+	var expire models.LocalTime
+	if req.ExpireTime != "" {
+		expire = *new(models.LocalTime).SetString(req.ExpireTime)
+		if expire.IsZero() || !expire.After(time.Now()) {
+			return fmt.Errorf("the expire time [%s] is incorrect", req.ExpireTime)
+		}
+	}
+	return s.TX.Model(&models.SysNode{}).Where("id IN (?)", ids).Updates(map[string]any{
+		"maintenance":        models.SysNodeMaintenanceOn,
+		"maintenance_reason": req.Reason,
+		"maintenance_owner":  req.Owner,
+		"maintenance_expire": expire,
+	}).Error
+}
+
+// ExitMaintenance 机器退出维护模式
+func (s *MysqlService) ExitMaintenance(ids []uint) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("no node is selected")
+	}
+	return s.TX.Model(&models.SysNode{}).Where("id IN (?)", ids).Updates(map[string]any{
+		"maintenance":        models.SysNodeMaintenanceOff,
+		"maintenance_reason": "",
+		"maintenance_owner":  "",
+		"maintenance_expire": models.LocalTime{},
+	}).Error
+}
+
+// checkMaintenance 检查机器是否在维护中, 维护中的机器需强制才能批量操作
+func (s *MysqlService) checkMaintenance(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	cond, args := whereInMaintenance()
+	addresses := make([]string, 0)
+	err := s.TX.Model(&models.SysNode{}).Where("id IN (?)", ids).Where(cond, args...).Pluck("address", &addresses).Error
+	if err != nil {
+		return err
+	}
+	if len(addresses) > 0 {
+		return fmt.Errorf("nodes [%s] are in maintenance, please use force to operate them", strings.Join(addresses, ","))
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"fmt"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/grpc"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"time"
+)
+
+const (
+	// 未指定时间范围时默认查询最近一天
+	defaultMetricsHistoryRange = 24 * time.Hour
+	// 未指定采样间隔时, 返回的数据点不超过该数量
+	maxMetricsHistoryPoints = 200
+)
+
+// saveMetricsSample 保存一条机器节点metrics采样
+func saveMetricsSample(nodeId uint, metric *grpc.Metric) error {
+	sample := &models.SysNodeMetricsSample{
+		NodeId:    nodeId,
+		Cpu:       metric.Cpu,
+		Ram:       metric.Ram,
+		Disk:      metric.Disk,
+		Io:        metric.Io,
+		CpuUsage:  grpc.GetMetricUsage(metric.Cpu),
+		RamUsage:  grpc.GetMetricUsage(metric.Ram),
+		DiskUsage: grpc.GetMetricUsage(metric.Disk),
+		IoUsage:   grpc.GetMetricUsage(metric.Io),
+	}
+	return global.Mysql.Model(&models.SysNodeMetricsSample{}).Create(sample).Error
+}
+
+// GetNodeMetricsHistory 获取机器节点指定时间范围内的metrics历史, 并按采样间隔降采样
+func (s *MysqlService) GetNodeMetricsHistory(nodeId uint, req *request.NodeMetricsHistoryRequestStruct) (
+	[]response.NodeMetricsHistoryItemResponseStruct, error) {
+	end := time.Now()
+	if req.EndTime != "" {
+		end = new(models.LocalTime).SetString(req.EndTime).Time
+	}
+	start := end.Add(-defaultMetricsHistoryRange)
+	if req.StartTime != "" {
+		start = new(models.LocalTime).SetString(req.StartTime).Time
+	}
+	if start.IsZero() || end.IsZero() || !start.Before(end) {
+		return nil, fmt.Errorf("the time range is incorrect")
+	}
+
+	samples := make([]models.SysNodeMetricsSample, 0)
+	err := s.TX.Model(&models.SysNodeMetricsSample{}).
+		Where("node_id = ? AND created_at BETWEEN ? AND ?", nodeId, start, end).
+		Order("created_at ASC").
+		Find(&samples).Error
+	if err != nil {
+		return nil, err
+	}
+
+	interval := time.Duration(req.Interval) * time.Second
+	if interval <= 0 {
+		interval = end.Sub(start) / maxMetricsHistoryPoints
+	}
+	return downsampleMetrics(samples, start, interval), nil
+}
+
+// downsampleMetrics 将采样按时间间隔分桶, 每个桶取平均值
+func downsampleMetrics(samples []models.SysNodeMetricsSample, start time.Time,
+	interval time.Duration) []response.NodeMetricsHistoryItemResponseStruct {
+	items := make([]response.NodeMetricsHistoryItemResponseStruct, 0)
+	if interval <= 0 {
+		interval = time.Second
+	}
+	var (
+		current response.NodeMetricsHistoryItemResponseStruct
+		bucket  int64 = -1
+		count   float64
+	)
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		current.CpuUsage /= count
+		current.RamUsage /= count
+		current.DiskUsage /= count
+		current.IoUsage /= count
+		current.Count = uint(count)
+		items = append(items, current)
+	}
+	for _, sample := range samples { //nolint:gocritic
+		b := int64(sample.CreatedAt.Sub(start) / interval)
+		if b != bucket {
+			flush()
+			bucket = b
+			count = 0
+			current = response.NodeMetricsHistoryItemResponseStruct{
+				Time: models.LocalTime{Time: start.Add(time.Duration(b) * interval)},
+			}
+		}
+		current.CpuUsage += sample.CpuUsage
+		current.RamUsage += sample.RamUsage
+		current.DiskUsage += sample.DiskUsage
+		current.IoUsage += sample.IoUsage
+		count++
+	}
+	flush()
+	return items
+}
+
+// CleanExpiredMetricsSamples 按保留天数物理删除过期的metrics采样
+func CleanExpiredMetricsSamples(retentionDays int) (int64, error) {
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+	deadline := time.Now().AddDate(0, 0, -retentionDays)
+	query := global.Mysql.Unscoped().Where("created_at < ?", deadline).Delete(&models.SysNodeMetricsSample{})
+	return query.RowsAffected, query.Error
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"metalflow/models"
+	"metalflow/pkg/request"
+	tests2 "metalflow/tests"
+	"testing"
+	"time"
+)
+
+func TestDownsampleMetrics(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local)
+	newSample := func(offset time.Duration, cpu, ram float64) models.SysNodeMetricsSample {
+		return models.SysNodeMetricsSample{
+			Model:    models.Model{CreatedAt: models.LocalTime{Time: start.Add(offset)}},
+			CpuUsage: cpu,
+			RamUsage: ram,
+		}
+	}
+	samples := []models.SysNodeMetricsSample{
+		newSample(time.Minute, 10, 20),
+		newSample(2*time.Minute, 30, 40),
+		newSample(11*time.Minute, 50, 60),
+	}
+	items := downsampleMetrics(samples, start, 10*time.Minute)
+	if len(items) != 2 {
+		t.Fatalf("downsampleMetrics() got %d items, want 2", len(items))
+	}
+	if items[0].CpuUsage != 20 || items[0].RamUsage != 30 || items[0].Count != 2 {
+		t.Errorf("downsampleMetrics() first bucket = %+v", items[0])
+	}
+	if !items[1].Time.Equal(start.Add(10*time.Minute)) || items[1].CpuUsage != 50 || items[1].Count != 1 {
+		t.Errorf("downsampleMetrics() second bucket = %+v", items[1])
+	}
+	if got := downsampleMetrics(nil, start, time.Minute); len(got) != 0 {
+		t.Errorf("downsampleMetrics() with no samples = %v, want empty", got)
+	}
+}
+
+func TestMysqlService_GetNodeMetricsHistory(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	type args struct {
+		nodeId uint
+		req    *request.NodeMetricsHistoryRequestStruct
+	}
+	tests := []struct {
+		name    string
+		s       *MysqlService
+		args    args
+		invoke  func(args2 args)
+		wantErr bool
+	}{
+		{
+			name: "fail1",
+			s:    &s,
+			args: args{
+				nodeId: 1,
+				req: &request.NodeMetricsHistoryRequestStruct{
+					StartTime: "2023-01-02 00:00:00",
+					EndTime:   "2023-01-01 00:00:00",
+				},
+			},
+			invoke:  func(args2 args) {},
+			wantErr: true,
+		},
+		{
+			name: "fail2",
+			s:    &s,
+			args: args{nodeId: 1, req: &request.NodeMetricsHistoryRequestStruct{}},
+			invoke: func(args2 args) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_metrics_sample`").
+					WithArgs(args2.nodeId, tests2.AnyTime{}, tests2.AnyTime{}).
+					WillReturnError(errors.New("DB error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "success",
+			s:    &s,
+			args: args{nodeId: 1, req: &request.NodeMetricsHistoryRequestStruct{Interval: 60}},
+			invoke: func(args2 args) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_metrics_sample`").
+					WithArgs(args2.nodeId, tests2.AnyTime{}, tests2.AnyTime{}).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "cpu_usage"}).AddRow(1, 12.5))
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke(tt.args)
+			if _, err := tt.s.GetNodeMetricsHistory(tt.args.nodeId, tt.args.req); (err != nil) != tt.wantErr {
+				t.Errorf("GetNodeMetricsHistory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
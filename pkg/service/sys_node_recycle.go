@@ -0,0 +1,156 @@
+package service
+
+import (
+	"fmt"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"metalflow/pkg/utils"
+	"strings"
+	"time"
+)
+
+// 删除机器时地址重写为"时间|D|原地址", 避免软删除与唯一索引冲突
+const nodeDeletedAddressSep = "|D|"
+
+// getDeletedAddress 删除机器时重写后的地址
+func getDeletedAddress(address string) string {
+	return fmt.Sprintf("%s%s%s", time.Now().Format(global.MsecLocalTimeFormat), nodeDeletedAddressSep, address)
+}
+
+// GetOriginalAddress 从删除时重写的地址中还原原始地址
+func GetOriginalAddress(address string) string {
+	if i := strings.Index(address, nodeDeletedAddressSep); i >= 0 {
+		return address[i+len(nodeDeletedAddressSep):]
+	}
+	return address
+}
+
+// GetRecycledNodes 获取回收站中的机器
+func (s *MysqlService) GetRecycledNodes(req *request.NodeRecycleListRequestStruct) ([]response.NodeRecycleResponseStruct, error) {
+	nodes := make([]models.SysNode, 0)
+	query := s.TX.
+		Unscoped().
+		Model(&models.SysNode{}).
+		Preload("Labels").
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC")
+	address := strings.TrimSpace(req.Address)
+	if address != "" {
+		query = query.Where("address LIKE ?", fmt.Sprintf("%%%s%s%%", nodeDeletedAddressSep, address))
+	}
+	creator := strings.TrimSpace(req.Creator)
+	if creator != "" {
+		query = query.Where("creator LIKE ?", fmt.Sprintf("%%%s%%", creator))
+	}
+	if err := s.Find(query, &req.PageInfo, &nodes); err != nil {
+		return nil, err
+	}
+
+	list := make([]response.NodeRecycleResponseStruct, 0, len(nodes))
+	addresses := make([]string, 0, len(nodes))
+	for _, node := range nodes { //nolint:gocritic
+		addresses = append(addresses, GetOriginalAddress(node.Address))
+	}
+	// 原地址被重新注册的机器无法直接恢复
+	conflicts := make([]string, 0)
+	if len(addresses) > 0 {
+		err := s.TX.Model(&models.SysNode{}).Where("address IN (?)", addresses).Pluck("address", &conflicts).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i, node := range nodes { //nolint:gocritic
+		list = append(list, response.NodeRecycleResponseStruct{
+			Id:             node.Id,
+			Address:        addresses[i],
+			DeletedAddress: node.Address,
+			Manager:        node.Manager,
+			Region:         node.Region,
+			Creator:        node.Creator,
+			Labels:         node.Labels,
+			DeletedAt:      node.DeletedAt,
+			Conflict:       utils.Contains(conflicts, addresses[i]),
+		})
+	}
+	return list, nil
+}
+
+// getRecycledNodes 根据编号获取回收站中的机器
+func (s *MysqlService) getRecycledNodes(ids []uint) ([]models.SysNode, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no node is selected")
+	}
+	nodes := make([]models.SysNode, 0)
+	err := s.TX.Unscoped().Where("id IN (?) AND deleted_at IS NOT NULL", ids).Find(&nodes).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) != len(ids) {
+		return nil, fmt.Errorf("some nodes are not in the recycle bin")
+	}
+	return nodes, nil
+}
+
+// RestoreNodeByIds 从回收站恢复机器
+// 删除时保留了标签/worker/收藏以及调优与安全记录, 恢复地址与删除时间后即可一并恢复
+func (s *MysqlService) RestoreNodeByIds(ids []uint) error {
+	nodes, err := s.getRecycledNodes(ids)
+	if err != nil {
+		return err
+	}
+	restored := make([]string, 0, len(nodes))
+	for _, node := range nodes { //nolint:gocritic
+		address := GetOriginalAddress(node.Address)
+		// 同一批中或已存在的机器占用了原地址
+		if utils.Contains(restored, address) {
+			return fmt.Errorf("the address %s is restored more than once", address)
+		}
+		var count int64
+		err = s.TX.Model(&models.SysNode{}).Where("address = ?", address).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("the address %s is already used by another node", address)
+		}
+		err = s.TX.Unscoped().Model(&models.SysNode{}).Where("id = ?", node.Id).Updates(map[string]any{
+			"address":    address,
+			"deleted_at": nil,
+		}).Error
+		if err != nil {
+			return err
+		}
+		restored = append(restored, address)
+	}
+	return nil
+}
+
+// PurgeNodeByIds 彻底删除回收站中的机器及其关联数据
+func (s *MysqlService) PurgeNodeByIds(ids []uint) error {
+	if _, err := s.getRecycledNodes(ids); err != nil {
+		return err
+	}
+	relations := []struct {
+		model  any
+		column string
+	}{
+		{&models.RelationNodeLabel{}, "sys_node_id"},
+		{&models.RelationNodeWorker{}, "sys_node_id"},
+		{&models.RelationNodeShut{}, "sys_node_id"},
+		{&models.SysCollection{}, "node_id"},
+		{&models.SysNodeSecure{}, "node_id"},
+		{&models.SysNodeTuneLog{}, "node_id"},
+		{&models.SysNodeChange{}, "node_id"},
+		{&models.SysNodeMetricsSample{}, "node_id"},
+		{&models.SysNodeReservation{}, "node_id"},
+	}
+	for _, relation := range relations {
+		err := s.TX.Unscoped().Where(fmt.Sprintf("%s IN (?)", relation.column), ids).Delete(relation.model).Error
+		if err != nil {
+			return err
+		}
+	}
+	return s.TX.Unscoped().Where("id IN (?)", ids).Delete(&models.SysNode{}).Error
+}
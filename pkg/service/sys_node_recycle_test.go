@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	tests2 "metalflow/tests"
+	"testing"
+)
+
+func TestGetOriginalAddress(t *testing.T) {
+	tests := map[string]string{
+		"2023-01-01 00:00:00.000|D|10.1.1.1": "10.1.1.1",
+		getDeletedAddress("10.1.1.2"):        "10.1.1.2",
+		"10.1.1.3":                           "10.1.1.3",
+	}
+	for address, want := range tests {
+		if got := GetOriginalAddress(address); got != want {
+			t.Errorf("GetOriginalAddress(%s) = %s, want %s", address, got, want)
+		}
+	}
+}
+
+func TestMysqlService_RestoreNodeByIds(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	deletedAddress := "2023-01-01 00:00:00.000|D|10.1.1.1"
+	type args struct {
+		ids []uint
+	}
+	tests := []struct {
+		name    string
+		s       *MysqlService
+		args    args
+		invoke  func(args2 args)
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			s:       &s,
+			args:    args{ids: []uint{}},
+			invoke:  func(args2 args) {},
+			wantErr: true,
+		},
+		{
+			name: "not deleted",
+			s:    &s,
+			args: args{ids: []uint{1}},
+			invoke: func(args2 args) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node` WHERE id IN (.*) AND deleted_at IS NOT NULL").
+					WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: true,
+		},
+		{
+			name: "conflict",
+			s:    &s,
+			args: args{ids: []uint{1}},
+			invoke: func(args2 args) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node` WHERE id IN (.*) AND deleted_at IS NOT NULL").
+					WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "address"}).AddRow(1, deletedAddress))
+				mock.ExpectQuery("SELECT count(.*) FROM `tb_sys_node`").
+					WithArgs("10.1.1.1").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail",
+			s:    &s,
+			args: args{ids: []uint{1}},
+			invoke: func(args2 args) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node` WHERE id IN (.*) AND deleted_at IS NOT NULL").
+					WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "address"}).AddRow(1, deletedAddress))
+				mock.ExpectQuery("SELECT count(.*) FROM `tb_sys_node`").
+					WithArgs("10.1.1.1").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `tb_sys_node`").
+					WithArgs("10.1.1.1", nil, tests2.AnyTime{}, 1).WillReturnError(errors.New("DB error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "success",
+			s:    &s,
+			args: args{ids: []uint{1}},
+			invoke: func(args2 args) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node` WHERE id IN (.*) AND deleted_at IS NOT NULL").
+					WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "address"}).AddRow(1, deletedAddress))
+				mock.ExpectQuery("SELECT count(.*) FROM `tb_sys_node`").
+					WithArgs("10.1.1.1").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `tb_sys_node`").
+					WithArgs("10.1.1.1", nil, tests2.AnyTime{}, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke(tt.args)
+			if err := tt.s.RestoreNodeByIds(tt.args.ids); (err != nil) != tt.wantErr {
+				t.Errorf("RestoreNodeByIds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
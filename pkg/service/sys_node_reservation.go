@@ -0,0 +1,217 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"metalflow/models"
+	"metalflow/pkg/async"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 到期自动释放预约时记录的释放人
+const reservationSystemReleaser = "system"
+
+// GetNodeReservations 获取机器预约列表
+func (s *MysqlService) GetNodeReservations(req *request.NodeReservationListRequestStruct) (
+	[]models.SysNodeReservation, error) {
+	list := make([]models.SysNodeReservation, 0)
+	query := s.TX.Model(&models.SysNodeReservation{}).Order("start_time DESC")
+	if req.NodeId > 0 {
+		query = query.Where("node_id = ?", req.NodeId)
+	}
+	address := strings.TrimSpace(req.Address)
+	if address != "" {
+		query = query.Where("address LIKE ?", fmt.Sprintf("%%%s%%", address))
+	}
+	username := strings.TrimSpace(req.Username)
+	if username != "" {
+		query = query.Where("username = ?", username)
+	}
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+	err := s.Find(query, &req.PageInfo, &list)
+	return list, err
+}
+
+// CreateNodeReservations 为当前用户预约一批机器, 与已有的有效预约时间段重叠时拒绝
+func (s *MysqlService) CreateNodeReservations(username string, ids []uint,
+	req *request.CreateNodeReservationRequestStruct) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("no node is selected")
+	}
+	start := time.Now()
+	if req.StartTime != "" {
+		start = new(models.LocalTime).SetString(req.StartTime).Time
+	}
+	end := new(models.LocalTime).SetString(req.EndTime).Time
+	if start.IsZero() || end.IsZero() || !start.Before(end) || !end.After(time.Now()) {
+		return fmt.Errorf("the reservation time range is incorrect")
+	}
+	nodes, err := s.lockReservationNodes(ids)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("no node is selected")
+	}
+	if err = s.checkReservationConflict(ids, start, end, 0); err != nil {
+		return err
+	}
+	reservations := make([]models.SysNodeReservation, 0, len(nodes))
+	for _, node := range nodes { //nolint:gocritic
+		reservations = append(reservations, models.SysNodeReservation{
+			NodeId:    node.Id,
+			Address:   node.Address,
+			Username:  username,
+			Purpose:   req.Purpose,
+			StartTime: models.LocalTime{Time: start},
+			EndTime:   models.LocalTime{Time: end},
+		})
+	}
+	return s.TX.Create(&reservations).Error
+}
+
+// lockReservationNodes 在事务中锁定需要预约的机器, 同时预约同一机器的请求依次检查冲突, 按id排序避免死锁
+func (s *MysqlService) lockReservationNodes(ids []uint) ([]models.SysNode, error) {
+	nodes := make([]models.SysNode, 0)
+	err := s.TX.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&models.SysNode{}).
+		Where("id IN (?)", ids).Order("id").Find(&nodes).Error
+	return nodes, err
+}
+
+// checkReservationConflict 检查机器在时间段内是否已被预约, excludeId为续期时需排除的预约
+func (s *MysqlService) checkReservationConflict(ids []uint, start, end time.Time, excludeId uint) error {
+	conflicts := make([]models.SysNodeReservation, 0)
+	query := s.TX.Model(&models.SysNodeReservation{}).
+		Where("node_id IN (?) AND status = ? AND start_time < ? AND end_time > ?",
+			ids, models.SysNodeReservationActive, end, start)
+	if excludeId > 0 {
+		query = query.Where("id != ?", excludeId)
+	}
+	if err := query.Find(&conflicts).Error; err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		msgs := make([]string, 0, len(conflicts))
+		for _, c := range conflicts { //nolint:gocritic
+			msgs = append(msgs, fmt.Sprintf("%s is reserved by %s from %s to %s", c.Address, c.Username, c.StartTime, c.EndTime))
+		}
+		return fmt.Errorf("reservation conflicts: %s", strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// getActiveReservation 获取有效的预约, 只有预约人或超级管理员可以操作
+func (s *MysqlService) getActiveReservation(id uint, user *models.SysUser) (models.SysNodeReservation, error) {
+	var reservation models.SysNodeReservation
+	err := s.TX.Where("id = ?", id).First(&reservation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return reservation, fmt.Errorf("reservation does not exist")
+	} else if err != nil {
+		return reservation, err
+	}
+	if reservation.Status != nil && *reservation.Status != models.SysNodeReservationActive {
+		return reservation, fmt.Errorf("reservation has been released")
+	}
+	if reservation.Username != user.Username && user.Role.Keyword != "super" {
+		return reservation, fmt.Errorf("reservation is held by %s", reservation.Username)
+	}
+	return reservation, nil
+}
+
+// RenewNodeReservation 续期机器预约
+func (s *MysqlService) RenewNodeReservation(id uint, user *models.SysUser, req *request.RenewNodeReservationRequestStruct) error {
+	reservation, err := s.getActiveReservation(id, user)
+	if err != nil {
+		return err
+	}
+	end := new(models.LocalTime).SetString(req.EndTime).Time
+	if end.IsZero() || !end.After(reservation.StartTime.Time) || !end.After(time.Now()) {
+		return fmt.Errorf("the end time [%s] is incorrect", req.EndTime)
+	}
+	if _, err = s.lockReservationNodes([]uint{reservation.NodeId}); err != nil {
+		return err
+	}
+	err = s.checkReservationConflict([]uint{reservation.NodeId}, reservation.StartTime.Time, end, reservation.Id)
+	if err != nil {
+		return err
+	}
+	// 续期后重新发送到期提醒
+	return s.TX.Model(&reservation).Updates(map[string]any{
+		"end_time": models.LocalTime{Time: end},
+		"reminded": 0,
+	}).Error
+}
+
+// ReleaseNodeReservation 提前释放机器预约
+func (s *MysqlService) ReleaseNodeReservation(id uint, user *models.SysUser) error {
+	reservation, err := s.getActiveReservation(id, user)
+	if err != nil {
+		return err
+	}
+	return s.TX.Model(&reservation).Updates(map[string]any{
+		"status":      models.SysNodeReservationReleased,
+		"released_by": user.Username,
+	}).Error
+}
+
+// RemindAndReleaseReservations 发送即将到期的预约提醒, 并自动释放已到期的预约
+func RemindAndReleaseReservations(remindBefore time.Duration) (reminded, released int, err error) {
+	now := time.Now()
+	expired := make([]models.SysNodeReservation, 0)
+	err = global.Mysql.Model(&models.SysNodeReservation{}).
+		Where("status = ? AND end_time <= ?", models.SysNodeReservationActive, now).
+		Find(&expired).Error
+	if err != nil {
+		return
+	}
+	for _, r := range expired { //nolint:gocritic
+		err = global.Mysql.Model(&models.SysNodeReservation{}).Where("id = ?", r.Id).Updates(map[string]any{
+			"status":      models.SysNodeReservationReleased,
+			"released_by": reservationSystemReleaser,
+		}).Error
+		if err != nil {
+			return
+		}
+		sendReservationMail(&r, fmt.Sprintf("<预约到期>服务器[%s]的预约已到期并自动释放", r.Address),
+			fmt.Sprintf("您对%s的预约(用途：%s)已于%s到期，已自动释放。", r.Address, r.Purpose, r.EndTime))
+		released++
+	}
+	if remindBefore <= 0 {
+		return
+	}
+	expiring := make([]models.SysNodeReservation, 0)
+	err = global.Mysql.Model(&models.SysNodeReservation{}).
+		Where("status = ? AND reminded = ? AND end_time <= ?", models.SysNodeReservationActive, 0, now.Add(remindBefore)).
+		Find(&expiring).Error
+	if err != nil {
+		return
+	}
+	for _, r := range expiring { //nolint:gocritic
+		err = global.Mysql.Model(&models.SysNodeReservation{}).Where("id = ?", r.Id).Update("reminded", 1).Error
+		if err != nil {
+			return
+		}
+		sendReservationMail(&r, fmt.Sprintf("<预约即将到期>服务器[%s]的预约将于%s到期", r.Address, r.EndTime),
+			fmt.Sprintf("您对%s的预约(用途：%s)将于%s到期并自动释放，如需继续使用请及时续期。", r.Address, r.Purpose, r.EndTime))
+		reminded++
+	}
+	return
+}
+
+func sendReservationMail(r *models.SysNodeReservation, title, body string) {
+	mail := &async.Mail{
+		Title:     title,
+		Body:      body,
+		Receivers: getEmailAddr([]string{r.Username}),
+		CC:        nil,
+	}
+	global.Machinery.SendMailTask(mail)
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"metalflow/pkg/request"
+	tests2 "metalflow/tests"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMysqlService_CreateNodeReservations(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	end := time.Now().Add(time.Hour).Format("2006-01-02 15:04:05")
+	tests := []struct {
+		name    string
+		ids     []uint
+		req     *request.CreateNodeReservationRequestStruct
+		invoke  func()
+		wantErr bool
+	}{
+		{
+			name:    "fail1",
+			ids:     []uint{1},
+			req:     &request.CreateNodeReservationRequestStruct{Purpose: "ci", EndTime: "2020-01-01 00:00:00"},
+			invoke:  func() {},
+			wantErr: true,
+		},
+		{
+			name: "conflict",
+			ids:  []uint{1},
+			req:  &request.CreateNodeReservationRequestStruct{Purpose: "ci", EndTime: end},
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node` (.*) FOR UPDATE").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "address"}).AddRow(1, "12.34.56.78"))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_reservation`").
+					WithArgs(1, 0, tests2.AnyTime{}, tests2.AnyTime{}).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "node_id", "address", "username"}).
+						AddRow(1, 1, "12.34.56.78", "tester"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "success",
+			ids:  []uint{1},
+			req:  &request.CreateNodeReservationRequestStruct{Purpose: "ci", EndTime: end},
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node` (.*) FOR UPDATE").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "address"}).AddRow(1, "12.34.56.78"))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_reservation`").
+					WithArgs(1, 0, tests2.AnyTime{}, tests2.AnyTime{}).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `tb_sys_node_reservation`").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke()
+			if err := s.CreateNodeReservations("tester", tt.ids, tt.req); (err != nil) != tt.wantErr {
+				t.Errorf("CreateNodeReservations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
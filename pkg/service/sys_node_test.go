@@ -36,6 +36,7 @@ func TestMysqlService_CreateNode(t *testing.T) {
 				},
 			},
 			invoke: func(args2 args) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(args2.req.Address).
 					WillReturnError(errors.New("the machine node already exists, please do not repeat the creation"))
 			},
@@ -52,6 +53,7 @@ func TestMysqlService_CreateNode(t *testing.T) {
 				},
 			},
 			invoke: func(args2 args) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(args2.req.Address).
 					WillReturnError(gorm.ErrRecordNotFound)
 			},
@@ -68,6 +70,7 @@ func TestMysqlService_CreateNode(t *testing.T) {
 				},
 			},
 			invoke: func(args2 args) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(args2.req.Address).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}))
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_label`").WithArgs(1).
@@ -86,10 +89,13 @@ func TestMysqlService_CreateNode(t *testing.T) {
 				},
 			},
 			invoke: func(args2 args) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(args2.req.Address).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}))
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_label`").WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery("SELECT `name`,`cidr` FROM `tb_sys_region`").
+					WillReturnRows(sqlmock.NewRows([]string{"name", "cidr"}).AddRow("Shanghai", "10.23.0.0/16"))
 				mock.ExpectBegin()
 				mock.ExpectExec("INSERT INTO `tb_sys_node`").WillReturnError(errors.New("DB error"))
 				mock.ExpectRollback()
@@ -107,10 +113,13 @@ func TestMysqlService_CreateNode(t *testing.T) {
 				},
 			},
 			invoke: func(args2 args) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(args2.req.Address).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}))
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_label`").WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery("SELECT `name`,`cidr` FROM `tb_sys_region`").
+					WillReturnRows(sqlmock.NewRows([]string{"name", "cidr"}).AddRow("Shanghai", "10.23.0.0/16"))
 				mock.ExpectBegin()
 				mock.ExpectExec("INSERT INTO `tb_sys_node`").
 					WillReturnResult(sqlmock.NewResult(1, 1))
@@ -245,6 +254,7 @@ func TestMysqlService_GetNodes(t *testing.T) {
 				},
 			},
 			invoke: func(args2 args) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(
 					fmt.Sprintf("%%%s%%", args2.req.Address),
 					fmt.Sprintf("%%%s%%", args2.req.Manager),
@@ -272,6 +282,7 @@ func TestMysqlService_GetNodes(t *testing.T) {
 				},
 			},
 			invoke: func(args2 args) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(
 					fmt.Sprintf("%%%s%%", args2.req.Address),
 					fmt.Sprintf("%%%s%%", args2.req.Manager),
@@ -284,6 +295,24 @@ func TestMysqlService_GetNodes(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "capacity",
+			s:    &s,
+			args: args{
+				req: &request.NodeListRequestStruct{
+					MinCpuCores: 64,
+					MinRamGb:    256,
+					SortBy:      "ramBytes",
+				},
+			},
+			invoke: func(args2 args) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node` WHERE \\(cpu_cores >= \\?\\) AND ram_bytes >= \\?").
+					WithArgs(args2.req.MinCpuCores, uint64(256)<<30).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -325,6 +354,7 @@ func TestMysqlService_RefreshNodeInfoById(t *testing.T) {
 			invoke: func(args2 args) {
 				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(args2.id).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
 			},
 			wantErr: false,
 		},
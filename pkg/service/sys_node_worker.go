@@ -0,0 +1,303 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/grpc"
+	"metalflow/pkg/request"
+	"metalflow/pkg/response"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SetNodeWorkerStatus 记录worker在机器上的部署状态, 没有部署记录时创建
+func (s *MysqlService) SetNodeWorkerStatus(nodeId, workerId, status uint, version, lastError string) error {
+	return setNodeWorkerStatus(s.TX, nodeId, workerId, status, version, lastError)
+}
+
+// setNodeWorkerStatus 记录worker在机器上的部署状态, 版本为空时保留上次部署的版本
+func setNodeWorkerStatus(tx *gorm.DB, nodeId, workerId, status uint, version, lastError string) error {
+	var relation models.RelationNodeWorker
+	err := tx.Where("sys_node_id = ? AND sys_worker_id = ?", nodeId, workerId).First(&relation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Create(&models.RelationNodeWorker{
+			SysNodeId:   nodeId,
+			SysWorkerId: workerId,
+			Status:      &status,
+			Version:     version,
+			LastError:   lastError,
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+	updates := map[string]any{"status": status, "last_error": lastError}
+	if version != "" {
+		updates["version"] = version
+	}
+	return tx.Model(&models.RelationNodeWorker{}).
+		Where("sys_node_id = ? AND sys_worker_id = ?", nodeId, workerId).Updates(updates).Error
+}
+
+// CheckNodeWorkers 对机器上有部署记录的worker进行健康检查并记录结果
+func (s *MysqlService) CheckNodeWorkers(nodeId uint) ([]models.RelationNodeWorker, error) {
+	node, err := s.getUnblockedNode(nodeId)
+	if err != nil {
+		return nil, err
+	}
+	relations := make([]models.RelationNodeWorker, 0)
+	if err = s.TX.Where("sys_node_id = ?", nodeId).Find(&relations).Error; err != nil {
+		return nil, err
+	}
+	workers, err := s.getWorkerMap(relations)
+	if err != nil {
+		return nil, err
+	}
+	for i := range relations {
+		worker, ok := workers[relations[i].SysWorkerId]
+		if !ok {
+			continue
+		}
+		if _, err = checkNodeWorker(s.TX, node.Address, &worker, &relations[i]); err != nil {
+			return nil, err
+		}
+	}
+	return relations, nil
+}
+
+// getWorkerMap 获取部署记录对应的worker, key为worker id
+func (s *MysqlService) getWorkerMap(relations []models.RelationNodeWorker) (map[uint]models.SysWorker, error) {
+	ids := make([]uint, 0, len(relations))
+	for _, relation := range relations { //nolint:gocritic
+		ids = append(ids, relation.SysWorkerId)
+	}
+	workers := make([]models.SysWorker, 0)
+	if len(ids) > 0 {
+		if err := s.TX.Where("id IN (?)", ids).Find(&workers).Error; err != nil {
+			return nil, err
+		}
+	}
+	m := make(map[uint]models.SysWorker, len(workers))
+	for _, worker := range workers { //nolint:gocritic
+		m[worker.Id] = worker
+	}
+	return m, nil
+}
+
+// workerCheckMaxFailures worker连续健康检查失败多少次后标记为异常
+func workerCheckMaxFailures() uint {
+	if global.Conf.NodeConf.WorkerCheckMaxFailures > 1 {
+		return global.Conf.NodeConf.WorkerCheckMaxFailures
+	}
+	return 1
+}
+
+// checkNodeWorker 通过worker的CheckReq检查其是否正常, 返回本次检查是否通过
+// 检查通过时视为运行中并以返回值作为已部署版本, 连续失败达到阈值后才标记为异常
+func checkNodeWorker(tx *gorm.DB, address string, worker *models.SysWorker, relation *models.RelationNodeWorker) (bool, error) {
+	now := time.Now()
+	updates := map[string]any{"last_check_time": now}
+	output, err := grpc.CheckWorker(address, worker.Port, worker.Role, worker.CheckReq)
+	if err != nil {
+		relation.CheckFailures++
+		relation.LastError = fmt.Sprintf("check worker [%s] failed: %v", worker.Name, err)
+		if relation.CheckFailures >= workerCheckMaxFailures() {
+			health := models.NodeWorkerHealthAbnormal
+			relation.Health = &health
+			updates["health"] = health
+		}
+	} else {
+		health := models.NodeWorkerHealthNormal
+		status := models.NodeWorkerRunning
+		relation.Health = &health
+		relation.Status = &status
+		relation.CheckFailures = 0
+		relation.LastError = ""
+		updates["health"] = health
+		updates["status"] = status
+		if output != "" {
+			relation.Version = output
+			updates["version"] = output
+		}
+	}
+	relation.LastCheckTime = models.LocalTime{Time: now}
+	updates["check_failures"] = relation.CheckFailures
+	updates["last_error"] = relation.LastError
+	err = tx.Model(&models.RelationNodeWorker{}).
+		Where("sys_node_id = ? AND sys_worker_id = ?", relation.SysNodeId, relation.SysWorkerId).Updates(updates).Error
+	return relation.CheckFailures == 0, err
+}
+
+// CheckAllNodeWorkers 对所有未被禁止且不在维护中的机器上运行中的worker进行健康检查
+// worker刚被标记为异常且redeploy为true时自动重新部署, 返回检查数、新增异常数与重新部署数
+func CheckAllNodeWorkers(redeploy bool) (checked, unhealthy, redeployed int, err error) {
+	relations := make([]models.RelationNodeWorker, 0)
+	err = global.Mysql.Where("status = ?", models.NodeWorkerRunning).
+		Order("sys_node_id, sys_worker_id").Find(&relations).Error
+	if err != nil || len(relations) == 0 {
+		return
+	}
+	s := New(nil)
+	workers, err := s.getWorkerMap(relations)
+	if err != nil {
+		return
+	}
+	nodeIds := make([]uint, 0, len(relations))
+	for _, relation := range relations { //nolint:gocritic
+		nodeIds = append(nodeIds, relation.SysNodeId)
+	}
+	nodeList := make([]models.SysNode, 0)
+	if err = global.Mysql.Where("id IN (?)", nodeIds).Find(&nodeList).Error; err != nil {
+		return
+	}
+	denyList, err := GetNodeDenyList()
+	if err != nil {
+		return
+	}
+	nodes := make(map[uint]*models.SysNode, len(nodeList))
+	for i := range nodeList {
+		// 维护中的机器不进行检查及自动重新部署
+		if !denyList.Denied(nodeList[i].Address) && !nodeList[i].InMaintenance() {
+			nodes[nodeList[i].Id] = &nodeList[i]
+		}
+	}
+
+	var (
+		lock = sync.Mutex{}
+		wg   = sync.WaitGroup{}
+		// nolint:gomnd
+		tokens = make(chan struct{}, 10)
+	)
+	maxFailures := workerCheckMaxFailures()
+	for i := range relations {
+		node, ok := nodes[relations[i].SysNodeId]
+		if !ok {
+			continue
+		}
+		worker, ok := workers[relations[i].SysWorkerId]
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(relation *models.RelationNodeWorker, worker models.SysWorker) {
+			defer wg.Done()
+			// 限制同时检查的数量
+			tokens <- struct{}{}
+			defer func() { <-tokens }()
+			passed, e := checkNodeWorker(global.Mysql, node.Address, &worker, relation)
+			if e != nil {
+				global.Log.Errorf("record worker [%s] health on %s failed: %v", worker.Name, node.Address, e)
+			}
+			// 仅在连续失败次数刚达到阈值时处理, 避免重复重新部署
+			becameUnhealthy := !passed && relation.CheckFailures == maxFailures
+			redeployedNow := false
+			if becameUnhealthy && redeploy {
+				result := runWorkerAction(global.Mysql, node, &worker, WorkerActionRedeploy, nil)
+				if !result.Success {
+					global.Log.Errorf("redeploy unhealthy worker [%s] on %s failed: %s", worker.Name, node.Address, result.Error)
+				}
+				redeployedNow = true
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			checked++
+			if becameUnhealthy {
+				unhealthy++
+			}
+			if redeployedNow {
+				redeployed++
+			}
+		}(&relations[i], worker)
+	}
+	wg.Wait()
+	return
+}
+
+// GetNodeWorkerMatrix 获取机器×worker的部署状态矩阵
+func (s *MysqlService) GetNodeWorkerMatrix(req *request.NodeWorkerMatrixRequestStruct) (*response.NodeWorkerMatrixResponseStruct, error) {
+	workers := make([]models.SysWorker, 0)
+	workerQuery := s.TX.Model(&models.SysWorker{}).Order("id")
+	role := strings.TrimSpace(req.Role)
+	if role != "" {
+		workerQuery = workerQuery.Where("role = ?", role)
+	}
+	if err := workerQuery.Find(&workers).Error; err != nil {
+		return nil, err
+	}
+
+	nodes := make([]models.SysNode, 0)
+	query := s.TX.Model(&models.SysNode{}).Order("id")
+	address := strings.TrimSpace(req.Address)
+	if address != "" {
+		query = query.Where("address LIKE ?", fmt.Sprintf("%%%s%%", address))
+	}
+	if req.Status != nil {
+		query = query.Where("id IN (?)", s.TX.Model(&models.RelationNodeWorker{}).
+			Select("sys_node_id").Where("status = ?", *req.Status))
+	}
+	query, err := s.whereNotDenied(query)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.Find(query, &req.PageInfo, &nodes); err != nil {
+		return nil, err
+	}
+
+	nodeIds := make([]uint, 0, len(nodes))
+	for _, node := range nodes { //nolint:gocritic
+		nodeIds = append(nodeIds, node.Id)
+	}
+	relations := make([]models.RelationNodeWorker, 0)
+	if len(nodeIds) > 0 {
+		if err = s.TX.Where("sys_node_id IN (?)", nodeIds).Find(&relations).Error; err != nil {
+			return nil, err
+		}
+	}
+	relationMap := make(map[[2]uint]models.RelationNodeWorker, len(relations))
+	for _, relation := range relations { //nolint:gocritic
+		relationMap[[2]uint{relation.SysNodeId, relation.SysWorkerId}] = relation
+	}
+
+	resp := &response.NodeWorkerMatrixResponseStruct{
+		PageInfo: req.PageInfo,
+		Workers:  make([]response.NodeWorkerColumnStruct, 0, len(workers)),
+		List:     make([]response.NodeWorkerMatrixRowStruct, 0, len(nodes)),
+	}
+	for _, worker := range workers { //nolint:gocritic
+		resp.Workers = append(resp.Workers, response.NodeWorkerColumnStruct{
+			Id:      worker.Id,
+			Name:    worker.Name,
+			Role:    worker.Role,
+			Version: worker.Version,
+		})
+	}
+	for _, node := range nodes { //nolint:gocritic
+		row := response.NodeWorkerMatrixRowStruct{
+			NodeId:  node.Id,
+			Address: node.Address,
+			Health:  node.Health,
+			Workers: make([]response.NodeWorkerStatusResponseStruct, 0, len(workers)),
+		}
+		for _, worker := range workers { //nolint:gocritic
+			item := response.NodeWorkerStatusResponseStruct{SysWorkerId: worker.Id}
+			if relation, ok := relationMap[[2]uint{node.Id, worker.Id}]; ok {
+				item.Deployed = true
+				item.Status = relation.Status
+				item.Version = relation.Version
+				item.Outdated = worker.Version != "" && relation.Version != worker.Version
+				item.Health = relation.Health
+				item.LastCheckTime = relation.LastCheckTime
+				item.LastError = relation.LastError
+				item.UpdatedAt = relation.UpdatedAt
+			}
+			row.Workers = append(row.Workers, item)
+		}
+		resp.List = append(resp.List, row)
+	}
+	return resp, nil
+}
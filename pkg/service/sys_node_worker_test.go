@@ -0,0 +1,182 @@
+package service
+
+import (
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	tests2 "metalflow/tests"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMysqlService_SetNodeWorkerStatus(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	tests := []struct {
+		name    string
+		invoke  func()
+		wantErr bool
+	}{
+		{
+			name: "create",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_worker_relation`").WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"sys_node_id"}))
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `tb_sys_node_worker_relation`").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+		{
+			name: "update",
+			invoke: func() {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_worker_relation`").WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"sys_node_id", "sys_worker_id"}).AddRow(1, 2))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `tb_sys_node_worker_relation` SET").
+					WithArgs("", models.NodeWorkerFailed, "v1.2.0", sqlmock.AnyArg(), 1, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke()
+			err := s.SetNodeWorkerStatus(1, 2, models.NodeWorkerFailed, "v1.2.0", "")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetNodeWorkerStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("SetNodeWorkerStatus() %v", err)
+			}
+		})
+	}
+}
+
+func TestMysqlService_GetNodeWorkerMatrix(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	running := models.NodeWorkerRunning
+	mock.ExpectQuery("SELECT (.*) FROM `tb_sys_worker`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "role", "version"}).
+			AddRow(1, "metalmetrics", models.SysWorkerRoleMetrics, "v2").
+			AddRow(2, "metalsecure", models.SysWorkerRoleSecure, ""))
+	mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(sqlmock.NewRows([]string{"cidr"}))
+	mock.ExpectQuery("SELECT count(.*) FROM `tb_sys_node`").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "address"}).AddRow(7, "10.0.0.7"))
+	mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_worker_relation`").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"sys_node_id", "sys_worker_id", "status", "version"}).
+			AddRow(7, 1, running, "v1"))
+
+	matrix, err := s.GetNodeWorkerMatrix(&request.NodeWorkerMatrixRequestStruct{})
+	if err != nil {
+		t.Fatalf("GetNodeWorkerMatrix() error = %v", err)
+	}
+	if len(matrix.Workers) != 2 || len(matrix.List) != 1 || len(matrix.List[0].Workers) != 2 {
+		t.Fatalf("GetNodeWorkerMatrix() = %+v, want 1 node x 2 workers", matrix)
+	}
+	metrics, secure := matrix.List[0].Workers[0], matrix.List[0].Workers[1]
+	if !metrics.Deployed || *metrics.Status != running || !metrics.Outdated {
+		t.Errorf("metrics worker = %+v, want deployed, running and outdated", metrics)
+	}
+	if secure.Deployed {
+		t.Errorf("secure worker = %+v, want not deployed", secure)
+	}
+}
+
+func TestCheckAllNodeWorkers(t *testing.T) {
+	mock := tests2.GetMock()
+	tests2.SetLog()
+	maxFailures := global.Conf.NodeConf.WorkerCheckMaxFailures
+	global.Conf.NodeConf.WorkerCheckMaxFailures = 2
+	defer func() {
+		global.Conf.NodeConf.WorkerCheckMaxFailures = maxFailures
+	}()
+	expectRelations := func(failures uint, denied bool, maintenance uint) {
+		mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_worker_relation`").WithArgs(models.NodeWorkerRunning).
+			WillReturnRows(sqlmock.NewRows([]string{"sys_node_id", "sys_worker_id", "status", "check_failures"}).
+				AddRow(1, 1, models.NodeWorkerRunning, failures))
+		mock.ExpectQuery("SELECT (.*) FROM `tb_sys_worker`").WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "port"}).AddRow(1, "metrics", 1))
+		mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "address", "maintenance"}).AddRow(1, "127.0.0.1", maintenance))
+		rows := sqlmock.NewRows([]string{"cidr", "action"})
+		if denied {
+			rows.AddRow("127.0.0.0/8", models.SysNodeDenyHide)
+		}
+		mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_deny`").WillReturnRows(rows)
+	}
+	tests := []struct {
+		name          string
+		redeploy      bool
+		invoke        func()
+		wantChecked   int
+		wantUnhealthy int
+	}{
+		{
+			name: "below threshold",
+			invoke: func() {
+				expectRelations(0, false, models.SysNodeMaintenanceOff)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `tb_sys_node_worker_relation` SET").
+					WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantChecked:   1,
+			wantUnhealthy: 0,
+		},
+		{
+			name: "became unhealthy",
+			invoke: func() {
+				expectRelations(1, false, models.SysNodeMaintenanceOff)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `tb_sys_node_worker_relation` SET").
+					WithArgs(2, models.NodeWorkerHealthAbnormal, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantChecked:   1,
+			wantUnhealthy: 1,
+		},
+		{
+			name:     "in maintenance",
+			redeploy: true,
+			invoke: func() {
+				expectRelations(1, false, models.SysNodeMaintenanceOn)
+			},
+			wantChecked:   0,
+			wantUnhealthy: 0,
+		},
+		{
+			name: "denied",
+			invoke: func() {
+				expectRelations(0, true, models.SysNodeMaintenanceOff)
+			},
+			wantChecked:   0,
+			wantUnhealthy: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke()
+			checked, unhealthy, redeployed, err := CheckAllNodeWorkers(tt.redeploy)
+			if err != nil {
+				t.Errorf("CheckAllNodeWorkers() error = %v", err)
+			}
+			if checked != tt.wantChecked || unhealthy != tt.wantUnhealthy || redeployed != 0 {
+				t.Errorf("CheckAllNodeWorkers() = %d, %d, %d, want %d, %d, 0",
+					checked, unhealthy, redeployed, tt.wantChecked, tt.wantUnhealthy)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("CheckAllNodeWorkers() %v", err)
+			}
+		})
+	}
+}
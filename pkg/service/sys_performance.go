@@ -0,0 +1,151 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/grpc"
+	"metalflow/pkg/request"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PerformanceHigh   uint = 0 // 高性能
+	PerformanceMedium uint = 1 // 中性能
+	PerformanceLow    uint = 2 // 低性能
+)
+
+// builtinPerformanceProfile 数据库中没有默认规则时使用的内置规则
+var builtinPerformanceProfile = models.SysPerformanceProfile{
+	Name:            "builtin",
+	CpuWeight:       40, //nolint:gomnd
+	RamWeight:       10, //nolint:gomnd
+	MediumThreshold: 19, //nolint:gomnd
+	HighThreshold:   38, //nolint:gomnd
+}
+
+// RatePerformance 根据评级规则计算机器性能
+func RatePerformance(profile *models.SysPerformanceProfile, metric *grpc.Metric) uint {
+	capacity := grpc.ParseCapacity(metric)
+	score := (float64(capacity.CpuCores)*profile.CpuWeight +
+		float64(capacity.RamBytes>>30)*profile.RamWeight +
+		float64(capacity.DiskBytes>>30)*profile.DiskWeight +
+		float64(GetPerformanceByMetrics(metric.Io))*profile.IoWeight +
+		float64(capacity.NicSpeed)/1000*profile.NetWeight) / 100 //nolint:gomnd
+	if score >= profile.HighThreshold {
+		return PerformanceHigh
+	}
+	if score >= profile.MediumThreshold {
+		return PerformanceMedium
+	}
+	return PerformanceLow
+}
+
+// getPerformanceProfile 获取机器使用的评级规则, 规则不存在时依次使用默认规则、内置规则
+func getPerformanceProfile(tx *gorm.DB, profileId uint) models.SysPerformanceProfile {
+	var profile models.SysPerformanceProfile
+	if profileId > 0 && tx.Where("id = ?", profileId).First(&profile).Error == nil {
+		return profile
+	}
+	if tx.Where("is_default = ?", 1).First(&profile).Error == nil {
+		return profile
+	}
+	return builtinPerformanceProfile
+}
+
+// GetPerformanceProfiles 获取性能评级规则列表
+func (s *MysqlService) GetPerformanceProfiles(req *request.PerformanceProfileListRequestStruct) (
+	[]models.SysPerformanceProfile, error) {
+	list := make([]models.SysPerformanceProfile, 0)
+	query := s.TX.Model(&models.SysPerformanceProfile{}).Order("created_at DESC")
+	name := strings.TrimSpace(req.Name)
+	if name != "" {
+		query = query.Where("name LIKE ?", fmt.Sprintf("%%%s%%", name))
+	}
+	err := s.Find(query, &req.PageInfo, &list)
+	return list, err
+}
+
+// CreatePerformanceProfile 创建性能评级规则
+func (s *MysqlService) CreatePerformanceProfile(req *request.CreatePerformanceProfileRequestStruct) error {
+	if req.MediumThreshold > req.HighThreshold {
+		return fmt.Errorf("the medium threshold cannot be greater than the high threshold")
+	}
+	err := s.TX.Where("name = ?", req.Name).First(&models.SysPerformanceProfile{}).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("performance profile [%s] already exists", req.Name)
+	}
+	if req.IsDefault != nil && *req.IsDefault == 1 {
+		if err = s.clearDefaultPerformanceProfile(); err != nil {
+			return err
+		}
+	}
+	return s.Create(req, new(models.SysPerformanceProfile))
+}
+
+// UpdatePerformanceProfileById 更新性能评级规则
+func (s *MysqlService) UpdatePerformanceProfileById(id uint, req *request.UpdatePerformanceProfileRequestStruct) error {
+	var profile models.SysPerformanceProfile
+	if err := s.TX.Where("id = ?", id).First(&profile).Error; err != nil {
+		return err
+	}
+	medium, high := profile.MediumThreshold, profile.HighThreshold
+	if req.MediumThreshold != nil {
+		medium = *req.MediumThreshold
+	}
+	if req.HighThreshold != nil {
+		high = *req.HighThreshold
+	}
+	if medium > high {
+		return fmt.Errorf("the medium threshold cannot be greater than the high threshold")
+	}
+	if req.IsDefault != nil && *req.IsDefault == 1 {
+		if err := s.clearDefaultPerformanceProfile(); err != nil {
+			return err
+		}
+	}
+	return s.UpdateById(id, req, new(models.SysPerformanceProfile))
+}
+
+// clearDefaultPerformanceProfile 取消当前的默认规则, 保证只有一个默认规则
+func (s *MysqlService) clearDefaultPerformanceProfile() error {
+	return s.TX.Model(&models.SysPerformanceProfile{}).Where("is_default = ?", 1).Update("is_default", 0).Error
+}
+
+// RecomputeNodePerformance 按当前的评级规则重新计算所有机器的性能, 返回更新的机器数
+func (s *MysqlService) RecomputeNodePerformance() (int64, error) {
+	nodes := make([]models.SysNode, 0)
+	err := s.TX.Model(&models.SysNode{}).Select("id", "address", "information", "profile_id").Find(&nodes).Error
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	profiles := make(map[uint]models.SysPerformanceProfile)
+	for _, node := range nodes { //nolint:gocritic
+		info := strings.TrimSpace(string(node.Information))
+		if info == "" || info == "null" {
+			continue
+		}
+		var metric grpc.Metric
+		if err = json.Unmarshal(node.Information, &metric); err != nil {
+			global.Log.Errorf("[%s]解析机器详情失败：%v", node.Address, err)
+			continue
+		}
+		profile, ok := profiles[node.ProfileId]
+		if !ok {
+			profile = getPerformanceProfile(s.TX, node.ProfileId)
+			profiles[node.ProfileId] = profile
+		}
+		performance := RatePerformance(&profile, &metric)
+		err = s.TX.Model(&models.SysNode{}).Where("id = ?", node.Id).Update("performance", performance).Error
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"metalflow/models"
+	"metalflow/pkg/grpc"
+	"metalflow/pkg/request"
+	tests2 "metalflow/tests"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRatePerformance(t *testing.T) {
+	buildServer := &models.SysPerformanceProfile{
+		CpuWeight:       10,
+		RamWeight:       10,
+		DiskWeight:      1,
+		MediumThreshold: 50,
+		HighThreshold:   100,
+	}
+	tests := []struct {
+		name    string
+		profile *models.SysPerformanceProfile
+		metric  grpc.Metric
+		want    uint
+	}{
+		{
+			name:    "builtin high",
+			profile: &builtinPerformanceProfile,
+			metric:  grpc.Metric{ModelMetric: grpc.ModelMetric{Cpu: "64 CPU (1.0% used)", Ram: "256 GB (2.0% used)"}},
+			want:    PerformanceHigh,
+		},
+		{
+			name:    "builtin medium",
+			profile: &builtinPerformanceProfile,
+			metric:  grpc.Metric{ModelMetric: grpc.ModelMetric{Cpu: "32 CPU (1.0% used)", Ram: "64 GB (2.0% used)"}},
+			want:    PerformanceMedium,
+		},
+		{
+			name:    "builtin low",
+			profile: &builtinPerformanceProfile,
+			metric:  grpc.Metric{ModelMetric: grpc.ModelMetric{Cpu: "8 CPU (1.0% used)", Ram: "16 GB (2.0% used)"}},
+			want:    PerformanceLow,
+		},
+		{
+			name:    "disk weight",
+			profile: buildServer,
+			metric: grpc.Metric{ModelMetric: grpc.ModelMetric{
+				Cpu:  "8 CPU (1.0% used)",
+				Ram:  "16 GB (2.0% used)",
+				Disk: "10 TB (3.0% used)",
+			}},
+			want: PerformanceHigh,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RatePerformance(tt.profile, &tt.metric); got != tt.want {
+				t.Errorf("RatePerformance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMysqlService_CreatePerformanceProfile(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	tests := []struct {
+		name    string
+		req     *request.CreatePerformanceProfileRequestStruct
+		invoke  func(req *request.CreatePerformanceProfileRequestStruct)
+		wantErr bool
+	}{
+		{
+			name:    "fail1",
+			req:     &request.CreatePerformanceProfileRequestStruct{Name: "test rig", MediumThreshold: 40, HighThreshold: 20},
+			invoke:  func(req *request.CreatePerformanceProfileRequestStruct) {},
+			wantErr: true,
+		},
+		{
+			name: "fail2",
+			req:  &request.CreatePerformanceProfileRequestStruct{Name: "test rig", MediumThreshold: 20, HighThreshold: 40},
+			invoke: func(req *request.CreatePerformanceProfileRequestStruct) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_performance_profile`").WithArgs(req.Name).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke(tt.req)
+			if err := s.CreatePerformanceProfile(tt.req); (err != nil) != tt.wantErr {
+				t.Errorf("CreatePerformanceProfile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"metalflow/models"
+	"metalflow/pkg/request"
+	"metalflow/pkg/utils"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 不属于任何网段的机器的地域
+const otherRegion = "Other"
+
+// matchRegion 根据网段匹配ip的地域
+func matchRegion(regions []models.SysRegion, ip string) string {
+	cidrs := make([]string, 0, len(regions))
+	for _, region := range regions { //nolint:gocritic
+		cidrs = append(cidrs, region.Cidr)
+	}
+	if i := utils.MatchCidr(ip, cidrs); i >= 0 {
+		return regions[i].Name
+	}
+	return otherRegion
+}
+
+// getRegionByIp 获取ip所在的地域
+func getRegionByIp(tx *gorm.DB, ip string) string {
+	regions := make([]models.SysRegion, 0)
+	if err := tx.Model(&models.SysRegion{}).Select("name", "cidr").Find(&regions).Error; err != nil {
+		return otherRegion
+	}
+	return matchRegion(regions, ip)
+}
+
+// GetRegions 获取地域网段列表
+func (s *MysqlService) GetRegions(req *request.RegionListRequestStruct) ([]models.SysRegion, error) {
+	list := make([]models.SysRegion, 0)
+	query := s.TX.Model(&models.SysRegion{}).Order("name, cidr")
+	name := strings.TrimSpace(req.Name)
+	if name != "" {
+		query = query.Where("name LIKE ?", fmt.Sprintf("%%%s%%", name))
+	}
+	cidr := strings.TrimSpace(req.Cidr)
+	if cidr != "" {
+		query = query.Where("cidr LIKE ?", fmt.Sprintf("%%%s%%", cidr))
+	}
+	err := s.Find(query, &req.PageInfo, &list)
+	return list, err
+}
+
+// checkRegionCidr 校验并格式化网段, 同一网段只能属于一个地域
+func (s *MysqlService) checkRegionCidr(id uint, cidr string) (string, error) {
+	cidr, err := utils.NormalizeCidr(cidr)
+	if err != nil {
+		return "", err
+	}
+	var region models.SysRegion
+	err = s.TX.Where("cidr = ? AND id != ?", cidr, id).First(&region).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("the cidr %s already belongs to region [%s]", cidr, region.Name)
+	}
+	return cidr, nil
+}
+
+// CreateRegion 创建地域网段
+func (s *MysqlService) CreateRegion(req *request.CreateRegionRequestStruct) (err error) {
+	req.Cidr, err = s.checkRegionCidr(0, req.Cidr)
+	if err != nil {
+		return
+	}
+	return s.Create(req, new(models.SysRegion))
+}
+
+// UpdateRegionById 更新地域网段
+func (s *MysqlService) UpdateRegionById(id uint, req *request.UpdateRegionRequestStruct) error {
+	if req.Cidr != nil {
+		cidr, err := s.checkRegionCidr(id, *req.Cidr)
+		if err != nil {
+			return err
+		}
+		req.Cidr = &cidr
+	}
+	return s.UpdateById(id, req, new(models.SysRegion))
+}
+
+// RecomputeNodeRegions 按当前的地域网段重新计算所有机器的地域, 返回地域变化的机器数
+func (s *MysqlService) RecomputeNodeRegions() (int64, error) {
+	regions := make([]models.SysRegion, 0)
+	if err := s.TX.Model(&models.SysRegion{}).Select("name", "cidr").Find(&regions).Error; err != nil {
+		return 0, err
+	}
+	nodes := make([]models.SysNode, 0)
+	if err := s.TX.Model(&models.SysNode{}).Select("id", "address", "region").Find(&nodes).Error; err != nil {
+		return 0, err
+	}
+	var count int64
+	for _, node := range nodes { //nolint:gocritic
+		region := matchRegion(regions, node.Address)
+		if region == node.Region {
+			continue
+		}
+		err := s.TX.Model(&models.SysNode{}).Where("id = ?", node.Id).Update("region", region).Error
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"metalflow/models"
+	tests2 "metalflow/tests"
+	"testing"
+)
+
+func TestMatchRegion(t *testing.T) {
+	regions := []models.SysRegion{
+		{Name: "Chengdu", Cidr: "10.1.0.0/16"},
+		{Name: "Shanghai", Cidr: "10.12.0.0/16"},
+		{Name: "Lab", Cidr: "10.12.34.0/24"},
+		{Name: "Xi'an", Cidr: "fd00::/16"},
+	}
+	tests := map[string]string{
+		"10.1.2.3":   "Chengdu",
+		"10.12.2.3":  "Shanghai",
+		"10.12.34.5": "Lab",
+		"fd00:1::1":  "Xi'an",
+		"10.123.4.5": otherRegion,
+	}
+	for ip, want := range tests {
+		if got := matchRegion(regions, ip); got != want {
+			t.Errorf("matchRegion(%s) = %s, want %s", ip, got, want)
+		}
+	}
+}
+
+func TestMysqlService_RecomputeNodeRegions(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	mock.ExpectQuery("SELECT `name`,`cidr` FROM `tb_sys_region`").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "cidr"}).AddRow("Shanghai", "10.12.0.0/16"))
+	mock.ExpectQuery("SELECT `id`,`address`,`region` FROM `tb_sys_node`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "address", "region"}).
+			AddRow(1, "10.12.0.1", "Shanghai").
+			AddRow(2, "10.123.0.1", "Shanghai"))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `tb_sys_node`").WithArgs(otherRegion, tests2.AnyTime{}, 2).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	count, err := s.RecomputeNodeRegions()
+	if err != nil || count != 1 {
+		t.Errorf("RecomputeNodeRegions() = %d, %v, want 1", count, err)
+	}
+
+	mock.ExpectQuery("SELECT `name`,`cidr` FROM `tb_sys_region`").WillReturnError(errors.New("DB error"))
+	if _, err = s.RecomputeNodeRegions(); err == nil {
+		t.Errorf("RecomputeNodeRegions() should fail when the regions cannot be loaded")
+	}
+}
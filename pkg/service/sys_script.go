@@ -0,0 +1,369 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"metalflow/models"
+	"metalflow/pkg/request"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const defaultScriptInterpreter = "bash"
+
+var scriptInterpreterReg = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// GetScripts 获取脚本列表
+func (s *MysqlService) GetScripts(req *request.ScriptListRequestStruct) ([]models.SysScript, error) {
+	list := make([]models.SysScript, 0)
+	query := s.TX.Model(&models.SysScript{}).Order("name")
+	name := strings.TrimSpace(req.Name)
+	if name != "" {
+		query = query.Where("name LIKE ?", fmt.Sprintf("%%%s%%", name))
+	}
+	creator := strings.TrimSpace(req.Creator)
+	if creator != "" {
+		query = query.Where("creator LIKE ?", fmt.Sprintf("%%%s%%", creator))
+	}
+	err := s.Find(query, &req.PageInfo, &list)
+	return list, err
+}
+
+// GetScriptById 获取脚本
+func (s *MysqlService) GetScriptById(id uint) (models.SysScript, error) {
+	var script models.SysScript
+	err := s.TX.Where("id = ?", id).First(&script).Error
+	return script, err
+}
+
+// GetScriptVersions 获取脚本的历史版本, 新版本在前
+func (s *MysqlService) GetScriptVersions(id uint) ([]models.SysScriptVersion, error) {
+	list := make([]models.SysScriptVersion, 0)
+	err := s.TX.Where("script_id = ?", id).Order("version DESC").Find(&list).Error
+	return list, err
+}
+
+// GetScriptVersion 获取脚本的指定版本, 版本号为0时为当前版本
+func (s *MysqlService) GetScriptVersion(id, version uint) (models.SysScriptVersion, error) {
+	var scriptVersion models.SysScriptVersion
+	if version == 0 {
+		script, err := s.GetScriptById(id)
+		if err != nil {
+			return scriptVersion, err
+		}
+		version = script.Version
+	}
+	err := s.TX.Where("script_id = ? AND version = ?", id, version).First(&scriptVersion).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return scriptVersion, fmt.Errorf("the version %d of the script does not exist", version)
+	}
+	return scriptVersion, err
+}
+
+// CreateScript 创建脚本及其第一个版本
+func (s *MysqlService) CreateScript(req *request.CreateScriptRequestStruct) (*models.SysScript, error) {
+	name := strings.TrimSpace(req.Name)
+	interpreter := strings.TrimSpace(req.Interpreter)
+	if interpreter == "" {
+		interpreter = defaultScriptInterpreter
+	}
+	if err := s.checkScriptName(0, name); err != nil {
+		return nil, err
+	}
+	version, err := newScriptVersion(interpreter, req.Params, req.Content)
+	if err != nil {
+		return nil, err
+	}
+	version.Version = 1
+	version.Remark = req.Remark
+	version.Creator = req.Creator
+	script := &models.SysScript{
+		Name:        name,
+		Desc:        req.Desc,
+		Interpreter: version.Interpreter,
+		Params:      version.Params,
+		Content:     version.Content,
+		Version:     version.Version,
+		Creator:     req.Creator,
+		Versions:    []models.SysScriptVersion{*version},
+	}
+	if err = s.TX.Create(script).Error; err != nil {
+		return nil, err
+	}
+	return script, nil
+}
+
+// UpdateScriptById 更新脚本, 内容、参数或解释器变化时生成新版本
+func (s *MysqlService) UpdateScriptById(id uint, req *request.UpdateScriptRequestStruct) error {
+	script, err := s.GetScriptById(id)
+	if err != nil {
+		return err
+	}
+	updates := make(map[string]any)
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if err = s.checkScriptName(id, name); err != nil {
+			return err
+		}
+		updates["name"] = name
+	}
+	if req.Desc != nil {
+		updates["desc"] = *req.Desc
+	}
+
+	interpreter, content := script.Interpreter, script.Content
+	params := make([]models.ScriptParam, 0)
+	if err = json.Unmarshal(script.Params, &params); err != nil {
+		return fmt.Errorf("the params of the script are invalid: %v", err)
+	}
+	// 数据库返回的json格式可能不同, 重新序列化后再比较
+	oldParams, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	if req.Interpreter != nil {
+		interpreter = strings.TrimSpace(*req.Interpreter)
+	}
+	if req.Params != nil {
+		params = *req.Params
+	}
+	if req.Content != nil {
+		content = *req.Content
+	}
+	version, err := newScriptVersion(interpreter, params, content)
+	if err != nil {
+		return err
+	}
+	if version.Interpreter != script.Interpreter || version.Content != script.Content ||
+		string(version.Params) != string(oldParams) {
+		version.ScriptId = id
+		version.Version = script.Version + 1
+		version.Remark = req.Remark
+		version.Creator = req.Creator
+		if err = s.TX.Create(version).Error; err != nil {
+			return err
+		}
+		updates["interpreter"] = version.Interpreter
+		updates["params"] = version.Params
+		updates["content"] = version.Content
+		updates["version"] = version.Version
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return s.TX.Model(&models.SysScript{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// checkScriptName 脚本名称不能为空且不能重复
+func (s *MysqlService) checkScriptName(id uint, name string) error {
+	if name == "" {
+		return errors.New("the script name is empty")
+	}
+	err := s.TX.Where("name = ? AND id != ?", name, id).First(&models.SysScript{}).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("the script [%s] already exists", name)
+	}
+	return nil
+}
+
+// newScriptVersion 校验解释器与参数定义, 并使用示例参数渲染脚本
+func newScriptVersion(interpreter string, params []models.ScriptParam, content string) (*models.SysScriptVersion, error) {
+	if !scriptInterpreterReg.MatchString(interpreter) {
+		return nil, fmt.Errorf("the interpreter [%s] is invalid", interpreter)
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, errors.New("the script content is empty")
+	}
+	if params == nil {
+		params = make([]models.ScriptParam, 0)
+	}
+	names := make(map[string]bool, len(params))
+	sample := make(map[string]string, len(params))
+	for i := range params {
+		param := &params[i]
+		if !deployVarNameReg.MatchString(param.Name) {
+			return nil, fmt.Errorf("the param name [%s] may only contain letters, digits and underscores", param.Name)
+		}
+		if names[param.Name] {
+			return nil, fmt.Errorf("the param [%s] is duplicated", param.Name)
+		}
+		names[param.Name] = true
+		switch param.Type {
+		case "":
+			param.Type = models.ScriptParamString
+		case models.ScriptParamString, models.ScriptParamInt, models.ScriptParamBool:
+		case models.ScriptParamEnum:
+			if len(param.Options) == 0 {
+				return nil, fmt.Errorf("the enum param [%s] has no options", param.Name)
+			}
+			sample[param.Name] = param.Options[0]
+		default:
+			return nil, fmt.Errorf("the type [%s] of param [%s] is not supported", param.Type, param.Name)
+		}
+		if param.Default != "" {
+			if _, err := parseScriptParam(param, param.Default); err != nil {
+				return nil, fmt.Errorf("the default value of param [%s] is invalid: %v", param.Name, err)
+			}
+		}
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	version := &models.SysScriptVersion{
+		Interpreter: interpreter,
+		Params:      datatypes.JSON(b),
+		Content:     content,
+	}
+	values, err := resolveScriptParams(params, sample, false)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = version.Render(values); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// resolveScriptParams 按参数定义转换参数值, 未传的参数使用默认值, strict为true时校验必填参数
+func resolveScriptParams(params []models.ScriptParam, values map[string]string, strict bool) (map[string]any, error) {
+	resolved := make(map[string]any, len(params))
+	defined := make(map[string]bool, len(params))
+	for i := range params {
+		param := &params[i]
+		defined[param.Name] = true
+		value, ok := values[param.Name]
+		if !ok || value == "" {
+			value = param.Default
+		}
+		if value == "" && strict && param.Required {
+			return nil, fmt.Errorf("the param [%s] is required", param.Name)
+		}
+		v, err := parseScriptParam(param, value)
+		if err != nil {
+			return nil, fmt.Errorf("the value of param [%s] is invalid: %v", param.Name, err)
+		}
+		resolved[param.Name] = v
+	}
+	for name := range values {
+		if !defined[name] {
+			return nil, fmt.Errorf("the param [%s] is not defined in the script", name)
+		}
+	}
+	return resolved, nil
+}
+
+// parseScriptParam 按参数类型转换参数值, 空值转换为该类型的零值
+func parseScriptParam(param *models.ScriptParam, value string) (any, error) {
+	switch param.Type {
+	case models.ScriptParamInt:
+		if value == "" {
+			return int64(0), nil
+		}
+		return strconv.ParseInt(value, 10, 64)
+	case models.ScriptParamBool:
+		if value == "" {
+			return false, nil
+		}
+		return strconv.ParseBool(value)
+	case models.ScriptParamEnum:
+		for _, option := range param.Options {
+			if option == value {
+				return value, nil
+			}
+		}
+		if value == "" {
+			return "", nil
+		}
+		return nil, fmt.Errorf("[%s] is not one of %v", value, param.Options)
+	default:
+		return value, nil
+	}
+}
+
+// DiffScriptVersions 对比脚本的两个版本, 返回unified diff, to为0时为当前版本
+func (s *MysqlService) DiffScriptVersions(id, from, to uint) (string, error) {
+	fromVersion, err := s.GetScriptVersion(id, from)
+	if err != nil {
+		return "", err
+	}
+	toVersion, err := s.GetScriptVersion(id, to)
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(scriptVersionText(&fromVersion)),
+		B:        difflib.SplitLines(scriptVersionText(&toVersion)),
+		FromFile: fmt.Sprintf("v%d", fromVersion.Version),
+		ToFile:   fmt.Sprintf("v%d", toVersion.Version),
+		Context:  3, //nolint:gomnd
+	})
+}
+
+// scriptVersionText 将版本的解释器、参数与内容转为用于对比的文本
+func scriptVersionText(version *models.SysScriptVersion) string {
+	params := make([]models.ScriptParam, 0)
+	_ = json.Unmarshal(version.Params, &params)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# interpreter: %s\n", version.Interpreter)
+	for _, param := range params { //nolint:gocritic
+		fmt.Fprintf(&b, "# param: %s %s required=%t default=%q", param.Name, param.Type, param.Required, param.Default)
+		if len(param.Options) > 0 {
+			fmt.Fprintf(&b, " options=%s", strings.Join(param.Options, ","))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(version.Content)
+	if !strings.HasSuffix(version.Content, "\n") {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// RunScript 使用参数渲染脚本的指定版本, 通过metaltask在机器上后台执行, 返回的任务可用于查询执行结果
+func (s *MysqlService) RunScript(id uint, req *request.RunScriptRequestStruct, nodeIds []uint) (*models.SysCommandJob, error) {
+	script, err := s.GetScriptById(id)
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.GetScriptVersion(id, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	params := make([]models.ScriptParam, 0)
+	if err = json.Unmarshal(version.Params, &params); err != nil {
+		return nil, fmt.Errorf("the params of the script are invalid: %v", err)
+	}
+	values, err := resolveScriptParams(params, req.Params, true)
+	if err != nil {
+		return nil, err
+	}
+	content, err := version.Render(values)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := getCommandJobRemoteDir(req.RemoteDir)
+	if err != nil {
+		return nil, err
+	}
+	job := s.newCommandJob(models.SysCommandJobKindScript, req.Params)
+	job.Name = fmt.Sprintf("%s v%d", script.Name, version.Version)
+	job.Content = content
+	job.ScriptId = id
+	job.ScriptVersion = version.Version
+	if req.Creator != "" {
+		job.Creator = req.Creator
+	}
+	fileName := fmt.Sprintf("metalflow-script-%d-v%d-%d", id, version.Version, time.Now().UnixNano())
+	if err = s.startCommandJob(job, fileName, dir, nodeIds, &req.CommandJobExecRequestStruct); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
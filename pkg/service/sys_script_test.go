@@ -0,0 +1,160 @@
+package service
+
+import (
+	"metalflow/models"
+	tests2 "metalflow/tests"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestNewScriptVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		interpreter string
+		params      []models.ScriptParam
+		content     string
+		wantErr     bool
+	}{
+		{
+			name:        "success",
+			interpreter: "bash",
+			params: []models.ScriptParam{
+				{Name: "service", Required: true},
+				{Name: "retries", Type: models.ScriptParamInt, Default: "3"},
+				{Name: "mode", Type: models.ScriptParamEnum, Options: []string{"soft", "hard"}},
+			},
+			content: "systemctl restart {{.Params.service}} # {{.Params.retries}} {{.Params.mode}}",
+			wantErr: false,
+		},
+		{
+			name:        "invalid interpreter",
+			interpreter: "bash; rm -rf /",
+			content:     "uptime",
+			wantErr:     true,
+		},
+		{
+			name:        "undefined param",
+			interpreter: "bash",
+			content:     "echo {{.Params.missing}}",
+			wantErr:     true,
+		},
+		{
+			name:        "invalid default",
+			interpreter: "bash",
+			params:      []models.ScriptParam{{Name: "retries", Type: models.ScriptParamInt, Default: "many"}},
+			content:     "echo {{.Params.retries}}",
+			wantErr:     true,
+		},
+		{
+			name:        "enum without options",
+			interpreter: "bash",
+			params:      []models.ScriptParam{{Name: "mode", Type: models.ScriptParamEnum}},
+			content:     "echo {{.Params.mode}}",
+			wantErr:     true,
+		},
+		{
+			name:        "duplicated param",
+			interpreter: "bash",
+			params:      []models.ScriptParam{{Name: "a"}, {Name: "a"}},
+			content:     "echo {{.Params.a}}",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newScriptVersion(tt.interpreter, tt.params, tt.content); (err != nil) != tt.wantErr {
+				t.Errorf("newScriptVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveScriptParams(t *testing.T) {
+	params := []models.ScriptParam{
+		{Name: "service", Type: models.ScriptParamString, Required: true},
+		{Name: "retries", Type: models.ScriptParamInt, Default: "3"},
+		{Name: "force", Type: models.ScriptParamBool},
+		{Name: "mode", Type: models.ScriptParamEnum, Options: []string{"soft", "hard"}, Default: "soft"},
+	}
+	tests := []struct {
+		name    string
+		values  map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success",
+			values:  map[string]string{"service": "it's", "force": "true"},
+			want:    "#!/usr/bin/env bash\nsystemctl restart 'it'\\''s' 3 true 'nginx; reboot' # soft",
+			wantErr: false,
+		},
+		{
+			name:    "enum compared with raw value",
+			values:  map[string]string{"service": "$(reboot)", "mode": "hard"},
+			want:    "#!/usr/bin/env bash\nsystemctl restart '$(reboot)' 3 false 'nginx; reboot' --force # 'hard'",
+			wantErr: false,
+		},
+		{
+			name:    "missing required",
+			values:  map[string]string{"retries": "1"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid int",
+			values:  map[string]string{"service": "nginx", "retries": "x"},
+			wantErr: true,
+		},
+		{
+			name:    "undefined param",
+			values:  map[string]string{"service": "nginx", "other": "1"},
+			wantErr: true,
+		},
+	}
+	version := models.SysScriptVersion{
+		Interpreter: "bash",
+		Content: "systemctl restart {{.Params.service}} {{.Params.retries}} {{.Params.force}} {{quote \"nginx; reboot\"}}" +
+			"{{if eq .Params.mode \"hard\"}} --force # {{quote .Params.mode}}{{else}} # soft{{end}}",
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := resolveScriptParams(params, tt.values, true)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveScriptParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := version.Render(values)
+			if err != nil || got != tt.want {
+				t.Errorf("Render() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMysqlService_DiffScriptVersions(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	columns := []string{"id", "script_id", "version", "interpreter", "params", "content"}
+	mock.ExpectQuery("SELECT (.*) FROM `tb_sys_script_version`").WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 1, 1, "bash", "[]", "echo a\n"))
+	mock.ExpectQuery("SELECT (.*) FROM `tb_sys_script`").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(1, 2))
+	mock.ExpectQuery("SELECT (.*) FROM `tb_sys_script_version`").WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(2, 1, 2, "bash", "[]", "echo b\n"))
+
+	diff, err := s.DiffScriptVersions(1, 1, 0)
+	if err != nil {
+		t.Fatalf("DiffScriptVersions() error = %v", err)
+	}
+	for _, line := range []string{"--- v1", "+++ v2", "-echo a", "+echo b"} {
+		if !strings.Contains(diff, line) {
+			t.Errorf("DiffScriptVersions() = %q, want line %q", diff, line)
+		}
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("DiffScriptVersions() %v", err)
+	}
+}
@@ -12,37 +12,22 @@ import (
 	"strings"
 )
 
-func (s *MysqlService) GetRiskCountById(nodeId uint) (uint, error) {
-	var metalSecure models.SysWorker
-	err := s.TX.Model(new(models.SysWorker)).Where("id = ?", 3).First(&metalSecure).Error //nolint:gomnd
-	if err != nil {
-		return 0, err
-	}
-	var node models.SysNode
-	err = s.TX.Model(models.SysNode{}).Where("id = ?", nodeId).First(&node).Error
-	if err != nil {
-		return 0, err
-	}
-	return grpc.GetRiskCount(node.Address, metalSecure.Port)
+func (s *MysqlService) GetRiskCountById(nodeId uint) (count uint, err error) {
+	err = s.secureScan(nodeId, map[string]any{"scan": "risk"}, func(address string, port int) (string, error) {
+		count, err = grpc.GetRiskCount(address, port)
+		return fmt.Sprintf("%d", count), err
+	})
+	return count, err
 }
 
 func (s *MysqlService) GetNodeImages(nodeId uint) (images datatypes.JSON, err error) {
-	// get metalsecure info from database
-	// TODO 因进行过worker初始化，所以id为2的worker就是metalsecure
-	var metalSecure models.SysWorker
-	err = s.TX.Model(new(models.SysWorker)).Where("id = ?", 3).First(&metalSecure).Error //nolint:gomnd
-	if err != nil {
-		return
-	}
-	var node models.SysNode
-	err = s.TX.Model(models.SysNode{}).Where("id = ?", nodeId).First(&node).Error
-	if err != nil {
-		return
-	}
 	// 直接连接grpc获取内容，不走异步任务
-	images, err = grpc.GetImages(node.Address, metalSecure.Port)
+	err = s.secureScan(nodeId, map[string]any{"scan": "images"}, func(address string, port int) (string, error) {
+		images, err = grpc.GetImages(address, port)
+		return "", err
+	})
 	if err != nil {
-		return
+		return nil, err
 	}
 	return images, nil
 }
@@ -55,77 +40,71 @@ const (
 )
 
 func (s *MysqlService) GetNodeDockerSecureInfo(nodeId uint, req *request.SecureImage) (imagesReport datatypes.JSON, err error) {
-	var metalSecure models.SysWorker
-	err = s.TX.Model(new(models.SysWorker)).Where("id = ?", 3).First(&metalSecure).Error //nolint:gomnd
-	if err != nil {
-		return
-	}
-	var node models.SysNode
-	err = s.TX.Model(models.SysNode{}).Where("id = ?", nodeId).First(&node).Error
-	if err != nil {
-		return
-	}
 	// 直接进行grpc通信获取对应的报告内容
 	images := make([]*securepb.ServerRequest_Spec_Docker_Image, 0)
 	for _, image := range req.Images {
 		images = append(images, &securepb.ServerRequest_Spec_Docker_Image{Repo: image.Repo, Tag: image.Tag})
 	}
-	if req.Category == category {
-		imagesReport, err = grpc.GetDockerSBOM(node.Address, metalSecure.Port, images)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		imagesReport, err = grpc.GetDockerVul(node.Address, metalSecure.Port, images)
-		if err != nil {
-			return nil, err
+	params := map[string]any{"scan": "docker", "category": req.Category, "images": req.Images}
+	err = s.secureScan(nodeId, params, func(address string, port int) (string, error) {
+		if req.Category == category {
+			imagesReport, err = grpc.GetDockerSBOM(address, port, images)
+		} else {
+			imagesReport, err = grpc.GetDockerVul(address, port, images)
 		}
+		return "", err
+	})
+	if err != nil {
+		return nil, err
 	}
 	return imagesReport, nil
 }
 
 func (s *MysqlService) GetNodeBareSecureInfo(nodeId uint, req *request.SecureBare) (bareReport datatypes.JSON, err error) {
-	var metalSecure models.SysWorker
-	err = s.TX.Model(new(models.SysWorker)).Where("id = ?", 3).First(&metalSecure).Error //nolint:gomnd
-	if err != nil {
-		return
-	}
-	var node models.SysNode
-	err = s.TX.Model(models.SysNode{}).Where("id = ?", nodeId).First(&node).Error
-	if err != nil {
-		return
-	}
-
-	if req.Category == category {
-		bareReport, err = grpc.GetBareSBOM(node.Address, metalSecure.Port, req.Paths)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		bareReport, err = grpc.GetBareVul(node.Address, metalSecure.Port, req.Paths)
-		if err != nil {
-			return nil, err
+	params := map[string]any{"scan": "bare", "category": req.Category, "paths": req.Paths}
+	err = s.secureScan(nodeId, params, func(address string, port int) (string, error) {
+		if req.Category == category {
+			bareReport, err = grpc.GetBareSBOM(address, port, req.Paths)
+		} else {
+			bareReport, err = grpc.GetBareVul(address, port, req.Paths)
 		}
+		return "", err
+	})
+	if err != nil {
+		return nil, err
 	}
 	return bareReport, nil
 }
 
 func (s *MysqlService) GetNodeSecureScore(nodeId uint) (score uint, err error) {
-	var metalSecure models.SysWorker
-	err = s.TX.Model(new(models.SysWorker)).Where("id = ?", 3).First(&metalSecure).Error //nolint:gomnd
+	err = s.secureScan(nodeId, map[string]any{"scan": "score"}, func(address string, port int) (string, error) {
+		score, err = grpc.GetSecureScore(address, port)
+		return fmt.Sprintf("%d", score), err
+	})
+	return score, err
+}
+
+// secureScan 通过metalsecure扫描机器并记录为安全扫描操作, 报告由scan返回给调用方, 操作记录的输出只保存风险数、评分等简要结果
+func (s *MysqlService) secureScan(nodeId uint, params map[string]any, scan func(address string, port int) (string, error)) error {
+	metalSecure, err := getWorkerByRole(s.TX, models.SysWorkerRoleSecure)
 	if err != nil {
-		return
+		return err
 	}
-	var node models.SysNode
-	err = s.TX.Model(models.SysNode{}).Where("id = ?", nodeId).First(&node).Error
+	node, err := s.getUnblockedNode(nodeId)
 	if err != nil {
-		return
+		return err
 	}
-
-	return grpc.GetSecureScore(node.Address, metalSecure.Port)
+	action := func(address string, _ func(stream, line string)) (string, error) {
+		return scan(address, metalSecure.Port)
+	}
+	_, err = s.runNodeAction(models.SysCommandJobKindSecureScan, params, node, action)
+	return err
 }
 
 func (s *MysqlService) RunBareSecure(nodeId uint) error {
+	if _, err := s.getUnblockedNode(nodeId); err != nil {
+		return err
+	}
 	ids := []uint{nodeId}
 	secureShell := &secureShellInfo{
 		content: "#!/bin/bash\necho 'hello world!'",
@@ -136,7 +115,7 @@ func (s *MysqlService) RunBareSecure(nodeId uint) error {
 		IsRunnable: true,
 		FileGetter: secureShell,
 	}
-	err := s.BatchUploadByIds(fileMetric, ids)
+	err := s.BatchUploadByIds(models.SysCommandJobKindSecureBare, nil, models.ExecStrategy{}, fileMetric, ids)
 	if err != nil {
 		global.Log.Errorf("执行裸金属安全修复失败：%v", err)
 		return err
@@ -145,6 +124,9 @@ func (s *MysqlService) RunBareSecure(nodeId uint) error {
 }
 
 func (s *MysqlService) RunContainerSecure(nodeId uint) error {
+	if _, err := s.getUnblockedNode(nodeId); err != nil {
+		return err
+	}
 	ids := []uint{nodeId}
 	secureShell := &secureShellInfo{
 		content: "#!/bin/bash\necho 'hello world!'",
@@ -155,7 +137,7 @@ func (s *MysqlService) RunContainerSecure(nodeId uint) error {
 		IsRunnable: true,
 		FileGetter: secureShell,
 	}
-	err := s.BatchUploadByIds(fileMetric, ids)
+	err := s.BatchUploadByIds(models.SysCommandJobKindSecureDocker, nil, models.ExecStrategy{}, fileMetric, ids)
 	if err != nil {
 		global.Log.Errorf("执行docker安全修复失败：%v", err)
 		return err
@@ -164,13 +146,11 @@ func (s *MysqlService) RunContainerSecure(nodeId uint) error {
 }
 
 func (s *MysqlService) FixSecureRisk(nodeId uint, cveId string) error {
-	var metalSecure models.SysWorker
-	err := s.TX.Model(new(models.SysWorker)).Where("id = ?", 3).First(&metalSecure).Error //nolint:gomnd
+	metalSecure, err := getWorkerByRole(s.TX, models.SysWorkerRoleSecure)
 	if err != nil {
 		return err
 	}
-	var node models.SysNode
-	err = s.TX.Model(models.SysNode{}).Where("id = ?", nodeId).First(&node).Error
+	node, err := s.getUnblockedNode(nodeId)
 	if err != nil {
 		return err
 	}
@@ -195,7 +175,7 @@ func (s *MysqlService) FixSecureRisk(nodeId uint, cveId string) error {
 		IsRunnable: true,
 		FileGetter: fixShellFile,
 	}
-	err = s.BatchUploadByIds(fileMetric, ids)
+	err = s.BatchUploadByIds(models.SysCommandJobKindSecureFix, map[string]any{"cveIds": severityIDS}, models.ExecStrategy{}, fileMetric, ids)
 	if err != nil {
 		global.Log.Errorf("执行fix修复脚本失败：%v", err)
 		return err
@@ -113,26 +113,34 @@ func GrpcTask(address string, port int, message string) error {
 	if err := global.Mysql.Model(&models.SysNode{}).Where("address = ?", address).First(&node).Error; err != nil {
 		return err
 	}
-	// 根据规则计算性能
-	var performance uint
-	cupData := GetPerformanceByMetrics(metric.Cpu)
-	ramData := GetPerformanceByMetrics(metric.Ram)
-	score := (cupData*40 + ramData*10) / 100
-	// nolint:gomnd
-	if score < 19 {
-		performance = 2
-	} else if score >= 19 && score < 38 {
-		performance = 1
-	} else {
-		performance = 0
+	// 根据机器的评级规则计算性能
+	profile := getPerformanceProfile(global.Mysql, node.ProfileId)
+	performance := RatePerformance(&profile, &metric)
+
+	// 与上次保存的信息比较, 记录硬件及配置变更
+	changes := detectNodeChanges(&node, &metric)
+	if len(changes) > 0 {
+		if err = global.Mysql.Model(&models.SysNodeChange{}).Create(&changes).Error; err != nil {
+			global.Log.Errorf("[%s]保存机器节点变更记录失败：%v", address, err)
+		}
 	}
 
 	node.Metrics = metricStr
 	node.Information = []byte(information)
 	node.Asset = metric.Assets
-	node.Region = GetAddrByIp(address)
+	node.Region = getRegionByIp(global.Mysql, address)
 	node.Performance = &performance
-	return global.Mysql.Save(&node).Error
+	capacity := grpc.ParseCapacity(&metric)
+	node.CpuCores = capacity.CpuCores
+	node.CpuModel = capacity.CpuModel
+	node.RamBytes = capacity.RamBytes
+	node.DiskBytes = capacity.DiskBytes
+	node.NicSpeed = capacity.NicSpeed
+	if err = global.Mysql.Save(&node).Error; err != nil {
+		return err
+	}
+	// 保留历史采样, 不覆盖之前的数据
+	return saveMetricsSample(node.Id, &metric)
 }
 
 func SecureImagesTask(address string, port int) error {
@@ -238,13 +246,20 @@ func saveSecure2DB(address, modelCol string, ret datatypes.JSON, ns *models.SysN
 	return err
 }
 
-func SetTuneTask(address string, port int, isSave bool) error {
+// SetTuneTask 智能调优, 记录为智能调优操作, creator为发起的用户名, 为空时为系统发起
+func SetTuneTask(address string, port int, isSave bool, creator string) error {
 	var node models.SysNode
 	err := global.Mysql.Model(&models.SysNode{}).Where("address = ?", address).First(&node).Error
 	if err != nil {
 		return err
 	}
-	profile, err := grpc.SetTune(address, port)
+	s := New(nil)
+	s.Operator = creator
+	params := map[string]any{"isSave": isSave}
+	tune := func(address string, _ func(stream, line string)) (string, error) {
+		return grpc.SetTune(address, port)
+	}
+	profile, err := s.runNodeAction(models.SysCommandJobKindTuneAuto, params, &node, tune)
 	if err != nil {
 		return err
 	}
@@ -273,23 +288,6 @@ func InitAsyncTaskMap() map[string]any {
 	return asyncTaskMap
 }
 
-func GetAddrByIp(ip string) string {
-	addrInfos := global.Conf.NodeConf.AddrBind
-	otherAddr := "Other"
-	if len(addrInfos) == 0 {
-		return otherAddr
-	}
-	ip = strings.TrimSpace(ip)
-	for _, addrInfo := range addrInfos {
-		for _, s := range addrInfo.Ips {
-			if strings.HasPrefix(ip, s) {
-				return addrInfo.Addr
-			}
-		}
-	}
-	return otherAddr
-}
-
 func GetPerformanceByMetrics(metric string) int {
 	var data int
 	reg := regexp.MustCompile(`(\d+).*\(.*`)
@@ -76,13 +76,11 @@ func (s *MysqlService) GetTuneLogsByNodeId(nodeId uint, req *request.TuneLogsReq
 }
 
 func (s *MysqlService) Cleanup(nodeId uint) error {
-	var metaltune models.SysWorker
-	err := s.TX.Model(new(models.SysWorker)).Where("id = ?", 4).First(&metaltune).Error //nolint:gomnd
+	metaltune, err := getWorkerByRole(s.TX, models.SysWorkerRoleTune)
 	if err != nil {
 		return err
 	}
-	var node models.SysNode
-	err = s.TX.Model(models.SysNode{}).Where("id = ?", nodeId).First(&node).Error
+	node, err := s.getUnblockedNode(nodeId)
 	if err != nil {
 		return err
 	}
@@ -93,7 +91,7 @@ func (s *MysqlService) Cleanup(nodeId uint) error {
 		Metadata:   &tunepb.Metadata{Name: name},
 		Spec:       &tunepb.Spec{Cleanup: true},
 	}
-	_, err = grpc.SendTuneRequest(node.Address, metaltune.Port, tuneRequest)
+	_, err = s.runNodeAction(models.SysCommandJobKindTuneCleanup, nil, node, tuneAction(metaltune.Port, tuneRequest))
 	if err != nil {
 		return err
 	}
@@ -107,13 +105,11 @@ func (s *MysqlService) Cleanup(nodeId uint) error {
 }
 
 func (s *MysqlService) Rollback(nodeId, logId uint) error {
-	var metaltune models.SysWorker
-	err := s.TX.Model(new(models.SysWorker)).Where("id = ?", 4).First(&metaltune).Error //nolint:gomnd
+	metaltune, err := getWorkerByRole(s.TX, models.SysWorkerRoleTune)
 	if err != nil {
 		return err
 	}
-	var node models.SysNode
-	err = s.TX.Model(models.SysNode{}).Where("id = ?", nodeId).First(&node).Error
+	node, err := s.getUnblockedNode(nodeId)
 	if err != nil {
 		return err
 	}
@@ -154,7 +150,8 @@ func (s *MysqlService) Rollback(nodeId, logId uint) error {
 			},
 		},
 	}
-	_, err = grpc.SendTuneRequest(node.Address, metaltune.Port, tuneReq)
+	params := map[string]any{"logId": logId}
+	_, err = s.runNodeAction(models.SysCommandJobKindTuneRollback, params, node, tuneAction(metaltune.Port, tuneReq))
 	if err != nil {
 		return err
 	}
@@ -162,29 +159,25 @@ func (s *MysqlService) Rollback(nodeId, logId uint) error {
 }
 
 func (s *MysqlService) SetTune(nodeId uint, req *request.TuneAutoSetRequest) error {
-	var metaltune models.SysWorker
-	err := s.TX.Model(new(models.SysWorker)).Where("id = ?", 4).First(&metaltune).Error //nolint:gomnd
+	metaltune, err := getWorkerByRole(s.TX, models.SysWorkerRoleTune)
 	if err != nil {
 		return err
 	}
-	var node models.SysNode
-	err = s.TX.Model(models.SysNode{}).Where("id = ?", nodeId).First(&node).Error
+	node, err := s.getUnblockedNode(nodeId)
 	if err != nil {
 		return err
 	}
-	// 异步请求同步进行智能调优
-	global.Machinery.SetTune(node.Address, metaltune.Port, req.IsSave)
+	// 异步请求同步进行智能调优, 由发起的用户记录操作
+	global.Machinery.SetTune(node.Address, metaltune.Port, req.IsSave, s.Operator)
 	return nil
 }
 
 func (s *MysqlService) SetScene(nodeId uint, scene string) error {
-	var metaltune models.SysWorker
-	err := s.TX.Model(new(models.SysWorker)).Where("id = ?", 4).First(&metaltune).Error //nolint:gomnd
+	metaltune, err := getWorkerByRole(s.TX, models.SysWorkerRoleTune)
 	if err != nil {
 		return err
 	}
-	var node models.SysNode
-	err = s.TX.Model(models.SysNode{}).Where("id = ?", nodeId).First(&node).Error
+	node, err := s.getUnblockedNode(nodeId)
 	if err != nil {
 		return err
 	}
@@ -204,7 +197,8 @@ func (s *MysqlService) SetScene(nodeId uint, scene string) error {
 			},
 		},
 	}
-	_, err = grpc.SendTuneRequest(node.Address, metaltune.Port, tuneReq)
+	params := map[string]any{"scene": scene}
+	_, err = s.runNodeAction(models.SysCommandJobKindTuneScene, params, node, tuneAction(metaltune.Port, tuneReq))
 	if err != nil {
 		return err
 	}
@@ -212,13 +206,11 @@ func (s *MysqlService) SetScene(nodeId uint, scene string) error {
 }
 
 func (s *MysqlService) Turbo(nodeId uint) error {
-	var metaltune models.SysWorker
-	err := s.TX.Model(new(models.SysWorker)).Where("id = ?", 4).First(&metaltune).Error //nolint:gomnd
+	metaltune, err := getWorkerByRole(s.TX, models.SysWorkerRoleTune)
 	if err != nil {
 		return err
 	}
-	var node models.SysNode
-	err = s.TX.Model(models.SysNode{}).Where("id = ?", nodeId).First(&node).Error
+	node, err := s.getUnblockedNode(nodeId)
 	if err != nil {
 		return err
 	}
@@ -231,7 +223,7 @@ func (s *MysqlService) Turbo(nodeId uint) error {
 			Turbo: true,
 		},
 	}
-	_, err = grpc.SendTuneRequest(node.Address, metaltune.Port, tuneReq)
+	_, err = s.runNodeAction(models.SysCommandJobKindTuneTurbo, nil, node, tuneAction(metaltune.Port, tuneReq))
 	if err != nil {
 		return err
 	}
@@ -239,3 +231,10 @@ func (s *MysqlService) Turbo(nodeId uint) error {
 	tuneLog := &models.SysNodeTuneLog{NodeId: nodeId, TuneType: models.TurboType, RespProfile: ""}
 	return s.TX.Model(&models.SysNodeTuneLog{}).Create(tuneLog).Error
 }
+
+// tuneAction 向metaltune发送调优请求
+func tuneAction(port int, req *tunepb.ServerRequest) commandJobAction {
+	return func(address string, _ func(stream, line string)) (string, error) {
+		return grpc.SendTuneRequest(address, port, req)
+	}
+}
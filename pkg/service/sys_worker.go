@@ -1,10 +1,13 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"metalflow/models"
 	"metalflow/pkg/request"
 	"strings"
+
+	"gorm.io/gorm"
 )
 
 func (s *MysqlService) GetWorkers(req *request.WorkerListRequestStruct) ([]models.SysWorker, error) {
@@ -16,6 +19,11 @@ func (s *MysqlService) GetWorkers(req *request.WorkerListRequestStruct) ([]model
 		query = query.Where("name LIKE ?", fmt.Sprintf("%%%s%%", name))
 	}
 
+	role := strings.TrimSpace(req.Role)
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+
 	if req.AutoDeploy != nil {
 		query = query.Where("auto_deploy = ?", *req.AutoDeploy)
 	}
@@ -29,3 +37,68 @@ func (s *MysqlService) GetWorkers(req *request.WorkerListRequestStruct) ([]model
 	err := s.Find(query, &req.PageInfo, &list)
 	return list, err
 }
+
+// getWorkerByRole 按角色获取当前使用的worker, 优先使用主版本, 没有主版本时使用最新创建的
+func getWorkerByRole(tx *gorm.DB, role string) (models.SysWorker, error) {
+	var worker models.SysWorker
+	err := tx.Model(&models.SysWorker{}).Where("role = ?", role).Order("is_primary DESC, id DESC").First(&worker).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return worker, fmt.Errorf("no worker with role [%s] is configured", role)
+	}
+	return worker, err
+}
+
+// GetWorkerByRole 按角色获取当前使用的worker
+func (s *MysqlService) GetWorkerByRole(role string) (models.SysWorker, error) {
+	return getWorkerByRole(s.TX, role)
+}
+
+// CreateWorker 创建worker, 校验部署命令模板, 设置为主版本时取消同角色其他worker的主版本
+func (s *MysqlService) CreateWorker(req *request.CreateWorkerRequestStruct) error {
+	if err := checkWorkerDeployCmd(s.TX, req.DeployCmd); err != nil {
+		return err
+	}
+	req.Role = strings.TrimSpace(req.Role)
+	if req.IsPrimary != nil && *req.IsPrimary == 1 {
+		if req.Role == "" {
+			return fmt.Errorf("the role is required for the primary worker")
+		}
+		if err := s.clearPrimaryWorker(req.Role, 0); err != nil {
+			return err
+		}
+	}
+	return s.Create(req, new(models.SysWorker))
+}
+
+// UpdateWorkerById 更新worker, 校验部署命令模板, 设置为主版本时取消同角色其他worker的主版本
+func (s *MysqlService) UpdateWorkerById(id uint, req *request.UpdateWorkerRequestStruct) error {
+	var worker models.SysWorker
+	if err := s.TX.Where("id = ?", id).First(&worker).Error; err != nil {
+		return err
+	}
+	if req.DeployCmd != "" {
+		if err := checkWorkerDeployCmd(s.TX, req.DeployCmd); err != nil {
+			return err
+		}
+	}
+	role := worker.Role
+	if req.Role = strings.TrimSpace(req.Role); req.Role != "" {
+		role = req.Role
+	}
+	if req.IsPrimary != nil && *req.IsPrimary == 1 {
+		if role == "" {
+			return fmt.Errorf("the role is required for the primary worker")
+		}
+		if err := s.clearPrimaryWorker(role, id); err != nil {
+			return err
+		}
+	}
+	return s.UpdateById(id, req, new(models.SysWorker))
+}
+
+// clearPrimaryWorker 取消同角色其他worker的主版本, 保证每个角色只有一个主版本
+func (s *MysqlService) clearPrimaryWorker(role string, excludeId uint) error {
+	return s.TX.Model(&models.SysWorker{}).
+		Where("role = ? AND id != ? AND is_primary = ?", role, excludeId, 1).
+		Update("is_primary", 0).Error
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"metalflow/models"
+	"metalflow/pkg/request"
+	"sort"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// getDeployCmdData 获取渲染部署命令模板所需的机器、worker与变量信息
+func getDeployCmdData(tx *gorm.DB, node *models.SysNode, worker *models.SysWorker) (*models.DeployCmdData, error) {
+	labels := make([]models.SysLabel, 0)
+	err := tx.Model(&models.SysLabel{}).Where("id IN (?)", tx.Model(&models.RelationNodeLabel{}).
+		Select("sys_label_id").Where("sys_node_id = ?", node.Id)).Find(&labels).Error
+	if err != nil {
+		return nil, err
+	}
+	vars, err := getDeployVars(tx, node.Region)
+	if err != nil {
+		return nil, err
+	}
+	data := &models.DeployCmdData{
+		Node: models.DeployCmdNode{
+			Id:          node.Id,
+			Address:     node.Address,
+			Region:      node.Region,
+			Os:          node.Os,
+			SshPort:     node.SshPort,
+			ServicePort: node.ServicePort,
+			Labels:      make(map[string]models.ShellString, len(labels)),
+		},
+		Worker: models.DeployCmdWorker{
+			Id:      worker.Id,
+			Name:    worker.Name,
+			Role:    worker.Role,
+			Version: worker.Version,
+			Port:    worker.Port,
+		},
+		Vars: make(map[string]models.ShellString, len(vars)),
+	}
+	for _, label := range labels { //nolint:gocritic
+		data.Node.Labels[label.Key] = models.ShellString(label.Value)
+	}
+	for name, value := range vars {
+		data.Vars[name] = models.ShellString(value)
+	}
+	return data, nil
+}
+
+// renderWorkerCmd 按机器渲染worker在该机器操作系统下的部署命令, 没有对应的命令时返回nil
+func renderWorkerCmd(tx *gorm.DB, node *models.SysNode, worker *models.SysWorker) (*models.CmdStruct, error) {
+	cmd, err := worker.GetCmd(node.Os)
+	if err != nil || cmd == nil {
+		return nil, err
+	}
+	data, err := getDeployCmdData(tx, node, worker)
+	if err != nil {
+		return nil, err
+	}
+	return cmd.Render(data)
+}
+
+// checkWorkerDeployCmd 校验部署命令格式, 并使用示例机器与已有的变量渲染各操作系统的命令模板
+func checkWorkerDeployCmd(tx *gorm.DB, deployCmd string) error {
+	osDeployCmd, err := models.ParseDeployCmd([]byte(deployCmd))
+	if err != nil {
+		return fmt.Errorf("the deploy command is invalid: %v", err)
+	}
+	if len(osDeployCmd) == 0 {
+		return errors.New("the deploy command has no os")
+	}
+	names := make([]string, 0)
+	if err = tx.Model(&models.SysDeployVar{}).Distinct("name").Pluck("name", &names).Error; err != nil {
+		return err
+	}
+	data := &models.DeployCmdData{
+		Node: models.DeployCmdNode{Labels: map[string]models.ShellString{}},
+		Vars: make(map[string]models.ShellString, len(names)),
+	}
+	for _, name := range names {
+		data.Vars[name] = ""
+	}
+	oss := make([]string, 0, len(osDeployCmd))
+	for os := range osDeployCmd {
+		oss = append(oss, os)
+	}
+	sort.Strings(oss)
+	for _, os := range oss {
+		if osDeployCmd[os] == nil {
+			continue
+		}
+		if _, err = osDeployCmd[os].Render(data); err != nil {
+			return fmt.Errorf("the %s deploy command is invalid: %v", os, err)
+		}
+	}
+	return nil
+}
+
+// PreviewWorkerCmd 预览worker在指定机器上各操作系统部署命令的渲染结果
+func (s *MysqlService) PreviewWorkerCmd(req *request.WorkerCmdPreviewRequestStruct) (map[string]*models.CmdStruct, error) {
+	var worker models.SysWorker
+	if req.WorkerId > 0 {
+		if err := s.TX.Where("id = ?", req.WorkerId).First(&worker).Error; err != nil {
+			return nil, err
+		}
+	}
+	if req.DeployCmd != "" {
+		worker.DeployCmd = datatypes.JSON(req.DeployCmd)
+	}
+	var node models.SysNode
+	if err := s.TX.Where("id = ?", req.NodeId).First(&node).Error; err != nil {
+		return nil, err
+	}
+	osDeployCmd, err := models.ParseDeployCmd([]byte(worker.DeployCmd.String()))
+	if err != nil {
+		return nil, fmt.Errorf("the deploy command is invalid: %v", err)
+	}
+	data, err := getDeployCmdData(s.TX, &node, &worker)
+	if err != nil {
+		return nil, err
+	}
+	rendered := make(map[string]*models.CmdStruct, len(osDeployCmd))
+	for os, cmd := range osDeployCmd {
+		if cmd == nil {
+			continue
+		}
+		if rendered[os], err = cmd.Render(data); err != nil {
+			return nil, fmt.Errorf("the %s deploy command is invalid: %v", os, err)
+		}
+	}
+	return rendered, nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"metalflow/models"
+	"metalflow/pkg/global"
+	tests2 "metalflow/tests"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCheckWorkerDeployCmd(t *testing.T) {
+	mock := tests2.GetMock()
+	tests := []struct {
+		name      string
+		deployCmd string
+		wantErr   bool
+	}{
+		{
+			name:      "plain commands",
+			deployCmd: `{"linux": {"download": "curl -o a.sh x", "start": "./a.sh start", "stop": "./a.sh stop"}}`,
+		},
+		{
+			name: "template",
+			deployCmd: `{"linux": {"download": "curl {{.Vars.mirror}}/{{.Worker.Name}}-{{.Worker.Version}}.sh", ` +
+				`"start": "./a.sh start {{.Worker.Port}} {{index .Node.Labels \"env\"}}", "stop": ""}}`,
+		},
+		{
+			name:      "unknown variable",
+			deployCmd: `{"linux": {"download": "curl {{.Vars.missing}}"}}`,
+			wantErr:   true,
+		},
+		{
+			name:      "unknown field",
+			deployCmd: `{"windows": {"start": "{{.Node.Hostname}}"}}`,
+			wantErr:   true,
+		},
+		{
+			name:      "syntax error",
+			deployCmd: `{"linux": {"start": "{{.Worker.Port"}}`,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectQuery("SELECT DISTINCT `name` FROM `tb_sys_deploy_var`").
+				WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("mirror"))
+			if err := checkWorkerDeployCmd(global.Mysql, tt.deployCmd); (err != nil) != tt.wantErr {
+				t.Errorf("checkWorkerDeployCmd() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if err := checkWorkerDeployCmd(global.Mysql, "not json"); err == nil {
+		t.Errorf("checkWorkerDeployCmd() should fail for invalid json")
+	}
+}
+
+func TestRenderWorkerCmd(t *testing.T) {
+	mock := tests2.GetMock()
+	node := &models.SysNode{Model: models.Model{Id: 1}, Address: "127.0.0.1", Os: "linux"}
+	worker := &models.SysWorker{Model: models.Model{Id: 2}, Name: "metaltune", DeployCmd: []byte(`{"linux": {` +
+		`"download": "curl -o /tmp/a.sh {{.Vars.mirror}}/a.sh", ` +
+		`"start": "/tmp/a.sh start {{index .Node.Labels \"env\"}}", ` +
+		`"stop": "{{if eq (index .Node.Labels \"env\") \"x; rm -rf /\"}}echo {{quote .Node.Address}}{{end}}"}}`)}
+	mock.ExpectQuery("SELECT (.*) FROM `tb_sys_label`").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "label_key", "label_value"}).AddRow(1, "env", "x; rm -rf /"))
+	mock.ExpectQuery("SELECT (.*) FROM `tb_sys_deploy_var`").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "region", "value"}).AddRow("mirror", "", "http://m/$(id)"))
+	cmd, err := renderWorkerCmd(global.Mysql, node, worker)
+	if err != nil {
+		t.Fatalf("renderWorkerCmd() error = %v", err)
+	}
+	want := models.CmdStruct{
+		Download: "curl -o /tmp/a.sh 'http://m/$(id)'/a.sh",
+		Start:    "/tmp/a.sh start 'x; rm -rf /'",
+		Stop:     "echo '127.0.0.1'",
+	}
+	if *cmd != want {
+		t.Errorf("renderWorkerCmd() = %+v, want %+v", *cmd, want)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("renderWorkerCmd() %v", err)
+	}
+}
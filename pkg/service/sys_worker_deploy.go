@@ -0,0 +1,274 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/grpc"
+	"metalflow/pkg/response"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// worker的部署动作
+const (
+	WorkerActionDeploy   = "deploy"   // 部署, 已运行当前版本的机器跳过
+	WorkerActionRedeploy = "redeploy" // 重新部署, 不论当前状态
+	WorkerActionUndeploy = "undeploy" // 卸载, 执行停止命令
+)
+
+// worker部署命令的步骤
+const (
+	workerStepDownload = "download"
+	workerStepStop     = "stop"
+	workerStepStart    = "start"
+)
+
+const metalBeatStatusOk = 201
+
+// metalBeatClient 请求metalbeat的客户端, 超时时间需覆盖下载worker的耗时, 避免机器无响应时一直等待
+var metalBeatClient = &http.Client{Timeout: 5 * time.Minute} //nolint:gomnd
+
+// MetalBeatResp metalbeat执行命令的返回
+type MetalBeatResp struct {
+	Code int    `json:"code"`
+	Data any    `json:"data"`
+	Msg  string `json:"msg"`
+}
+
+// Output 命令输出
+func (r MetalBeatResp) Output() string {
+	switch data := r.Data.(type) {
+	case nil:
+		return ""
+	case string:
+		return data
+	default:
+		b, _ := json.Marshal(data)
+		return string(b)
+	}
+}
+
+// sendCmd2MetalBeat 通过机器上的metalbeat执行命令, 返回命令输出
+func sendCmd2MetalBeat(address, cmd string, port int) (string, error) {
+	body, err := json.Marshal(map[string]string{"cmd": cmd})
+	if err != nil {
+		return "", fmt.Errorf("marshal request body failed. address: %s, err: %v", address, err)
+	}
+
+	shellUrl := fmt.Sprintf("http://%s:%d/shell", address, port)
+	req, err := http.NewRequest(http.MethodPost, shellUrl, bytes.NewBuffer(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to request. address: %s, err: %v", address, err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	resp, err := metalBeatClient.Do(req) // nolint:bodyclose
+	if err != nil {
+		return "", fmt.Errorf("failed to post. address: %s, err: %v", address, err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("invalid status to send metalbeat:%s cmd", address)
+	}
+	ret, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read metalbeat:%s resp", address)
+	}
+	metalBeatResp := MetalBeatResp{}
+	err = json.Unmarshal(ret, &metalBeatResp)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal metalbeat:%s resp", address)
+	}
+	if metalBeatResp.Code != metalBeatStatusOk {
+		return metalBeatResp.Output(), fmt.Errorf("send cmd to metalbeat:%s failed, err: %s", address, metalBeatResp.Msg)
+	}
+	return metalBeatResp.Output(), nil
+}
+
+// DeployWorker 创建在多台机器上执行worker部署/重新部署/卸载的任务并在后台执行, 返回的任务可用于查询每台机器的进度与结果
+func (s *MysqlService) DeployWorker(workerId uint, nodeIds []uint, action string) (*models.SysCommandJob, error) {
+	if action != WorkerActionDeploy && action != WorkerActionRedeploy && action != WorkerActionUndeploy {
+		return nil, fmt.Errorf("unsupported worker action [%s]", action)
+	}
+	var worker models.SysWorker
+	if err := s.TX.Where("id = ?", workerId).First(&worker).Error; err != nil {
+		return nil, err
+	}
+	nodes := make([]*models.SysNode, 0)
+	if err := s.TX.Where("id IN (?)", nodeIds).Order("id").Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("no nodes are selected")
+	}
+	job := s.newCommandJob(models.SysCommandJobKindWorkerDeploy, map[string]any{
+		"workerId": worker.Id,
+		"version":  worker.Version,
+		"action":   action,
+	})
+	job.Name = fmt.Sprintf("%s %s", action, worker.Name)
+	// 请求可能处于事务中, 任务在后台执行, 使用无事务的连接记录
+	if err := createCommandJob(s.DB, job, grpc.FileMetric{}, nodes); err != nil {
+		return nil, err
+	}
+	// 后台执行时使用副本, 避免与返回的任务同时读写
+	running := *job
+	running.Nodes = append([]models.SysCommandJobNode(nil), job.Nodes...)
+	go runCommandJob(s.DB, &running, workerDeployAction(s.DB, nodes, &worker, action))
+	return job, nil
+}
+
+// workerDeployAction 在单台机器上执行worker的部署动作, 每个步骤结束后回调输出, 部署失败时返回错误
+func workerDeployAction(tx *gorm.DB, nodes []*models.SysNode, worker *models.SysWorker, action string) commandJobAction {
+	nodeMap := make(map[string]*models.SysNode, len(nodes))
+	for _, node := range nodes {
+		nodeMap[node.Address] = node
+	}
+	return func(address string, onLine func(stream, line string)) (string, error) {
+		node := nodeMap[address]
+		result := runWorkerAction(tx, node, worker, action, func(step response.WorkerDeployStepStruct) {
+			for _, line := range strings.Split(strings.TrimRight(step.Output, "\n"), "\n") {
+				onLine("stdout", fmt.Sprintf("[%s] %s", step.Step, line))
+			}
+			if step.Error != "" {
+				onLine("stderr", fmt.Sprintf("[%s] %s", step.Step, step.Error))
+			}
+		})
+		if result.Skipped {
+			onLine("stdout", fmt.Sprintf("worker [%s] %s is already running, skipped", worker.Name, worker.Version))
+		}
+		if !result.Success {
+			return "", errors.New(result.Error)
+		}
+		return "", nil
+	}
+}
+
+// deployWorkerOnNodes 在多台机器上并发执行worker的部署动作, 结果与机器顺序一致
+func deployWorkerOnNodes(tx *gorm.DB, nodes []models.SysNode, worker *models.SysWorker, action string) []response.WorkerDeployResultStruct {
+	var (
+		results = make([]response.WorkerDeployResultStruct, len(nodes))
+		wg      = sync.WaitGroup{}
+		// nolint:gomnd
+		tokens = make(chan struct{}, 10)
+	)
+	for i := range nodes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// 限制同时部署的机器数量
+			tokens <- struct{}{}
+			results[i] = runWorkerAction(tx, &nodes[i], worker, action, nil)
+			<-tokens
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// RunWorkerAction 在单台机器上执行worker的部署/重新部署/卸载, 机器需已有操作系统与metalbeat端口信息
+func (s *MysqlService) RunWorkerAction(node *models.SysNode, worker *models.SysWorker, action string) response.WorkerDeployResultStruct {
+	return runWorkerAction(s.TX, node, worker, action, nil)
+}
+
+// runWorkerAction 依次执行部署命令并记录worker在机器上的部署状态, onStep不为空时在每个步骤结束后回调
+func runWorkerAction(tx *gorm.DB, node *models.SysNode, worker *models.SysWorker, action string,
+	onStep func(step response.WorkerDeployStepStruct)) response.WorkerDeployResultStruct {
+	result := response.WorkerDeployResultStruct{
+		NodeId:  node.Id,
+		Address: node.Address,
+		Steps:   make([]response.WorkerDeployStepStruct, 0),
+	}
+	runStep := func(step, cmd string) string {
+		count := len(result.Steps)
+		e := runWorkerStep(&result, node, step, cmd)
+		// 命令为空时没有执行步骤
+		if onStep != nil && len(result.Steps) > count {
+			onStep(result.Steps[count])
+		}
+		return e
+	}
+	finish := func(status uint, lastError string) response.WorkerDeployResultStruct {
+		result.Status = status
+		result.Success = lastError == ""
+		result.Error = lastError
+		if err := setNodeWorkerStatus(tx, node.Id, worker.Id, status, worker.Version, lastError); err != nil {
+			global.Log.Errorf("record worker [%s] status on %s failed: %v", worker.Name, node.Address, err)
+		}
+		return result
+	}
+
+	if action == WorkerActionDeploy && isWorkerUpToDate(tx, node.Id, worker) {
+		result.Skipped = true
+		result.Success = true
+		result.Status = models.NodeWorkerRunning
+		return result
+	}
+	cmd, err := renderWorkerCmd(tx, node, worker)
+	if err != nil {
+		return finish(models.NodeWorkerFailed, err.Error())
+	}
+	if cmd == nil {
+		return finish(models.NodeWorkerFailed, fmt.Sprintf("no deploy commands of worker [%s] for os [%s]", worker.Name, node.Os))
+	}
+
+	if action == WorkerActionUndeploy {
+		if e := runStep(workerStepStop, cmd.Stop); e != "" {
+			return finish(models.NodeWorkerFailed, e)
+		}
+		return finish(models.NodeWorkerStopped, "")
+	}
+
+	// 记录部署中状态后再发送命令
+	if err = setNodeWorkerStatus(tx, node.Id, worker.Id, models.NodeWorkerDeploying, "", ""); err != nil {
+		global.Log.Errorf("record worker [%s] status on %s failed: %v", worker.Name, node.Address, err)
+	}
+	if e := runStep(workerStepDownload, cmd.Download); e != "" {
+		return finish(models.NodeWorkerFailed, e)
+	}
+	// 启动前先停止, 未运行时停止失败不影响部署
+	_ = runStep(workerStepStop, cmd.Stop)
+	if e := runStep(workerStepStart, cmd.Start); e != "" {
+		return finish(models.NodeWorkerFailed, e)
+	}
+	return finish(models.NodeWorkerRunning, "")
+}
+
+// runWorkerStep 执行单个部署步骤, 命令为空时跳过, 失败时返回错误信息
+func runWorkerStep(result *response.WorkerDeployResultStruct, node *models.SysNode, step, cmd string) string {
+	if cmd == "" {
+		return ""
+	}
+	output, err := sendCmd2MetalBeat(node.Address, cmd, node.ServicePort)
+	item := response.WorkerDeployStepStruct{Step: step, Output: output}
+	if err != nil {
+		item.Error = err.Error()
+	}
+	result.Steps = append(result.Steps, item)
+	if item.Error != "" {
+		return fmt.Sprintf("%s: %s", step, item.Error)
+	}
+	return ""
+}
+
+// isWorkerUpToDate 机器上的worker是否已运行当前版本, worker未配置版本时总是重新部署
+func isWorkerUpToDate(tx *gorm.DB, nodeId uint, worker *models.SysWorker) bool {
+	if worker.Version == "" {
+		return false
+	}
+	var relation models.RelationNodeWorker
+	err := tx.Where("sys_node_id = ? AND sys_worker_id = ?", nodeId, worker.Id).First(&relation).Error
+	return err == nil && relation.Status != nil && *relation.Status == models.NodeWorkerRunning &&
+		relation.Version == worker.Version
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"encoding/json"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	tests2 "metalflow/tests"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/datatypes"
+)
+
+// newMetalBeatServer 模拟metalbeat, 命令为fail时返回执行失败
+func newMetalBeatServer(t *testing.T, cmds *[]string) (string, int) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		*cmds = append(*cmds, body["cmd"])
+		resp := MetalBeatResp{Code: metalBeatStatusOk, Data: "ok: " + body["cmd"]}
+		if body["cmd"] == "fail" {
+			resp = MetalBeatResp{Code: http.StatusInternalServerError, Data: "exit 1", Msg: "command failed"}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return host, p
+}
+
+func TestMysqlService_RunWorkerAction(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	cmds := make([]string, 0)
+	host, port := newMetalBeatServer(t, &cmds)
+	node := &models.SysNode{Model: models.Model{Id: 1}, Address: host, ServicePort: port, Os: "linux"}
+	expectStatus := func(exists bool) {
+		rows := sqlmock.NewRows([]string{"sys_node_id", "sys_worker_id"})
+		if exists {
+			rows.AddRow(1, 2)
+		}
+		mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_worker_relation`").WithArgs(1, 2).WillReturnRows(rows)
+		mock.ExpectBegin()
+		if exists {
+			mock.ExpectExec("UPDATE `tb_sys_node_worker_relation`").WillReturnResult(sqlmock.NewResult(0, 1))
+		} else {
+			mock.ExpectExec("INSERT INTO `tb_sys_node_worker_relation`").WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+	}
+	// 渲染部署命令模板时查询机器标签与部署变量
+	expectRender := func() {
+		mock.ExpectQuery("SELECT (.*) FROM `tb_sys_label`").WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "label_key", "label_value"}).AddRow(1, "env", "ci"))
+		mock.ExpectQuery("SELECT (.*) FROM `tb_sys_deploy_var`").
+			WillReturnRows(sqlmock.NewRows([]string{"name", "region", "value"}).AddRow("mirror", "", "http://mirror"))
+	}
+	tests := []struct {
+		name       string
+		deployCmd  string
+		action     string
+		invoke     func()
+		wantCmds   []string
+		wantStatus uint
+		wantErr    bool
+	}{
+		{
+			name: "deploy",
+			deployCmd: `{"linux": {"download": "get {{.Vars.mirror}}/{{.Worker.Name}}", ` +
+				`"start": "start {{index .Node.Labels \"env\"}}", "stop": "stop"}}`,
+			action: WorkerActionDeploy,
+			invoke: func() {
+				expectRender()
+				expectStatus(false)
+				expectStatus(true)
+			},
+			wantCmds:   []string{"get 'http://mirror'/metaltune", "stop", "start 'ci'"},
+			wantStatus: models.NodeWorkerRunning,
+		},
+		{
+			name:      "download failed",
+			deployCmd: `{"linux": {"download": "fail", "start": "start", "stop": "stop"}}`,
+			action:    WorkerActionRedeploy,
+			invoke: func() {
+				expectRender()
+				expectStatus(true)
+				expectStatus(true)
+			},
+			wantCmds:   []string{"fail"},
+			wantStatus: models.NodeWorkerFailed,
+			wantErr:    true,
+		},
+		{
+			name:      "undeploy",
+			deployCmd: `{"linux": {"download": "get", "start": "start", "stop": "stop"}}`,
+			action:    WorkerActionUndeploy,
+			invoke: func() {
+				expectRender()
+				expectStatus(true)
+			},
+			wantCmds:   []string{"stop"},
+			wantStatus: models.NodeWorkerStopped,
+		},
+		{
+			name:      "no commands for os",
+			deployCmd: `{"windows": {"download": "get", "start": "start", "stop": ""}}`,
+			action:    WorkerActionDeploy,
+			invoke: func() {
+				expectStatus(true)
+			},
+			wantCmds:   []string{},
+			wantStatus: models.NodeWorkerFailed,
+			wantErr:    true,
+		},
+		{
+			name:      "unknown variable",
+			deployCmd: `{"linux": {"download": "get {{.Vars.unknown}}", "start": "start", "stop": "stop"}}`,
+			action:    WorkerActionDeploy,
+			invoke: func() {
+				expectRender()
+				expectStatus(true)
+			},
+			wantCmds:   []string{},
+			wantStatus: models.NodeWorkerFailed,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds = cmds[:0]
+			tt.invoke()
+			worker := &models.SysWorker{Model: models.Model{Id: 2}, Name: "metaltune", DeployCmd: datatypes.JSON(tt.deployCmd)}
+			result := s.RunWorkerAction(node, worker, tt.action)
+			if result.Success == tt.wantErr || result.Status != tt.wantStatus {
+				t.Errorf("RunWorkerAction() = %+v, want status %v, wantErr %v", result, tt.wantStatus, tt.wantErr)
+			}
+			if len(cmds) != len(tt.wantCmds) {
+				t.Fatalf("RunWorkerAction() ran %v, want %v", cmds, tt.wantCmds)
+			}
+			for i := range cmds {
+				if cmds[i] != tt.wantCmds[i] {
+					t.Errorf("RunWorkerAction() ran %v, want %v", cmds, tt.wantCmds)
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("RunWorkerAction() %v", err)
+			}
+		})
+	}
+}
+
+func TestWorkerDeployAction(t *testing.T) {
+	mock := tests2.GetMock()
+	tests2.SetLog()
+	cmds := make([]string, 0)
+	host, port := newMetalBeatServer(t, &cmds)
+	node := &models.SysNode{Model: models.Model{Id: 1}, Address: host, ServicePort: port, Os: "linux"}
+	tests := []struct {
+		name      string
+		deployCmd string
+		wantLines []string
+		wantErr   bool
+	}{
+		{
+			name:      "deploy",
+			deployCmd: `{"linux": {"download": "get", "start": "start", "stop": "stop"}}`,
+			wantLines: []string{"stdout [download] ok: get", "stdout [stop] ok: stop", "stdout [start] ok: start"},
+		},
+		{
+			name:      "start failed",
+			deployCmd: `{"linux": {"download": "get", "start": "fail", "stop": ""}}`,
+			wantLines: []string{"stdout [download] ok: get", "stdout [start] exit 1",
+				"stderr [start] send cmd to metalbeat:" + host + " failed, err: command failed"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds = cmds[:0]
+			mock.ExpectQuery("SELECT (.*) FROM `tb_sys_label`").WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "label_key", "label_value"}))
+			mock.ExpectQuery("SELECT (.*) FROM `tb_sys_deploy_var`").
+				WillReturnRows(sqlmock.NewRows([]string{"name", "region", "value"}))
+			for i := 0; i < 2; i++ {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node_worker_relation`").WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"sys_node_id", "sys_worker_id"}).AddRow(1, 2))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `tb_sys_node_worker_relation`").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+			worker := &models.SysWorker{Model: models.Model{Id: 2}, Name: "metaltune", DeployCmd: datatypes.JSON(tt.deployCmd)}
+			action := workerDeployAction(global.Mysql, []*models.SysNode{node}, worker, WorkerActionRedeploy)
+			lines := make([]string, 0)
+			_, err := action(host, func(stream, line string) {
+				lines = append(lines, stream+" "+line)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("workerDeployAction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(lines, "\n") != strings.Join(tt.wantLines, "\n") {
+				t.Errorf("workerDeployAction() lines = %q, want %q", lines, tt.wantLines)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("workerDeployAction() %v", err)
+			}
+		})
+	}
+}
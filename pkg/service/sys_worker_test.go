@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
+	"metalflow/models"
 	"metalflow/pkg/request"
 	tests2 "metalflow/tests"
 	"testing"
@@ -67,3 +68,49 @@ func TestMysqlService_GetWorkers(t *testing.T) {
 		})
 	}
 }
+
+func TestMysqlService_GetWorkerByRole(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	tests := []struct {
+		name     string
+		role     string
+		invoke   func(role string)
+		wantPort int
+		wantErr  bool
+	}{
+		{
+			name: "not configured",
+			role: models.SysWorkerRoleTune,
+			invoke: func(role string) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_worker`").WithArgs(role).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: true,
+		},
+		{
+			name: "success",
+			role: models.SysWorkerRoleMetrics,
+			invoke: func(role string) {
+				mock.ExpectQuery("SELECT (.*) FROM `tb_sys_worker`").WithArgs(role).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "role", "port"}).
+						AddRow(3, "metalmetrics-v2", role, 19095))
+			},
+			wantPort: 19095,
+			wantErr:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke(tt.role)
+			worker, err := s.GetWorkerByRole(tt.role)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetWorkerByRole() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if worker.Port != tt.wantPort {
+				t.Errorf("GetWorkerByRole() port = %v, want %v", worker.Port, tt.wantPort)
+			}
+		})
+	}
+}
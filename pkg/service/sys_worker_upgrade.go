@@ -0,0 +1,406 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"metalflow/models"
+	"metalflow/pkg/global"
+	"metalflow/pkg/request"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// runningWorkerUpgrades 正在后台执行的升级任务, 保证同一任务只有一个执行者
+var runningWorkerUpgrades sync.Map
+
+// GetWorkerUpgrades 获取worker升级任务列表
+func (s *MysqlService) GetWorkerUpgrades(req *request.WorkerUpgradeListRequestStruct) ([]models.SysWorkerUpgrade, error) {
+	list := make([]models.SysWorkerUpgrade, 0)
+	query := s.TX.Model(&models.SysWorkerUpgrade{}).Order("created_at DESC")
+	if req.WorkerId > 0 {
+		query = query.Where("worker_id = ?", req.WorkerId)
+	}
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+	creator := strings.TrimSpace(req.Creator)
+	if creator != "" {
+		query = query.Where("creator LIKE ?", fmt.Sprintf("%%%s%%", creator))
+	}
+	err := s.Find(query, &req.PageInfo, &list)
+	return list, err
+}
+
+// GetWorkerUpgradeById 获取升级任务及其各批次的机器
+func (s *MysqlService) GetWorkerUpgradeById(id uint) (models.SysWorkerUpgrade, error) {
+	var upgrade models.SysWorkerUpgrade
+	err := s.TX.Preload("Nodes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("batch, id")
+	}).Where("id = ?", id).First(&upgrade).Error
+	return upgrade, err
+}
+
+// CreateWorkerUpgrade 创建worker升级任务, 金丝雀机器为第0批, 其余机器按每批机器数划分批次
+func (s *MysqlService) CreateWorkerUpgrade(req *request.CreateWorkerUpgradeRequestStruct, nodeIds []uint) (
+	*models.SysWorkerUpgrade, error) {
+	var worker models.SysWorker
+	if err := s.TX.Where("id = ?", req.WorkerId).First(&worker).Error; err != nil {
+		return nil, err
+	}
+	if err := checkWorkerDeployCmd(s.TX, req.DeployCmd); err != nil {
+		return nil, err
+	}
+	// 同一worker同时只能有一个未结束的升级任务
+	var count int64
+	err := s.TX.Model(&models.SysWorkerUpgrade{}).Where("worker_id = ? AND status IN (?)", worker.Id, []uint{
+		models.SysWorkerUpgradePending,
+		models.SysWorkerUpgradeRunning,
+		models.SysWorkerUpgradePaused,
+		models.SysWorkerUpgradeRollingBack,
+	}).Count(&count).Error
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("worker [%s] already has an unfinished upgrade", worker.Name)
+	}
+	nodes := make([]models.SysNode, 0)
+	if err = s.TX.Where("id IN (?)", nodeIds).Order("id").Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("no nodes are selected")
+	}
+
+	upgradeNodes, batchCount := splitWorkerUpgradeBatches(nodes, req.CanaryIds, req.BatchSize)
+	status := models.SysWorkerUpgradePending
+	onFailure := models.SysWorkerUpgradeOnFailurePause
+	if req.OnFailure != nil {
+		onFailure = *req.OnFailure
+	}
+	upgrade := &models.SysWorkerUpgrade{
+		WorkerId:      worker.Id,
+		WorkerName:    worker.Name,
+		Version:       req.Version,
+		DeployCmd:     datatypes.JSON(req.DeployCmd),
+		PrevVersion:   worker.Version,
+		PrevDeployCmd: worker.DeployCmd,
+		BatchSize:     req.BatchSize,
+		BatchCount:    batchCount,
+		MaxFailures:   req.MaxFailures,
+		OnFailure:     &onFailure,
+		CheckDelay:    req.CheckDelay,
+		Status:        &status,
+		Creator:       req.Creator,
+		Nodes:         upgradeNodes,
+	}
+	err = s.TX.Create(upgrade).Error
+	return upgrade, err
+}
+
+// splitWorkerUpgradeBatches 划分升级批次, 没有指定金丝雀机器时取第一台机器, 返回机器及总批次数
+func splitWorkerUpgradeBatches(nodes []models.SysNode, canaryIds []uint, batchSize uint) ([]models.SysWorkerUpgradeNode, uint) {
+	if batchSize == 0 {
+		batchSize = 1
+	}
+	canary := make(map[uint]bool, len(canaryIds))
+	for _, id := range canaryIds {
+		canary[id] = true
+	}
+	matched := false
+	for _, node := range nodes { //nolint:gocritic
+		if canary[node.Id] {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		canary = map[uint]bool{nodes[0].Id: true}
+	}
+
+	upgradeNodes := make([]models.SysWorkerUpgradeNode, 0, len(nodes))
+	var rest uint
+	for _, node := range nodes { //nolint:gocritic
+		batch := uint(0)
+		if !canary[node.Id] {
+			batch = rest/batchSize + 1
+			rest++
+		}
+		status := models.SysWorkerUpgradeNodePending
+		upgradeNodes = append(upgradeNodes, models.SysWorkerUpgradeNode{
+			NodeId:  node.Id,
+			Address: node.Address,
+			Batch:   batch,
+			Status:  &status,
+		})
+	}
+	return upgradeNodes, (rest+batchSize-1)/batchSize + 1
+}
+
+// changeWorkerUpgradeStatus 升级任务处于from中的状态时更新, 否则返回错误
+func (s *MysqlService) changeWorkerUpgradeStatus(id uint, updates map[string]any, from ...uint) error {
+	// 后台执行者使用无事务的连接读取状态, 状态变更不放在请求事务中, 避免其读到旧状态
+	query := s.DB.Model(&models.SysWorkerUpgrade{}).Where("id = ? AND status IN (?)", id, from).Updates(updates)
+	if query.Error != nil {
+		return query.Error
+	}
+	if query.RowsAffected == 0 {
+		return fmt.Errorf("the upgrade %d does not exist or its status does not allow this operation", id)
+	}
+	return nil
+}
+
+// StartWorkerUpgrade 开始或继续升级任务, 继续时重新执行当前批次中未成功的机器
+func (s *MysqlService) StartWorkerUpgrade(id uint) error {
+	err := s.changeWorkerUpgradeStatus(id, map[string]any{
+		"status":     models.SysWorkerUpgradeRunning,
+		"last_error": "",
+	}, models.SysWorkerUpgradePending, models.SysWorkerUpgradePaused)
+	if err != nil {
+		return err
+	}
+	go RunWorkerUpgrade(id)
+	return nil
+}
+
+// PauseWorkerUpgrade 暂停升级任务, 当前批次执行完成后生效
+func (s *MysqlService) PauseWorkerUpgrade(id uint) error {
+	return s.changeWorkerUpgradeStatus(id, map[string]any{
+		"status": models.SysWorkerUpgradePaused,
+	}, models.SysWorkerUpgradeRunning)
+}
+
+// RollbackWorkerUpgrade 将已升级的机器回滚到升级前的版本, 升级中的任务在当前批次执行完成后回滚
+func (s *MysqlService) RollbackWorkerUpgrade(id uint) error {
+	err := s.changeWorkerUpgradeStatus(id, map[string]any{
+		"status": models.SysWorkerUpgradeRollingBack,
+	}, models.SysWorkerUpgradeRunning, models.SysWorkerUpgradePaused, models.SysWorkerUpgradeCompleted)
+	if err != nil {
+		return err
+	}
+	go RunWorkerUpgrade(id)
+	return nil
+}
+
+// CancelWorkerUpgrade 取消未开始或已暂停的升级任务, 已升级的机器保持不变
+func (s *MysqlService) CancelWorkerUpgrade(id uint) error {
+	return s.changeWorkerUpgradeStatus(id, map[string]any{
+		"status": models.SysWorkerUpgradeCancelled,
+	}, models.SysWorkerUpgradePending, models.SysWorkerUpgradePaused)
+}
+
+// ResumeWorkerUpgrades 服务启动时继续执行重启前未完成的升级与回滚
+func ResumeWorkerUpgrades() {
+	ids := make([]uint, 0)
+	err := global.Mysql.Model(&models.SysWorkerUpgrade{}).Where("status IN (?)", []uint{
+		models.SysWorkerUpgradeRunning,
+		models.SysWorkerUpgradeRollingBack,
+	}).Pluck("id", &ids).Error
+	if err != nil {
+		global.Log.Error("[worker升级]查询未完成的升级任务失败：", err)
+		return
+	}
+	for _, id := range ids {
+		global.Log.Infof("[worker升级]继续执行升级任务%d", id)
+		go RunWorkerUpgrade(id)
+	}
+}
+
+// RunWorkerUpgrade 在后台逐批执行升级任务, 直到任务完成、暂停或回滚结束
+func RunWorkerUpgrade(id uint) {
+	if _, running := runningWorkerUpgrades.LoadOrStore(id, true); running {
+		return
+	}
+	defer runningWorkerUpgrades.Delete(id)
+	for {
+		// 每批开始前重新读取任务, 以响应暂停与回滚
+		var upgrade models.SysWorkerUpgrade
+		err := global.Mysql.Preload("Nodes").Where("id = ?", id).First(&upgrade).Error
+		if err != nil {
+			global.Log.Errorf("[worker升级]查询升级任务%d失败：%v", id, err)
+			return
+		}
+		switch *upgrade.Status {
+		case models.SysWorkerUpgradeRunning:
+			err = runWorkerUpgradeBatch(global.Mysql, &upgrade)
+		case models.SysWorkerUpgradeRollingBack:
+			err = rollbackWorkerUpgrade(global.Mysql, &upgrade)
+		default:
+			return
+		}
+		if err != nil {
+			global.Log.Errorf("[worker升级]执行升级任务%d失败：%v", id, err)
+			// 数据库等异常时暂停任务, 避免反复重试
+			_ = updateWorkerUpgradeStatus(global.Mysql, id, models.SysWorkerUpgradePaused, err.Error(),
+				models.SysWorkerUpgradeRunning, models.SysWorkerUpgradeRollingBack)
+			return
+		}
+	}
+}
+
+// updateWorkerUpgradeStatus 更新升级任务状态, 传了from时只在任务处于其中的状态时更新, 避免覆盖执行期间被暂停或取消的状态
+func updateWorkerUpgradeStatus(tx *gorm.DB, id, status uint, lastError string, from ...uint) error {
+	query := tx.Model(&models.SysWorkerUpgrade{}).Where("id = ?", id)
+	if len(from) > 0 {
+		query = query.Where("status IN (?)", from)
+	}
+	return query.Updates(map[string]any{"status": status, "last_error": lastError}).Error
+}
+
+// runWorkerUpgradeBatch 执行当前批次, 失败机器数超过阈值时暂停或回滚, 全部批次完成后更新worker的版本与部署命令
+func runWorkerUpgradeBatch(tx *gorm.DB, upgrade *models.SysWorkerUpgrade) error {
+	if upgrade.CurrentBatch >= upgrade.BatchCount {
+		err := tx.Model(&models.SysWorker{}).Where("id = ?", upgrade.WorkerId).
+			Updates(map[string]any{"version": upgrade.Version, "deploy_cmd": upgrade.DeployCmd}).Error
+		if err != nil {
+			return err
+		}
+		global.Log.Infof("[worker升级]升级任务%d完成, %s已升级到%s", upgrade.Id, upgrade.WorkerName, upgrade.Version)
+		return updateWorkerUpgradeStatus(tx, upgrade.Id, models.SysWorkerUpgradeCompleted, "", models.SysWorkerUpgradeRunning)
+	}
+	var worker models.SysWorker
+	if err := tx.Where("id = ?", upgrade.WorkerId).First(&worker).Error; err != nil {
+		return err
+	}
+	worker.Version = upgrade.Version
+	worker.DeployCmd = upgrade.DeployCmd
+
+	nodes := make([]models.SysWorkerUpgradeNode, 0)
+	for _, node := range upgrade.Nodes { //nolint:gocritic
+		if node.Batch == upgrade.CurrentBatch && *node.Status != models.SysWorkerUpgradeNodeSuccess {
+			nodes = append(nodes, node)
+		}
+	}
+	failures, err := upgradeWorkerOnNodes(tx, &worker, nodes, upgrade.CheckDelay)
+	if err != nil {
+		return err
+	}
+	if failures > upgrade.MaxFailures {
+		status := models.SysWorkerUpgradePaused
+		if *upgrade.OnFailure == models.SysWorkerUpgradeOnFailureRollback {
+			status = models.SysWorkerUpgradeRollingBack
+		}
+		msg := fmt.Sprintf("%d nodes failed in batch %d, exceeds the threshold %d", failures, upgrade.CurrentBatch, upgrade.MaxFailures)
+		global.Log.Warnf("[worker升级]升级任务%d：%s", upgrade.Id, msg)
+		return updateWorkerUpgradeStatus(tx, upgrade.Id, status, msg, models.SysWorkerUpgradeRunning)
+	}
+	return tx.Model(&models.SysWorkerUpgrade{}).Where("id = ?", upgrade.Id).
+		Update("current_batch", upgrade.CurrentBatch+1).Error
+}
+
+// upgradeWorkerOnNodes 在一批机器上重新部署worker并进行健康检查, 返回失败的机器数
+func upgradeWorkerOnNodes(tx *gorm.DB, worker *models.SysWorker, upgradeNodes []models.SysWorkerUpgradeNode, checkDelay uint) (
+	uint, error) {
+	nodes, err := getWorkerUpgradeSysNodes(tx, upgradeNodes)
+	if err != nil {
+		return 0, err
+	}
+	results := deployWorkerOnNodes(tx, nodes, worker, WorkerActionRedeploy)
+	if checkDelay > 0 {
+		time.Sleep(time.Duration(checkDelay) * time.Second)
+	}
+	deployed := make(map[uint]string, len(results))
+	for _, result := range results { //nolint:gocritic
+		deployed[result.NodeId] = result.Error
+	}
+
+	var failures uint
+	for i := range upgradeNodes {
+		node := &upgradeNodes[i]
+		status := models.SysWorkerUpgradeNodeFailed
+		version := ""
+		lastError, ok := deployed[node.NodeId]
+		if !ok {
+			lastError = "the node does not exist"
+		} else if lastError == "" {
+			// 部署成功后通过CheckReq确认worker正常
+			relation := models.RelationNodeWorker{SysNodeId: node.NodeId, SysWorkerId: worker.Id}
+			passed, e := checkNodeWorker(tx, node.Address, worker, &relation)
+			if e != nil {
+				return 0, e
+			}
+			if passed {
+				status = models.SysWorkerUpgradeNodeSuccess
+				version = relation.Version
+			} else {
+				lastError = relation.LastError
+			}
+		}
+		if status != models.SysWorkerUpgradeNodeSuccess {
+			failures++
+		}
+		err = tx.Model(&models.SysWorkerUpgradeNode{}).Where("id = ?", node.Id).
+			Updates(map[string]any{"status": status, "version": version, "error": lastError}).Error
+		if err != nil {
+			return 0, err
+		}
+	}
+	return failures, nil
+}
+
+// getWorkerUpgradeSysNodes 获取升级任务机器对应的机器信息, 已删除的机器不返回
+func getWorkerUpgradeSysNodes(tx *gorm.DB, upgradeNodes []models.SysWorkerUpgradeNode) ([]models.SysNode, error) {
+	nodes := make([]models.SysNode, 0)
+	if len(upgradeNodes) == 0 {
+		return nodes, nil
+	}
+	ids := make([]uint, 0, len(upgradeNodes))
+	for _, node := range upgradeNodes { //nolint:gocritic
+		ids = append(ids, node.NodeId)
+	}
+	err := tx.Where("id IN (?)", ids).Find(&nodes).Error
+	return nodes, err
+}
+
+// rollbackWorkerUpgrade 将已执行过升级的机器重新部署为升级前的版本, 任务已完成时同时恢复worker的版本与部署命令
+func rollbackWorkerUpgrade(tx *gorm.DB, upgrade *models.SysWorkerUpgrade) error {
+	var worker models.SysWorker
+	if err := tx.Where("id = ?", upgrade.WorkerId).First(&worker).Error; err != nil {
+		return err
+	}
+	if worker.Version == upgrade.Version {
+		err := tx.Model(&models.SysWorker{}).Where("id = ?", worker.Id).
+			Updates(map[string]any{"version": upgrade.PrevVersion, "deploy_cmd": upgrade.PrevDeployCmd}).Error
+		if err != nil {
+			return err
+		}
+	}
+	worker.Version = upgrade.PrevVersion
+	worker.DeployCmd = upgrade.PrevDeployCmd
+
+	// 升级失败的机器也可能已部署了部分文件, 一并回滚
+	upgradeNodes := make([]models.SysWorkerUpgradeNode, 0)
+	for _, node := range upgrade.Nodes { //nolint:gocritic
+		if *node.Status == models.SysWorkerUpgradeNodeSuccess || *node.Status == models.SysWorkerUpgradeNodeFailed {
+			upgradeNodes = append(upgradeNodes, node)
+		}
+	}
+	nodes, err := getWorkerUpgradeSysNodes(tx, upgradeNodes)
+	if err != nil {
+		return err
+	}
+	failed := make([]string, 0)
+	for _, result := range deployWorkerOnNodes(tx, nodes, &worker, WorkerActionRedeploy) { //nolint:gocritic
+		updates := map[string]any{"status": models.SysWorkerUpgradeNodeRolledBack, "error": ""}
+		if !result.Success {
+			failed = append(failed, result.Address)
+			updates = map[string]any{"error": "rollback: " + result.Error}
+		}
+		err = tx.Model(&models.SysWorkerUpgradeNode{}).Where("upgrade_id = ? AND node_id = ?", upgrade.Id, result.NodeId).
+			Updates(updates).Error
+		if err != nil {
+			return err
+		}
+	}
+	// 保留触发回滚的原因
+	lastError := upgrade.LastError
+	if len(failed) > 0 {
+		lastError = strings.TrimPrefix(fmt.Sprintf("%s; rollback failed on %s", lastError, strings.Join(failed, ",")), "; ")
+	}
+	global.Log.Infof("[worker升级]升级任务%d已回滚到%s", upgrade.Id, upgrade.PrevVersion)
+	return updateWorkerUpgradeStatus(tx, upgrade.Id, models.SysWorkerUpgradeRolledBack, lastError)
+}
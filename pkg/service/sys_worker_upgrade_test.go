@@ -0,0 +1,128 @@
+package service
+
+import (
+	"metalflow/models"
+	"metalflow/pkg/global"
+	tests2 "metalflow/tests"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSplitWorkerUpgradeBatches(t *testing.T) {
+	nodes := make([]models.SysNode, 0)
+	for i := uint(1); i <= 6; i++ {
+		nodes = append(nodes, models.SysNode{Model: models.Model{Id: i}})
+	}
+	tests := []struct {
+		name      string
+		canaryIds []uint
+		batchSize uint
+		want      []uint // 各机器所在批次
+		wantCount uint
+	}{
+		{
+			name:      "first node as canary",
+			batchSize: 2,
+			want:      []uint{0, 1, 1, 2, 2, 3},
+			wantCount: 4,
+		},
+		{
+			name:      "selected canaries",
+			canaryIds: []uint{3, 5, 100},
+			batchSize: 3,
+			want:      []uint{1, 1, 0, 1, 0, 2},
+			wantCount: 3,
+		},
+		{
+			name:      "unknown canaries",
+			canaryIds: []uint{100},
+			batchSize: 10,
+			want:      []uint{0, 1, 1, 1, 1, 1},
+			wantCount: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, count := splitWorkerUpgradeBatches(nodes, tt.canaryIds, tt.batchSize)
+			if count != tt.wantCount {
+				t.Errorf("splitWorkerUpgradeBatches() count = %v, want %v", count, tt.wantCount)
+			}
+			for i := range got {
+				if got[i].Batch != tt.want[i] {
+					t.Errorf("splitWorkerUpgradeBatches() node %d batch = %v, want %v", got[i].NodeId, got[i].Batch, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMysqlService_PauseWorkerUpgrade(t *testing.T) {
+	mock := tests2.GetMock()
+	s := New(nil)
+	tests := []struct {
+		name    string
+		invoke  func()
+		wantErr bool
+	}{
+		{
+			name: "not running",
+			invoke: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `tb_sys_worker_upgrade` SET").
+					WithArgs(models.SysWorkerUpgradePaused, sqlmock.AnyArg(), 1, models.SysWorkerUpgradeRunning).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantErr: true,
+		},
+		{
+			name: "success",
+			invoke: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `tb_sys_worker_upgrade` SET").
+					WithArgs(models.SysWorkerUpgradePaused, sqlmock.AnyArg(), 1, models.SysWorkerUpgradeRunning).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invoke()
+			if err := s.PauseWorkerUpgrade(1); (err != nil) != tt.wantErr {
+				t.Errorf("PauseWorkerUpgrade() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunWorkerUpgradeBatch_ExceedThreshold(t *testing.T) {
+	mock := tests2.GetMock()
+	tests2.SetLog()
+	onFailure := models.SysWorkerUpgradeOnFailureRollback
+	pending := models.SysWorkerUpgradeNodePending
+	upgrade := &models.SysWorkerUpgrade{Model: models.Model{Id: 1}, WorkerId: 2, BatchCount: 2, OnFailure: &onFailure}
+	upgrade.Nodes = []models.SysWorkerUpgradeNode{{Model: models.Model{Id: 3}, NodeId: 4, Status: &pending}}
+	mock.ExpectQuery("SELECT (.*) FROM `tb_sys_worker`").WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "metaltune"))
+	// 机器已被删除, 升级失败
+	mock.ExpectQuery("SELECT (.*) FROM `tb_sys_node`").WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `tb_sys_worker_upgrade_node` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// 执行期间被暂停或取消的任务不覆盖为回滚
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `tb_sys_worker_upgrade` SET (.*) WHERE id = \\? AND status IN \\(\\?\\)").
+		WithArgs(sqlmock.AnyArg(), models.SysWorkerUpgradeRollingBack, sqlmock.AnyArg(), 1, models.SysWorkerUpgradeRunning).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	if err := runWorkerUpgradeBatch(global.Mysql, upgrade); err != nil {
+		t.Errorf("runWorkerUpgradeBatch() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("runWorkerUpgradeBatch() %v", err)
+	}
+}
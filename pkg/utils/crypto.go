@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+// EncryptString 使用主密钥以AES-256-GCM加密, 返回base64编码的密文(随机nonce在前), 明文为空时返回空
+func EncryptString(key, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	gcm, err := newGcm(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+// DecryptString 解密EncryptString加密的密文, 密文为空时返回空
+func DecryptString(key, ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+	gcm, err := newGcm(key)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("the ciphertext is too short")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt, the master key may have been changed")
+	}
+	return string(plaintext), nil
+}
+
+// newGcm 由主密钥的sha256摘要作为AES-256密钥
+func newGcm(key string) (cipher.AEAD, error) {
+	if key == "" {
+		return nil, errors.New("the master key is not configured")
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
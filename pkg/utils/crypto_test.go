@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestEncryptString(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		decryptKey string
+		plaintext  string
+		wantErr    bool
+	}{
+		{
+			name:       "success",
+			key:        "master",
+			decryptKey: "master",
+			plaintext:  "p@ssw0rd",
+			wantErr:    false,
+		},
+		{
+			name:       "empty plaintext",
+			key:        "master",
+			decryptKey: "master",
+			plaintext:  "",
+			wantErr:    false,
+		},
+		{
+			name:       "wrong key",
+			key:        "master",
+			decryptKey: "other",
+			plaintext:  "p@ssw0rd",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ciphertext, err := EncryptString(tt.key, tt.plaintext)
+			if err != nil {
+				t.Fatalf("EncryptString() error = %v", err)
+			}
+			if tt.plaintext != "" && ciphertext == tt.plaintext {
+				t.Fatalf("EncryptString() = %q, the plaintext is not encrypted", ciphertext)
+			}
+			got, err := DecryptString(tt.decryptKey, ciphertext)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecryptString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.plaintext {
+				t.Errorf("DecryptString() = %q, want %q", got, tt.plaintext)
+			}
+		})
+	}
+	if _, err := EncryptString("", "p@ssw0rd"); err == nil {
+		t.Errorf("EncryptString() without master key error = nil, wantErr true")
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if _, err = ParsePrivateKey(string(data), ""); err != nil {
+		t.Errorf("ParsePrivateKey() error = %v", err)
+	}
+	if _, err = ParsePrivateKey("not a key", ""); err == nil {
+		t.Errorf("ParsePrivateKey() error = nil, wantErr true")
+	}
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 标签选择器支持的操作符
+const (
+	SelectorEquals    = "="      // key=value
+	SelectorNotEquals = "!="     // key!=value
+	SelectorIn        = "in"     // key in (v1,v2)
+	SelectorNotIn     = "notin"  // key notin (v1,v2)
+	SelectorExists    = "exists" // key
+	SelectorNotExists = "!"      // !key
+)
+
+// SelectorRequirement 标签选择器中的一个条件, 多个条件之间为且的关系
+type SelectorRequirement struct {
+	Key      string
+	Operator string
+	Values   []string
+}
+
+var (
+	selectorSetReg = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+	// 键和值中不允许出现选择器的保留字符
+	selectorReserved = ",()=! \t"
+)
+
+// ParseLabel 将"key=value"格式的标签拆分为键和值, 没有等号时整体作为键
+func ParseLabel(label string) (key, value string) {
+	label = strings.TrimSpace(label)
+	if i := strings.Index(label, "="); i >= 0 {
+		return strings.TrimSpace(label[:i]), strings.TrimSpace(label[i+1:])
+	}
+	return label, ""
+}
+
+// FormatLabel 将标签的键值拼接为"key=value"格式, 值为空时只返回键
+func FormatLabel(key, value string) string {
+	if value == "" {
+		return key
+	}
+	return key + "=" + value
+}
+
+// ValidateLabel 校验标签的键值是否合法
+func ValidateLabel(key, value string) error {
+	if key == "" {
+		return fmt.Errorf("the label key cannot be empty")
+	}
+	if strings.ContainsAny(key, selectorReserved) {
+		return fmt.Errorf("the label key [%s] cannot contain any of [%s]", key, selectorReserved)
+	}
+	if strings.ContainsAny(value, selectorReserved) {
+		return fmt.Errorf("the label value [%s] cannot contain any of [%s]", value, selectorReserved)
+	}
+	return nil
+}
+
+// ParseSelector 解析标签选择器, 如"env=ci,arch in (x86,arm),!deprecated"
+func ParseSelector(selector string) ([]SelectorRequirement, error) {
+	requirements := make([]SelectorRequirement, 0)
+	terms, err := splitSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, term := range terms {
+		var r SelectorRequirement
+		switch {
+		case strings.HasPrefix(term, "!") && !strings.Contains(term, "="):
+			r = SelectorRequirement{Key: strings.TrimSpace(term[1:]), Operator: SelectorNotExists}
+		case selectorSetReg.MatchString(term):
+			strs := selectorSetReg.FindStringSubmatch(term)
+			r = SelectorRequirement{Key: strs[1], Operator: strs[2]}
+			for _, v := range strings.Split(strs[3], ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					r.Values = append(r.Values, v)
+				}
+			}
+			if len(r.Values) == 0 {
+				return nil, fmt.Errorf("the selector [%s] has no values", term)
+			}
+		case strings.Contains(term, "!="):
+			i := strings.Index(term, "!=")
+			r = SelectorRequirement{
+				Key:      strings.TrimSpace(term[:i]),
+				Operator: SelectorNotEquals,
+				Values:   []string{strings.TrimSpace(term[i+2:])},
+			}
+		case strings.Contains(term, "="):
+			key, value := ParseLabel(strings.Replace(term, "==", "=", 1))
+			r = SelectorRequirement{Key: key, Operator: SelectorEquals, Values: []string{value}}
+		default:
+			r = SelectorRequirement{Key: term, Operator: SelectorExists}
+		}
+		if err = ValidateLabel(r.Key, ""); err != nil {
+			return nil, fmt.Errorf("invalid selector [%s]: %v", term, err)
+		}
+		for _, v := range r.Values {
+			if err = ValidateLabel(r.Key, v); err != nil {
+				return nil, fmt.Errorf("invalid selector [%s]: %v", term, err)
+			}
+		}
+		requirements = append(requirements, r)
+	}
+	return requirements, nil
+}
+
+// splitSelector 按不在括号内的逗号拆分选择器
+func splitSelector(selector string) ([]string, error) {
+	terms := make([]string, 0)
+	depth, start := 0, 0
+	appendTerm := func(term string) {
+		if term = strings.TrimSpace(term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("the selector [%s] has unbalanced parentheses", selector)
+			}
+		case ',':
+			if depth == 0 {
+				appendTerm(selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("the selector [%s] has unbalanced parentheses", selector)
+	}
+	appendTerm(selector[start:])
+	return terms, nil
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		want     []SelectorRequirement
+		wantErr  bool
+	}{
+		{
+			name:     "all operators",
+			selector: "env=ci, arch in (x86, arm),!deprecated,region!=上海,gpu,os notin (windows)",
+			want: []SelectorRequirement{
+				{Key: "env", Operator: SelectorEquals, Values: []string{"ci"}},
+				{Key: "arch", Operator: SelectorIn, Values: []string{"x86", "arm"}},
+				{Key: "deprecated", Operator: SelectorNotExists},
+				{Key: "region", Operator: SelectorNotEquals, Values: []string{"上海"}},
+				{Key: "gpu", Operator: SelectorExists},
+				{Key: "os", Operator: SelectorNotIn, Values: []string{"windows"}},
+			},
+		},
+		{
+			name:     "double equals",
+			selector: "env==ci",
+			want:     []SelectorRequirement{{Key: "env", Operator: SelectorEquals, Values: []string{"ci"}}},
+		},
+		{
+			name:     "empty",
+			selector: " ",
+			want:     []SelectorRequirement{},
+		},
+		{
+			name:     "unbalanced",
+			selector: "arch in (x86,arm",
+			wantErr:  true,
+		},
+		{
+			name:     "empty set",
+			selector: "arch in ()",
+			wantErr:  true,
+		},
+		{
+			name:     "empty key",
+			selector: "=ci",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSelector(tt.selector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSelector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSelector() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLabel(t *testing.T) {
+	tests := []struct {
+		label, key, value string
+	}{
+		{label: "env=ci", key: "env", value: "ci"},
+		{label: " gpu ", key: "gpu", value: ""},
+		{label: "a=b=c", key: "a", value: "b=c"},
+	}
+	for _, tt := range tests {
+		key, value := ParseLabel(tt.label)
+		if key != tt.key || value != tt.value {
+			t.Errorf("ParseLabel(%q) = %q, %q, want %q, %q", tt.label, key, value, tt.key, tt.value)
+		}
+	}
+}
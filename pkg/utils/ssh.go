@@ -1,20 +1,28 @@
 package utils
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"golang.org/x/crypto/ssh"
-	"net"
+	"io"
 	"strings"
+	"sync"
 	"time"
 )
 
 type SshConfig struct {
-	Address  string
-	Port     int
-	Username string
-	Password string
-	Protocol string
-	Timeout  int // 默认ssh连接超时时间
+	Address    string
+	Port       int
+	Username   string
+	Password   string
+	PrivateKey string // PEM格式的私钥, 设置后优先使用密钥认证
+	Passphrase string // 私钥的密码
+	Protocol   string
+	Timeout    int // 默认ssh连接超时时间
+	// 主机公钥校验, 必须设置
+	HostKeyCallback   ssh.HostKeyCallback
+	HostKeyAlgorithms []string
 }
 
 // Option 尝试一下go编程范式Functional Options: https://coolshell.cn/articles/21146.html#Functional_Options
@@ -32,6 +40,22 @@ func Protocol(p string) Option {
 	}
 }
 
+// PrivateKey 使用私钥认证, 同时设置了密码时私钥认证失败后再尝试密码认证
+func PrivateKey(key, passphrase string) Option {
+	return func(config *SshConfig) {
+		config.PrivateKey = key
+		config.Passphrase = passphrase
+	}
+}
+
+// HostKey 校验主机公钥, algorithms为已知公钥对应的算法, 使服务端优先提供已知类型的公钥
+func HostKey(callback ssh.HostKeyCallback, algorithms []string) Option {
+	return func(config *SshConfig) {
+		config.HostKeyCallback = callback
+		config.HostKeyAlgorithms = algorithms
+	}
+}
+
 func NewSshConfig(addr string, port int, username, password string, options ...Option) *SshConfig {
 	sshConfig := SshConfig{
 		Address:  addr,
@@ -57,18 +81,31 @@ func GetSshClient(config *SshConfig) (*ssh.Client, error) {
 		err          error
 	)
 
+	// 不接受未校验主机公钥的连接
+	if config.HostKeyCallback == nil {
+		return nil, errors.New("the host key callback is required")
+	}
+
 	auth = make([]ssh.AuthMethod, 0)
-	auth = append(auth, ssh.Password(config.Password))
+	if config.PrivateKey != "" {
+		var signer ssh.Signer
+		signer, err = ParsePrivateKey(config.PrivateKey, config.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if config.PrivateKey == "" || config.Password != "" {
+		auth = append(auth, ssh.Password(config.Password))
+	}
 
 	clientConfig = &ssh.ClientConfig{
-		User:    config.Username,
-		Auth:    auth,
-		Timeout: time.Second * time.Duration(config.Timeout),
-		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-			return nil
-		},
+		User:              config.Username,
+		Auth:              auth,
+		Timeout:           time.Second * time.Duration(config.Timeout),
+		HostKeyCallback:   config.HostKeyCallback,
+		HostKeyAlgorithms: config.HostKeyAlgorithms,
 	}
-
 	// connect to ssh
 	addr = fmt.Sprintf("%s:%d", config.Address, config.Port)
 	client, err = ssh.Dial(config.Protocol, addr, clientConfig)
@@ -78,6 +115,35 @@ func GetSshClient(config *SshConfig) (*ssh.Client, error) {
 	return client, nil
 }
 
+// ParsePrivateKey 解析PEM格式的私钥, 私钥有密码时需传passphrase
+func ParsePrivateKey(key, passphrase string) (ssh.Signer, error) {
+	var (
+		signer ssh.Signer
+		err    error
+	)
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(key), []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey([]byte(key))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("私钥解析失败: %v", err)
+	}
+	return signer, nil
+}
+
+// HostKeyAlgorithms 获取公钥类型对应的主机公钥算法, rsa公钥可使用sha2签名算法
+func HostKeyAlgorithms(keyTypes []string) []string {
+	algorithms := make([]string, 0, len(keyTypes))
+	for _, keyType := range keyTypes {
+		if keyType == ssh.KeyAlgoRSA {
+			algorithms = append(algorithms, ssh.SigAlgoRSASHA2512, ssh.SigAlgoRSASHA2256)
+		}
+		algorithms = append(algorithms, keyType)
+	}
+	return algorithms
+}
+
 // IsSafetyCmd 判断命令是否运行的安全命令
 func IsSafetyCmd(cmd string) error {
 	// 避免rm * 或 rm /*等命令直接出现, 删除命令指定全路径
@@ -94,3 +160,42 @@ func IsSafetyCmd(cmd string) error {
 	}
 	return nil
 }
+
+// RunSshCommand 在ssh连接上执行命令并等待结束, 按行回调标准输出与错误输出
+// 命令退出码非0时返回*ssh.ExitError
+func RunSshCommand(client *ssh.Client, cmd string, stdin io.Reader, onLine func(stderr bool, line string)) error {
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("建立ssh会话失败: %v", err)
+	}
+	defer func() {
+		_ = session.Close()
+	}()
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := session.StderrPipe()
+	if err != nil {
+		return err
+	}
+	session.Stdin = stdin
+	if err = session.Start(cmd); err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	scan := func(r io.Reader, isStderr bool) {
+		defer wg.Done()
+		scanner := bufio.NewScanner(r)
+		// 单行最长1MB, 避免输出很长的行时中断
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024) //nolint:gomnd
+		for scanner.Scan() {
+			onLine(isStderr, scanner.Text())
+		}
+	}
+	wg.Add(2) //nolint:gomnd
+	go scan(stdout, false)
+	go scan(stderr, true)
+	wg.Wait()
+	return session.Wait()
+}
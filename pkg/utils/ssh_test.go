@@ -33,3 +33,23 @@ func TestIsSafetyCmd(t *testing.T) {
 		})
 	}
 }
+
+func TestHostKeyAlgorithms(t *testing.T) {
+	got := HostKeyAlgorithms([]string{"ssh-ed25519", "ssh-rsa"})
+	want := []string{"ssh-ed25519", "rsa-sha2-512", "rsa-sha2-256", "ssh-rsa"}
+	if len(got) != len(want) {
+		t.Fatalf("HostKeyAlgorithms() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("HostKeyAlgorithms() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestGetSshClientWithoutHostKey(t *testing.T) {
+	config := NewSshConfig("127.0.0.1", 22, "root", "123456")
+	if _, err := GetSshClient(config); err == nil {
+		t.Errorf("GetSshClient() error = nil, want the host key callback is required")
+	}
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	ipv4Bits = 32
+	ipv6Bits = 128
+)
+
+// NormalizeCidr 将网段统一为CIDR格式, 支持:
+// CIDR(10.12.0.0/16, fd00::/8), 单个ip(10.12.0.1, 转为/32或/128)
+// 以及旧配置中的ipv4前缀(10.12或10.12., 按段数转为/8 /16 /24)
+func NormalizeCidr(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", fmt.Errorf("the cidr is empty")
+	}
+	if strings.Contains(s, "/") {
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return "", fmt.Errorf("the cidr [%s] is incorrect", s)
+		}
+		return ipNet.String(), nil
+	}
+	if ip := net.ParseIP(s); ip != nil {
+		if ip.To4() != nil {
+			return fmt.Sprintf("%s/%d", ip.String(), ipv4Bits), nil
+		}
+		return fmt.Sprintf("%s/%d", ip.String(), ipv6Bits), nil
+	}
+	// ipv4前缀
+	parts := strings.Split(strings.TrimSuffix(s, "."), ".")
+	if len(parts) > 3 { //nolint:gomnd
+		return "", fmt.Errorf("the cidr [%s] is incorrect", s)
+	}
+	octets := []string{"0", "0", "0", "0"}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || n > 255 {
+			return "", fmt.Errorf("the cidr [%s] is incorrect", s)
+		}
+		octets[i] = strconv.Itoa(n)
+	}
+	return fmt.Sprintf("%s/%d", strings.Join(octets, "."), len(parts)*8), nil //nolint:gomnd
+}
+
+// MatchCidr 返回包含ip且前缀最长的网段下标, 没有匹配时返回-1
+func MatchCidr(ip string, cidrs []string) int {
+	addr := net.ParseIP(strings.TrimSpace(ip))
+	if addr == nil {
+		return -1
+	}
+	index, longest := -1, -1
+	for i, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil || !ipNet.Contains(addr) {
+			continue
+		}
+		if ones, _ := ipNet.Mask.Size(); ones > longest {
+			index, longest = i, ones
+		}
+	}
+	return index
+}
@@ -0,0 +1,44 @@
+package utils
+
+import "testing"
+
+func TestNormalizeCidr(t *testing.T) {
+	tests := []struct {
+		cidr    string
+		want    string
+		wantErr bool
+	}{
+		{cidr: "10.12", want: "10.12.0.0/16"},
+		{cidr: "10.12.3.", want: "10.12.3.0/24"},
+		{cidr: "10.12.3.4/20", want: "10.12.0.0/20"},
+		{cidr: "10.12.3.4", want: "10.12.3.4/32"},
+		{cidr: "fd00:1::/32", want: "fd00:1::/32"},
+		{cidr: "fd00::1", want: "fd00::1/128"},
+		{cidr: "10.256", wantErr: true},
+		{cidr: "10.1.2.3.4", wantErr: true},
+		{cidr: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeCidr(tt.cidr)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeCidr(%s) = %s, %v, want %s, wantErr %v", tt.cidr, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestMatchCidr(t *testing.T) {
+	cidrs := []string{"10.1.0.0/16", "10.12.0.0/16", "10.12.34.0/24", "fd00::/8"}
+	tests := map[string]int{
+		"10.1.2.3":   0,
+		"10.12.2.3":  1,
+		"10.12.34.5": 2,
+		"fd00::1":    3,
+		"10.123.0.1": -1,
+		"unknown":    -1,
+	}
+	for ip, want := range tests {
+		if got := MatchCidr(ip, cidrs); got != want {
+			t.Errorf("MatchCidr(%s) = %d, want %d", ip, got, want)
+		}
+	}
+}
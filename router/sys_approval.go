@@ -0,0 +1,25 @@
+package router
+
+import (
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	v1 "metalflow/api/v1"
+)
+
+// InitApprovalRouter 审批策略及变更申请路由
+func InitApprovalRouter(r *gin.RouterGroup, authMiddleware *jwt.GinJWTMiddleware) (i gin.IRoutes) {
+	router1 := GetCasbinRouter(r, authMiddleware, "/approval")
+	router2 := GetCasbinAndIdempotenceRouter(r, authMiddleware, "/approval")
+	{ // nolint:gocritic
+		router1.GET("/policy/list", v1.GetApprovalPolicies)
+		router2.POST("/policy/create", v1.CreateApprovalPolicy)
+		router1.PATCH("/policy/update/:policyId", v1.UpdateApprovalPolicyById)
+		router1.DELETE("/policy/delete/batch", v1.BatchDeleteApprovalPolicyByIds)
+		router1.GET("/request/list", v1.GetChangeRequests)
+		router1.GET("/request/detail/:requestId", v1.GetChangeRequestById)
+		router1.POST("/request/approve/:requestId", v1.ApproveChangeRequest)
+		router1.POST("/request/reject/:requestId", v1.RejectChangeRequest)
+		router1.POST("/request/cancel/:requestId", v1.CancelChangeRequest)
+	}
+	return r
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	v1 "metalflow/api/v1"
+)
+
+// InitCredentialRouter ssh凭据路由
+func InitCredentialRouter(r *gin.RouterGroup, authMiddleware *jwt.GinJWTMiddleware) (i gin.IRoutes) {
+	router1 := GetCasbinRouter(r, authMiddleware, "/credential")
+	router2 := GetCasbinAndIdempotenceRouter(r, authMiddleware, "/credential")
+	{ // nolint:gocritic
+		router1.GET("/list", v1.GetCredentials)
+		router2.POST("/create", v1.CreateCredential)
+		router1.PATCH("/update/:credentialId", v1.UpdateCredentialById)
+		router1.DELETE("/delete/batch", v1.BatchDeleteCredentialByIds)
+	}
+	return r
+}
@@ -27,6 +27,28 @@ func InitMachineRouter(r *gin.RouterGroup, authMiddleware *jwt.GinJWTMiddleware)
 		router1.PATCH("/update/:nodeId", v1.UpdateNodeById)
 		router1.DELETE("/delete/batch", v1.BatchDeleteNodeByIds)
 		router1.PATCH("/refresh/:nodeId", v1.RefreshNodeInfo)
+		router1.GET("/metrics/history/:nodeId", v1.GetNodeMetricsHistory)
+		router1.GET("/change/list", v1.GetNodeChanges)
+		router1.GET("/change/list/:nodeId", v1.GetNodeChanges)
+		router1.POST("/maintenance/enter", v1.EnterNodeMaintenance)
+		router1.POST("/maintenance/exit", v1.ExitNodeMaintenance)
+		router1.POST("/import", v1.ImportNodes)
+		router1.GET("/export", v1.ExportNodes)
+		router1.GET("/recycle/list", v1.GetRecycledNodes)
+		router1.PATCH("/recycle/restore", v1.RestoreNodeByIds)
+		router1.DELETE("/recycle/purge", v1.PurgeNodeByIds)
+		router1.GET("/deny/list", v1.GetNodeDenies)
+		router2.POST("/deny/create", v1.CreateNodeDeny)
+		router1.PATCH("/deny/update/:denyId", v1.UpdateNodeDenyById)
+		router1.DELETE("/deny/delete/batch", v1.BatchDeleteNodeDenyByIds)
+		router1.GET("/worker/matrix", v1.GetNodeWorkerMatrix)
+		router1.PATCH("/worker/check/:nodeId", v1.CheckNodeWorkers)
+		router1.GET("/command/list", v1.GetCommandJobs)
+		router1.GET("/command/detail/:jobId", v1.GetCommandJobById)
+		router2.POST("/command/create", v1.CreateCommandJob)
+		router1.GET("/command/download/:jobId", v1.DownloadCommandJobOutput)
+		router1.GET("/command/ws/:jobId", v1.WatchCommandJobWs)
+		router1.GET("/command/stream/:jobId", v1.WatchCommandJobSse)
 	}
 	return r
 }
@@ -0,0 +1,19 @@
+package router
+
+import (
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	v1 "metalflow/api/v1"
+)
+
+// InitNodeHostKeyRouter 机器ssh主机公钥路由
+func InitNodeHostKeyRouter(r *gin.RouterGroup, authMiddleware *jwt.GinJWTMiddleware) (i gin.IRoutes) {
+	router1 := GetCasbinRouter(r, authMiddleware, "/hostkey")
+	{ // nolint:gocritic
+		router1.GET("/list", v1.GetNodeHostKeys)
+		router1.POST("/accept/:keyId", v1.AcceptNodeHostKey)
+		router1.POST("/import", v1.ImportKnownHosts)
+		router1.DELETE("/delete/batch", v1.BatchDeleteNodeHostKeyByIds)
+	}
+	return r
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	v1 "metalflow/api/v1"
+)
+
+// InitReservationRouter 机器预约路由
+func InitReservationRouter(r *gin.RouterGroup, authMiddleware *jwt.GinJWTMiddleware) (i gin.IRoutes) {
+	router1 := GetCasbinRouter(r, authMiddleware, "/reservation")
+	router2 := GetCasbinAndIdempotenceRouter(r, authMiddleware, "/reservation")
+	{ // nolint:gocritic
+		router1.GET("/list", v1.GetNodeReservations)
+		router2.POST("/create", v1.CreateNodeReservation)
+		router1.PATCH("/renew/:reservationId", v1.RenewNodeReservation)
+		router1.PATCH("/release/:reservationId", v1.ReleaseNodeReservation)
+	}
+	return r
+}
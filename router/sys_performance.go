@@ -0,0 +1,21 @@
+package router
+
+import (
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	v1 "metalflow/api/v1"
+)
+
+// InitPerformanceRouter 性能评级规则路由
+func InitPerformanceRouter(r *gin.RouterGroup, authMiddleware *jwt.GinJWTMiddleware) (i gin.IRoutes) {
+	router1 := GetCasbinRouter(r, authMiddleware, "/performance")
+	router2 := GetCasbinAndIdempotenceRouter(r, authMiddleware, "/performance")
+	{ // nolint:gocritic
+		router1.GET("/profile/list", v1.GetPerformanceProfiles)
+		router2.POST("/profile/create", v1.CreatePerformanceProfile)
+		router1.PATCH("/profile/update/:profileId", v1.UpdatePerformanceProfileById)
+		router1.DELETE("/profile/delete/batch", v1.BatchDeletePerformanceProfileByIds)
+		router1.POST("/recompute", v1.RecomputeNodePerformance)
+	}
+	return r
+}
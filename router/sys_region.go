@@ -0,0 +1,21 @@
+package router
+
+import (
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	v1 "metalflow/api/v1"
+)
+
+// InitRegionRouter 地域网段路由
+func InitRegionRouter(r *gin.RouterGroup, authMiddleware *jwt.GinJWTMiddleware) (i gin.IRoutes) {
+	router1 := GetCasbinRouter(r, authMiddleware, "/region")
+	router2 := GetCasbinAndIdempotenceRouter(r, authMiddleware, "/region")
+	{ // nolint:gocritic
+		router1.GET("/list", v1.GetRegions)
+		router2.POST("/create", v1.CreateRegion)
+		router1.PATCH("/update/:regionId", v1.UpdateRegionById)
+		router1.DELETE("/delete/batch", v1.BatchDeleteRegionByIds)
+		router1.POST("/recompute", v1.RecomputeNodeRegions)
+	}
+	return r
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	v1 "metalflow/api/v1"
+)
+
+// InitScriptRouter 脚本库路由
+func InitScriptRouter(r *gin.RouterGroup, authMiddleware *jwt.GinJWTMiddleware) (i gin.IRoutes) {
+	router1 := GetCasbinRouter(r, authMiddleware, "/script")
+	router2 := GetCasbinAndIdempotenceRouter(r, authMiddleware, "/script")
+	{ // nolint:gocritic
+		router1.GET("/list", v1.GetScripts)
+		router1.GET("/detail/:scriptId", v1.GetScriptById)
+		router2.POST("/create", v1.CreateScript)
+		router1.PATCH("/update/:scriptId", v1.UpdateScriptById)
+		router1.DELETE("/delete/batch", v1.BatchDeleteScriptByIds)
+		router1.GET("/versions/:scriptId", v1.GetScriptVersions)
+		router1.GET("/diff/:scriptId", v1.DiffScriptVersions)
+		router1.POST("/run/:scriptId", v1.RunScript)
+	}
+	return r
+}
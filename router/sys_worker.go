@@ -12,9 +12,25 @@ func InitWorkerRouter(r *gin.RouterGroup, authMiddleware *jwt.GinJWTMiddleware)
 	router2 := GetCasbinAndIdempotenceRouter(r, authMiddleware, "/worker")
 	{ // nolint:gocritic
 		router1.GET("/list", v1.GetWorkers)
+		router1.GET("/role/:role", v1.GetWorkerByRole)
 		router2.POST("/create", v1.CreateWorker)
 		router1.PATCH("/update/:workerId", v1.UpdateWorkerById)
 		router1.DELETE("/delete/batch", v1.BatchDeleteWorkerByIds)
+		router1.POST("/deploy/:workerId", v1.DeployWorker)
+		router1.POST("/redeploy/:workerId", v1.RedeployWorker)
+		router1.POST("/undeploy/:workerId", v1.UndeployWorker)
+		router1.GET("/upgrade/list", v1.GetWorkerUpgrades)
+		router1.GET("/upgrade/detail/:upgradeId", v1.GetWorkerUpgradeById)
+		router2.POST("/upgrade/create", v1.CreateWorkerUpgrade)
+		router1.PATCH("/upgrade/start/:upgradeId", v1.StartWorkerUpgrade)
+		router1.PATCH("/upgrade/pause/:upgradeId", v1.PauseWorkerUpgrade)
+		router1.PATCH("/upgrade/rollback/:upgradeId", v1.RollbackWorkerUpgrade)
+		router1.PATCH("/upgrade/cancel/:upgradeId", v1.CancelWorkerUpgrade)
+		router1.POST("/cmd/preview", v1.PreviewWorkerCmd)
+		router1.GET("/var/list", v1.GetDeployVars)
+		router2.POST("/var/create", v1.CreateDeployVar)
+		router1.PATCH("/var/update/:varId", v1.UpdateDeployVarById)
+		router1.DELETE("/var/delete/batch", v1.BatchDeleteDeployVarByIds)
 	}
 	return r
 }